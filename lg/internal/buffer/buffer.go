@@ -4,89 +4,282 @@ import (
 	"sync"
 
 	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
 )
 
-// Buffer is a thread-safe ring buffer for log entries
+// DefaultCapacity is the number of entries kept in memory by default
+const DefaultCapacity = 10000
+
+// Buffer is a thread-safe ring buffer for log entries. The newest entries
+// are kept in memory; with disk history enabled, older ones are moved to
+// temporary files instead of being dropped, so the whole session stays
+// available through Len, Get and the filters.
+//
+// Every entry gets a sequence number, in order of arrival, that stays the
+// same while it is in the buffer, even as older entries are dropped.
+// Callers that follow the stream use these with Scan and GetSeq instead of
+// indexes, which shift.
 type Buffer struct {
-	entries  []*parser.LogEntry
+	ring     []*parser.LogEntry // Fixed-size ring of the newest entries
+	head     int                // Index of the oldest entry in ring
+	count    int                // Entries in ring
 	capacity int
+	next     uint64 // Sequence number of the next entry
+	disk     *spill // nil when history is kept in memory only
+	err      error  // First disk error; history then falls back to memory
+	dropped  uint64 // Entries dropped for lack of room
 	mu       sync.RWMutex
 }
 
-// New creates a new Buffer with the specified capacity
+// New creates a new in-memory Buffer with the specified capacity. Once
+// full, the oldest entry is dropped for every new one.
 func New(capacity int) *Buffer {
 	if capacity <= 0 {
-		capacity = 10000
+		capacity = DefaultCapacity
 	}
 	return &Buffer{
-		entries:  make([]*parser.LogEntry, 0, capacity),
+		ring:     make([]*parser.LogEntry, capacity),
 		capacity: capacity,
 	}
 }
 
+// NewWithDisk creates a Buffer that keeps capacity entries in memory and
+// moves older ones to temporary files, using at most maxDisk bytes before
+// the oldest history is dropped. A maxDisk of zero keeps memory only. Close
+// removes the files.
+func NewWithDisk(capacity int, maxDisk int64) (*Buffer, error) {
+	b := New(capacity)
+	if maxDisk <= 0 {
+		return b, nil
+	}
+	disk, err := newSpill(maxDisk, b.capacity)
+	if err != nil {
+		return nil, err
+	}
+	b.disk = disk
+	return b, nil
+}
+
 // Add adds a new entry to the buffer
 func (b *Buffer) Add(entry *parser.LogEntry) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if len(b.entries) >= b.capacity {
-		// Remove oldest entry
-		b.entries = b.entries[1:]
+	b.next++
+	if b.count < b.capacity {
+		b.ring[(b.head+b.count)%b.capacity] = entry
+		b.count++
+		return
 	}
-	b.entries = append(b.entries, entry)
+
+	// Full: the oldest entry moves to disk (or is dropped) and its slot is
+	// reused for the new one
+	oldest := b.ring[b.head]
+	if b.disk == nil {
+		b.dropped++
+	} else if dropped, err := b.disk.add(b.next-1-uint64(b.capacity), oldest); err != nil {
+		b.dropped++
+		b.failDisk(err)
+	} else {
+		b.dropped += uint64(dropped)
+	}
+	b.ring[b.head] = entry
+	b.head = (b.head + 1) % b.capacity
 }
 
-// Entries returns a copy of all entries
-func (b *Buffer) Entries() []*parser.LogEntry {
+// failDisk records a disk error and stops using disk history, dropping it
+func (b *Buffer) failDisk(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+	b.dropped += uint64(b.disk.len())
+	b.disk.close()
+	b.disk = nil
+}
+
+// Err returns the first error from disk history, if any
+func (b *Buffer) Err() error {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	return b.err
+}
 
-	result := make([]*parser.LogEntry, len(b.entries))
-	copy(result, b.entries)
-	return result
+// Dropped returns how many of the oldest entries were dropped since the
+// buffer was created or cleared: beyond the capacity without disk history,
+// beyond maxDisk with it, or when disk history failed
+func (b *Buffer) Dropped() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.dropped
 }
 
-// Len returns the number of entries in the buffer
+// Entries returns a copy of all entries
+func (b *Buffer) Entries() []*parser.LogEntry {
+	return b.FilterQuery(nil)
+}
+
+// Len returns the number of entries in the buffer, including disk history
 func (b *Buffer) Len() int {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return len(b.entries)
+	return b.len()
+}
+
+func (b *Buffer) len() int {
+	if b.disk == nil {
+		return b.count
+	}
+	return b.disk.len() + b.count
 }
 
-// Get returns the entry at the specified index
+// Get returns the entry at the specified index, oldest first
 func (b *Buffer) Get(index int) *parser.LogEntry {
+	// Reading from disk updates the spill cache, so take the write lock
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if index < 0 || index >= b.len() {
+		return nil
+	}
+	return b.get(index)
+}
+
+// get returns an entry by index; the caller holds the write lock
+func (b *Buffer) get(index int) *parser.LogEntry {
+	if b.disk != nil {
+		if index < b.disk.len() {
+			e, err := b.disk.get(index)
+			if err != nil {
+				b.failDisk(err)
+				return nil
+			}
+			return e
+		}
+		index -= b.disk.len()
+	}
+	return b.ring[(b.head+index)%b.capacity]
+}
+
+// First returns the sequence number of the oldest entry in the buffer, or
+// Next when it is empty
+func (b *Buffer) First() uint64 {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	return b.first()
+}
 
-	if index < 0 || index >= len(b.entries) {
-		return nil
+func (b *Buffer) first() uint64 {
+	if b.disk != nil && b.disk.len() > 0 {
+		return b.disk.records[0].seq
 	}
-	return b.entries[index]
+	return b.next - uint64(b.count)
 }
 
-// Filter returns entries that match the query
-func (b *Buffer) Filter(query string) []*parser.LogEntry {
+// Next returns the sequence number the next added entry will get
+func (b *Buffer) Next() uint64 {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	return b.next
+}
+
+// GetSeq returns the entry with the given sequence number, or nil when it
+// is no longer (or not yet) in the buffer
+func (b *Buffer) GetSeq(seq uint64) *parser.LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-	if query == "" {
-		result := make([]*parser.LogEntry, len(b.entries))
-		copy(result, b.entries)
-		return result
+	first := b.first()
+	if seq < first || seq >= b.next {
+		return nil
 	}
+	return b.get(int(seq - first))
+}
 
-	var result []*parser.LogEntry
-	for _, entry := range b.entries {
-		if entry.MatchesFilter(query) {
-			result = append(result, entry)
+// Scan calls fn for every entry from sequence number from onwards, oldest
+// first, until fn returns false. It returns the sequence number to resume
+// from. fn runs with the buffer locked and must not call back into it.
+func (b *Buffer) Scan(from uint64, fn func(seq uint64, e *parser.LogEntry) bool) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seq := max(from, b.first())
+	for seq < b.next {
+		e := b.get(int(seq - b.first()))
+		if e == nil {
+			// Disk history failed and was dropped; resume in memory
+			seq = b.first()
+			continue
 		}
+		if !fn(seq, e) {
+			return seq + 1
+		}
+		seq++
+	}
+	return seq
+}
+
+// Filter returns entries that match the query. Queries that fail to
+// compile as an expression fall back to a plain substring search.
+func (b *Buffer) Filter(input string) []*parser.LogEntry {
+	q, err := query.Compile(input)
+	if err != nil {
+		q = query.Text(input)
+	}
+	return b.FilterQuery(q)
+}
+
+// FilterQuery returns entries that match a compiled query. A nil query
+// returns every entry.
+func (b *Buffer) FilterQuery(q *query.Query) []*parser.LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, ok := b.filter(q)
+	if !ok {
+		// Disk history failed and was dropped; what is left is in memory
+		result, _ = b.filter(q)
 	}
 	return result
 }
 
+// filter scans every entry, oldest first. It returns false if reading disk
+// history failed midway.
+func (b *Buffer) filter(q *query.Query) ([]*parser.LogEntry, bool) {
+	n := b.len()
+	result := make([]*parser.LogEntry, 0, n)
+	for i := 0; i < n; i++ {
+		entry := b.get(i)
+		if entry == nil {
+			return nil, false
+		}
+		if q == nil || q.Match(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result, true
+}
+
 // Clear removes all entries from the buffer
 func (b *Buffer) Clear() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.entries = b.entries[:0]
+	clear(b.ring)
+	b.head = 0
+	b.count = 0
+	b.dropped = 0
+	if b.disk != nil {
+		b.disk.reset()
+	}
+}
+
+// Close removes the disk history files
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.disk == nil {
+		return nil
+	}
+	err := b.disk.close()
+	b.disk = nil
+	return err
 }
@@ -1,9 +1,13 @@
 package buffer
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
 )
 
 func TestBuffer_AddAndGet(t *testing.T) {
@@ -34,6 +38,13 @@ func TestBuffer_Capacity(t *testing.T) {
 	if buf.Len() != capacity {
 		t.Errorf("Len() = %d, want %d (capacity)", buf.Len(), capacity)
 	}
+	if n := buf.Dropped(); n != 5 {
+		t.Errorf("Dropped() = %d, want 5", n)
+	}
+	buf.Clear()
+	if n := buf.Dropped(); n != 0 {
+		t.Errorf("Dropped() after Clear() = %d, want 0", n)
+	}
 }
 
 func TestBuffer_Entries(t *testing.T) {
@@ -109,3 +120,272 @@ func TestBuffer_GetOutOfBounds(t *testing.T) {
 		t.Error("Get(100) should return nil for index out of bounds")
 	}
 }
+
+func TestBuffer_FilterExpression(t *testing.T) {
+	buf := New(10)
+
+	buf.Add(parser.Parse(`{"level": "error", "status": 502}`))
+	buf.Add(parser.Parse(`{"level": "info", "status": 200}`))
+	buf.Add(parser.Parse(`{"level": "error", "status": 404}`))
+	buf.Add(parser.Parse(`plain text level=error`))
+
+	filtered := buf.Filter("level=error && status>=500")
+	if len(filtered) != 1 {
+		t.Errorf("Filter('level=error && status>=500') len = %d, want 1", len(filtered))
+	}
+
+	// Invalid expressions fall back to substring search
+	filtered = buf.Filter("level=error &&")
+	if len(filtered) != 0 {
+		t.Errorf("Filter('level=error &&') len = %d, want 0", len(filtered))
+	}
+
+	filtered = buf.FilterQuery(nil)
+	if len(filtered) != 4 {
+		t.Errorf("FilterQuery(nil) len = %d, want 4", len(filtered))
+	}
+}
+
+func TestBuffer_DiskHistory(t *testing.T) {
+	buf, err := NewWithDisk(3, 1<<20)
+	if err != nil {
+		t.Fatalf("NewWithDisk() error = %v", err)
+	}
+	defer buf.Close()
+
+	for i := 0; i < 10; i++ {
+		e := parser.Parse(fmt.Sprintf(`{"i": %d, "level": "info"}`, i))
+		e.Source = fmt.Sprint("source", i%2)
+		e.Highlight = fmt.Sprint("rule", i%3)
+		buf.Add(e)
+	}
+	buf.Add(parser.ParseLines([]string{"panic: boom", "goroutine 1 [running]:"}, nil))
+
+	if buf.Len() != 11 {
+		t.Fatalf("Len() = %d, want 11", buf.Len())
+	}
+	for i := 0; i < 10; i++ {
+		e := buf.Get(i)
+		if e == nil || valueOf(e, "i") != fmt.Sprint(i) || e.Level != parser.LevelInfo || e.Source != fmt.Sprint("source", i%2) || e.Highlight != fmt.Sprint("rule", i%3) {
+			t.Errorf("Get(%d) = %+v, want entry i=%d", i, e, i)
+		}
+	}
+	if e := buf.Get(10); e == nil || len(e.Continuation) != 1 {
+		t.Errorf("Get(10) = %+v, want the panic with its continuation", e)
+	}
+
+	if got := buf.Filter("i<5"); len(got) != 5 {
+		t.Errorf("Filter('i<5') len = %d, want 5", len(got))
+	}
+	if err := buf.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestBuffer_DiskLimit(t *testing.T) {
+	line := `{"msg": "` + strings.Repeat("x", 100) + `"}`
+	buf, err := NewWithDisk(1, int64(len(line)*10))
+	if err != nil {
+		t.Fatalf("NewWithDisk() error = %v", err)
+	}
+	defer buf.Close()
+
+	for i := 0; i < 100; i++ {
+		buf.Add(parser.Parse(line))
+	}
+
+	// The oldest history is dropped, but never the newest entries
+	if n := buf.Len(); n < 2 || n > 12 {
+		t.Errorf("Len() = %d, want about 10 entries on disk plus 1 in memory", n)
+	}
+	if buf.Get(buf.Len()-1) == nil {
+		t.Error("Get() of the newest entry returned nil")
+	}
+	if n := buf.Dropped(); n != uint64(100-buf.Len()) {
+		t.Errorf("Dropped() = %d, want %d", n, 100-buf.Len())
+	}
+}
+
+func TestBuffer_DiskClearAndClose(t *testing.T) {
+	buf, err := NewWithDisk(2, 1<<20)
+	if err != nil {
+		t.Fatalf("NewWithDisk() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		buf.Add(parser.Parse(fmt.Sprintf(`{"i": %d}`, i)))
+	}
+	dir := buf.disk.dir
+
+	buf.Clear()
+	if buf.Len() != 0 {
+		t.Errorf("Len() after Clear() = %d, want 0", buf.Len())
+	}
+	buf.Add(parser.Parse(`{"i": 5}`))
+	if e := buf.Get(0); e == nil || valueOf(e, "i") != "5" {
+		t.Errorf("Get(0) after Clear() = %+v, want i=5", e)
+	}
+
+	if err := buf.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("spill directory %s still exists after Close()", dir)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1048576", 1 << 20, false},
+		{"512MB", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"1.5kib", 1536, false},
+		{"100b", 100, false},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"-1G", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffer_Sequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxDisk int64
+		first   uint64 // Oldest sequence number left after adding 10
+	}{
+		{"memory", 0, 7},
+		{"disk", 1 << 20, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := NewWithDisk(3, tt.maxDisk)
+			if err != nil {
+				t.Fatalf("NewWithDisk() error = %v", err)
+			}
+			defer buf.Close()
+
+			for i := 0; i < 10; i++ {
+				buf.Add(parser.Parse(fmt.Sprintf(`{"i": %d}`, i)))
+			}
+			if buf.First() != tt.first || buf.Next() != 10 {
+				t.Fatalf("First(), Next() = %d, %d, want %d, 10", buf.First(), buf.Next(), tt.first)
+			}
+			if e := buf.GetSeq(8); e == nil || valueOf(e, "i") != "8" {
+				t.Errorf("GetSeq(8) = %+v, want i=8", e)
+			}
+			if e := buf.GetSeq(10); e != nil {
+				t.Errorf("GetSeq(10) = %+v, want nil", e)
+			}
+
+			// Scan resumes where it stopped and skips dropped entries
+			var seen []uint64
+			next := buf.Scan(0, func(seq uint64, e *parser.LogEntry) bool {
+				seen = append(seen, seq)
+				return valueOf(e, "i") != "8"
+			})
+			if next != 9 || seen[0] != tt.first || seen[len(seen)-1] != 8 {
+				t.Errorf("Scan() = %d, saw %v", next, seen)
+			}
+			if next = buf.Scan(next, func(uint64, *parser.LogEntry) bool { return true }); next != 10 {
+				t.Errorf("Scan() resumed = %d, want 10", next)
+			}
+
+			buf.Clear()
+			if buf.First() != 10 {
+				t.Errorf("First() after Clear() = %d, want 10", buf.First())
+			}
+		})
+	}
+}
+
+// benchEntries returns n parsed entries to add in benchmarks
+func benchEntries(n int) []*parser.LogEntry {
+	levels := []string{"debug", "info", "info", "warn", "error"}
+	entries := make([]*parser.LogEntry, n)
+	for i := range entries {
+		entries[i] = parser.Parse(fmt.Sprintf(
+			`{"timestamp":"2025-01-01T10:00:00Z","level":%q,"message":"request %d","status":%d,"duration_ms":%d}`,
+			levels[i%len(levels)], i, 200+i%5*100, i%300))
+	}
+	return entries
+}
+
+func BenchmarkBuffer_Add(b *testing.B) {
+	entries := benchEntries(1000)
+	for _, bc := range []struct {
+		name    string
+		maxDisk int64
+	}{
+		{"memory", 0},
+		{"disk", 1 << 30},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			buf, err := NewWithDisk(1000, bc.maxDisk)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer buf.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Add(entries[i%len(entries)])
+			}
+		})
+	}
+}
+
+// BenchmarkBuffer_ScanNew matches one frame of new entries at 10k lines per
+// second against a filter. The cost must not grow with the history size.
+func BenchmarkBuffer_ScanNew(b *testing.B) {
+	const perFrame = 10000 / 60
+	entries := benchEntries(perFrame)
+	q, _ := query.Compile("level=error && status>=500")
+
+	for _, history := range []int{1000, 100000} {
+		b.Run(fmt.Sprintf("history=%d", history), func(b *testing.B) {
+			buf, err := NewWithDisk(DefaultCapacity, 1<<30)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer buf.Close()
+			for i := 0; i < history; i++ {
+				buf.Add(entries[i%len(entries)])
+			}
+			next := buf.Next()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, e := range entries {
+					buf.Add(e)
+				}
+				next = buf.Scan(next, func(_ uint64, e *parser.LogEntry) bool {
+					q.Match(e)
+					return true
+				})
+			}
+		})
+	}
+}
+
+// valueOf returns a field of an entry as text
+func valueOf(e *parser.LogEntry, key string) string {
+	v, _ := e.Parsed.Get(key)
+	return parser.ValueString(v)
+}
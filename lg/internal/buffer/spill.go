@@ -0,0 +1,236 @@
+package buffer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+const (
+	minSegmentSize = 1 << 20 // Smallest spill file, unless max-disk is smaller
+	segmentsPerMax = 8       // max-disk is split into this many spill files
+)
+
+// spill stores entries evicted from memory in append-only segment files in
+// a temporary directory. Only each entry's raw text is written; the index
+// of offsets, formats, sources and highlights stays in memory and entries
+// are parsed again when read back. Once the files exceed maxBytes, the oldest segment is deleted.
+type spill struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+	segments    []*segment // Oldest first
+	records     []record   // Oldest first
+	size        int64      // Bytes across all segments
+	nextSegment int
+
+	// cache keeps recently spilled or read entries decoded, so they keep
+	// their identity while the TUI holds on to them
+	cache      map[uint64]*parser.LogEntry
+	cacheOrder []uint64
+	cacheSize  int
+}
+
+type segment struct {
+	file *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+// record locates an entry in a segment
+type record struct {
+	seq       uint64 // Position in the session, never reused
+	seg       *segment
+	offset    int64
+	length    int
+	format    string
+	source    string
+	highlight string
+}
+
+func newSpill(maxBytes int64, cacheSize int) (*spill, error) {
+	dir, err := os.MkdirTemp("", "lg-*")
+	if err != nil {
+		return nil, fmt.Errorf("create spill directory: %w", err)
+	}
+	segmentSize := max(maxBytes/segmentsPerMax, minSegmentSize)
+	return &spill{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: min(segmentSize, maxBytes),
+		cache:       make(map[uint64]*parser.LogEntry),
+		cacheSize:   cacheSize,
+	}, nil
+}
+
+// add appends an entry, dropping the oldest segments beyond maxBytes. It
+// returns how many entries were dropped.
+func (s *spill) add(seq uint64, e *parser.LogEntry) (int, error) {
+	n := int64(len(e.Raw))
+	cur := s.current()
+	if cur == nil || (cur.size > 0 && cur.size+n > s.segmentSize) {
+		var err error
+		if cur, err = s.newSegment(); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := cur.w.WriteString(e.Raw); err != nil {
+		return 0, fmt.Errorf("write spill file: %w", err)
+	}
+	s.records = append(s.records, record{seq: seq, seg: cur, offset: cur.size, length: len(e.Raw), format: e.Format, source: e.Source, highlight: e.Highlight})
+	cur.size += n
+	s.size += n
+	s.remember(seq, e)
+
+	dropped := 0
+	for s.size > s.maxBytes && len(s.segments) > 1 {
+		dropped += s.dropOldest()
+	}
+	return dropped, nil
+}
+
+// get returns the i-th stored entry, reading it back from disk if needed
+func (s *spill) get(i int) (*parser.LogEntry, error) {
+	r := s.records[i]
+	if e, ok := s.cache[r.seq]; ok {
+		return e, nil
+	}
+
+	if r.seg.w.Buffered() > 0 {
+		if err := r.seg.w.Flush(); err != nil {
+			return nil, fmt.Errorf("write spill file: %w", err)
+		}
+	}
+	raw := make([]byte, r.length)
+	if _, err := r.seg.file.ReadAt(raw, r.offset); err != nil {
+		return nil, fmt.Errorf("read spill file: %w", err)
+	}
+
+	e := parser.Reparse(string(raw), r.format)
+	e.Source = r.source
+	e.Highlight = r.highlight
+	s.remember(r.seq, e)
+	return e, nil
+}
+
+func (s *spill) len() int {
+	return len(s.records)
+}
+
+func (s *spill) current() *segment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *spill) newSegment() (*segment, error) {
+	name := filepath.Join(s.dir, "segment-"+strconv.Itoa(s.nextSegment))
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create spill file: %w", err)
+	}
+	s.nextSegment++
+
+	// Earlier segments are complete, so flush them before moving on
+	if cur := s.current(); cur != nil {
+		if err := cur.w.Flush(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("write spill file: %w", err)
+		}
+	}
+
+	seg := &segment{file: f, w: bufio.NewWriter(f)}
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// dropOldest deletes the oldest segment and forgets its entries, returning
+// how many there were
+func (s *spill) dropOldest() int {
+	seg := s.segments[0]
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+
+	n := 0
+	for n < len(s.records) && s.records[n].seg == seg {
+		delete(s.cache, s.records[n].seq)
+		n++
+	}
+	s.records = append(s.records[:0:0], s.records[n:]...)
+
+	name := seg.file.Name()
+	seg.file.Close()
+	os.Remove(name)
+	return n
+}
+
+// remember caches a decoded entry, evicting the oldest cached one
+func (s *spill) remember(seq uint64, e *parser.LogEntry) {
+	if s.cacheSize <= 0 {
+		return
+	}
+	if _, ok := s.cache[seq]; ok {
+		return
+	}
+	if len(s.cacheOrder) >= s.cacheSize {
+		delete(s.cache, s.cacheOrder[0])
+		s.cacheOrder = s.cacheOrder[1:]
+	}
+	s.cache[seq] = e
+	s.cacheOrder = append(s.cacheOrder, seq)
+}
+
+// close deletes every segment and the spill directory
+func (s *spill) close() error {
+	for _, seg := range s.segments {
+		seg.file.Close()
+	}
+	s.segments = nil
+	s.records = nil
+	s.cache = nil
+	s.cacheOrder = nil
+	s.size = 0
+	return os.RemoveAll(s.dir)
+}
+
+// reset deletes every stored entry but keeps the spill usable
+func (s *spill) reset() {
+	for _, seg := range s.segments {
+		name := seg.file.Name()
+		seg.file.Close()
+		os.Remove(name)
+	}
+	s.segments = nil
+	s.records = nil
+	s.cache = make(map[uint64]*parser.LogEntry)
+	s.cacheOrder = nil
+	s.size = 0
+}
+
+// ParseSize parses a size such as "512MB", "2G" or "1048576". Units are
+// powers of 1024; "0" disables the limit it configures.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "B"), "I")
+
+	mult := int64(1)
+	if str != "" {
+		if i := strings.IndexByte("KMGT", str[len(str)-1]); i >= 0 {
+			mult = 1 << (10 * (i + 1))
+			str = str[:len(str)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512MB or 2GB)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
@@ -0,0 +1,231 @@
+// Package config loads lg's configuration file, ~/.config/lg/config.yaml:
+//
+//	theme: light
+//	themes:
+//	  solarized:
+//	    base: dark
+//	    primary: "#268bd2"
+//	keys:
+//	  pause: space
+//	  quit: [q, ctrl+q]
+//	columns: [time, level, service, msg]
+//	views:
+//	  - name: errors
+//	    filter: status>=500
+//	    columns: [time, status, path, msg]
+//	    level: warn
+//	rules:
+//	  - name: server errors
+//	    when: status>=500
+//	    style: {fg: "231", bg: "124", bold: true}
+//	    alert: true
+//	  - name: deadlocks
+//	    when: msg ~ "deadlock"
+//	    bell: true
+//	    exec: notify-send lg "deadlock detected"
+//	redact:
+//	  keys: [ssn, session_id]
+//	  patterns: ['\b\d{3}-\d{2}-\d{4}\b']
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/theme"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the contents of the configuration file
+type Config struct {
+	Theme   string               `yaml:"theme"`   // Name of a theme in Themes or a built-in one
+	Themes  map[string]ThemeSpec `yaml:"themes"`  // Themes by name
+	Keys    map[string]Strings   `yaml:"keys"`    // Keys by action, replacing its default keys
+	Columns []string             `yaml:"columns"` // Default columns of the table view
+	Views   []View               `yaml:"views"`   // Saved views, in the order they are listed
+	Rules   []Rule               `yaml:"rules"`
+	Redact  Redact               `yaml:"redact"` // Masking rules added to the default preset
+}
+
+// ThemeSpec is a theme of the configuration file. The colors it sets
+// replace those of its base theme.
+type ThemeSpec struct {
+	Base        string `yaml:"base"` // Built-in theme, dark by default
+	theme.Theme `yaml:",inline"`
+}
+
+// View is a saved combination of a filter, table columns and a level
+// threshold
+type View struct {
+	Name    string   `yaml:"name"`
+	Filter  string   `yaml:"filter"`  // Query, in the search bar's language
+	Columns []string `yaml:"columns"` // Table columns (the default ones when empty)
+	Level   string   `yaml:"level"`   // Minimum level (all levels when empty)
+}
+
+// Redact lists what is masked when redaction is on, besides the default
+// preset
+type Redact struct {
+	Keys     []string `yaml:"keys"`     // Fields whose names end in the words of one of these
+	Patterns []string `yaml:"patterns"` // Regular expressions for text masked wherever it appears
+}
+
+// Strings is a list that can also be written as a single string
+type Strings []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (s *Strings) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = Strings{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// Rule highlights the entries matching a query and can fire actions for
+// them
+type Rule struct {
+	Name  string `yaml:"name"`
+	When  string `yaml:"when"`  // Query, in the search bar's language
+	Style Style  `yaml:"style"` // Highlight of the matching entries
+	Bell  bool   `yaml:"bell"`  // Ring the terminal bell
+	Alert bool   `yaml:"alert"` // Count the entry in the status bar's alerts
+	Exec  string `yaml:"exec"`  // Shell command run with the entry's JSON on stdin
+}
+
+// Style is a text style. Colors are ANSI numbers ("196") or hex ("#ff0000").
+type Style struct {
+	Fg        string `yaml:"fg"`
+	Bg        string `yaml:"bg"`
+	Bold      bool   `yaml:"bold"`
+	Italic    bool   `yaml:"italic"`
+	Underline bool   `yaml:"underline"`
+}
+
+// IsZero reports whether the style changes nothing
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+// Lipgloss returns the style for rendering
+func (s Style) Lipgloss() lipgloss.Style {
+	style := lipgloss.NewStyle().Bold(s.Bold).Italic(s.Italic).Underline(s.Underline)
+	if s.Fg != "" {
+		style = style.Foreground(lipgloss.Color(s.Fg))
+	}
+	if s.Bg != "" {
+		style = style.Background(lipgloss.Color(s.Bg))
+	}
+	return style
+}
+
+// Path returns the default location of the configuration file, under
+// $XDG_CONFIG_HOME or ~/.config
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "lg", "config.yaml")
+}
+
+// LoadDefault reads the configuration file at Path, if there is one
+func LoadDefault() (*Config, error) {
+	path := Path()
+	if _, err := os.Stat(path); path == "" || errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	return Load(path)
+}
+
+// Load reads a configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, r := range cfg.Rules {
+		if r.When == "" {
+			return nil, fmt.Errorf("%s: rule %d has no when", path, i+1)
+		}
+		if r.Name == "" {
+			cfg.Rules[i].Name = r.When
+		}
+	}
+	return cfg, nil
+}
+
+// check validates the saved views, which are applied without a chance to
+// report errors
+func (c *Config) check() error {
+	seen := make(map[string]bool)
+	for i, v := range c.Views {
+		if v.Name == "" {
+			return fmt.Errorf("view %d has no name", i+1)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("view %q is defined twice", v.Name)
+		}
+		seen[v.Name] = true
+		if _, err := query.Compile(v.Filter); err != nil {
+			return fmt.Errorf("view %q: %w", v.Name, err)
+		}
+		if _, ok := parser.ParseLevel(v.Level); v.Level != "" && !ok {
+			return fmt.Errorf("view %q: unknown level %q", v.Name, v.Level)
+		}
+	}
+	return nil
+}
+
+// LookupTheme returns a theme of the configuration file, or a built-in one
+func (c *Config) LookupTheme(name string) (theme.Theme, error) {
+	spec, ok := c.Themes[name]
+	if !ok {
+		return theme.Lookup(name)
+	}
+	base := spec.Base
+	if base == "" {
+		base = "dark"
+	}
+	t, err := theme.Lookup(base)
+	if err != nil {
+		return theme.Theme{}, fmt.Errorf("theme %q: %w", name, err)
+	}
+	return t.Override(spec.Theme), nil
+}
+
+// LookupView returns the saved view with a name
+func (c *Config) LookupView(name string) (View, error) {
+	for _, v := range c.Views {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	names := make([]string, len(c.Views))
+	for i, v := range c.Views {
+		names[i] = v.Name
+	}
+	return View{}, fmt.Errorf("unknown view %q (available: %v)", name, names)
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thalessoares/lg/internal/theme"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+rules:
+  - name: server errors
+    when: status>=500
+    style: {fg: "231", bg: "124", bold: true}
+    alert: true
+  - when: msg ~ "deadlock"
+    bell: true
+    exec: cat > /dev/null
+`), 0o600)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("rules = %d, want 2", len(cfg.Rules))
+	}
+	r := cfg.Rules[0]
+	if r.Name != "server errors" || r.When != "status>=500" || !r.Alert || r.Bell {
+		t.Errorf("rule 1 = %+v", r)
+	}
+	if want := (Style{Fg: "231", Bg: "124", Bold: true}); r.Style != want {
+		t.Errorf("style = %+v, want %+v", r.Style, want)
+	}
+	r = cfg.Rules[1]
+	if r.Name != `msg ~ "deadlock"` || !r.Bell || r.Exec != "cat > /dev/null" || !r.Style.IsZero() {
+		t.Errorf("rule 2 = %+v", r)
+	}
+}
+
+func TestLoad_Settings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+theme: solarized
+themes:
+  solarized:
+    base: light
+    primary: "#268bd2"
+keys:
+  pause: space
+  quit: [q, ctrl+q]
+columns: [time, level, msg]
+views:
+  - name: errors
+    filter: status>=500
+    columns: [time, status, msg]
+    level: warn
+redact:
+  keys: [ssn]
+  patterns: ['\d{3}-\d{2}-\d{4}']
+`), 0o600)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Keys["pause"]; len(got) != 1 || got[0] != "space" {
+		t.Errorf("pause keys = %v, want [space]", got)
+	}
+	if got := cfg.Keys["quit"]; len(got) != 2 || got[1] != "ctrl+q" {
+		t.Errorf("quit keys = %v, want [q ctrl+q]", got)
+	}
+	if len(cfg.Columns) != 3 {
+		t.Errorf("columns = %v", cfg.Columns)
+	}
+	if got := cfg.Redact; len(got.Keys) != 1 || len(got.Patterns) != 1 || got.Patterns[0] != `\d{3}-\d{2}-\d{4}` {
+		t.Errorf("redact = %+v", got)
+	}
+
+	th, err := cfg.LookupTheme(cfg.Theme)
+	if err != nil {
+		t.Fatalf("LookupTheme() error = %v", err)
+	}
+	if th.Primary != "#268bd2" || th.Error != theme.Light.Error {
+		t.Errorf("theme = %+v, want light with primary #268bd2", th)
+	}
+	if _, err := cfg.LookupTheme("dark"); err != nil {
+		t.Errorf("LookupTheme(dark) error = %v", err)
+	}
+	if _, err := cfg.LookupTheme("nope"); err == nil {
+		t.Error("LookupTheme(nope) should fail")
+	}
+
+	v, err := cfg.LookupView("errors")
+	if err != nil {
+		t.Fatalf("LookupView() error = %v", err)
+	}
+	if v.Filter != "status>=500" || v.Level != "warn" || len(v.Columns) != 3 {
+		t.Errorf("view = %+v", v)
+	}
+	if _, err := cfg.LookupView("nope"); err == nil {
+		t.Error("LookupView(nope) should fail")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"no-when.yaml":    "rules:\n  - name: empty\n",
+		"syntax.yaml":     "rules: [\n",
+		"view-name.yaml":  "views:\n  - filter: a=1\n",
+		"view-dup.yaml":   "views:\n  - name: a\n  - name: a\n",
+		"view-level.yaml": "views:\n  - name: a\n    level: loud\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(data), 0o600)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s) should fail", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Load() of a missing file should fail")
+	}
+}
+
+func TestLoadDefault(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if got, want := Path(), filepath.Join(dir, "lg", "config.yaml"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+	cfg, err := LoadDefault()
+	if err != nil || len(cfg.Rules) != 0 {
+		t.Errorf("LoadDefault() without a file = %+v, %v, want an empty config", cfg, err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package correlate groups entries by a correlation ID, such as a request
+// or trace ID, to follow one request across the lines it logged.
+package correlate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// maxGroups caps the IDs tracked; entries with new IDs past it are not
+// counted
+const maxGroups = 10000
+
+// Keys are the fields detected as correlation IDs, most specific to a
+// request first
+var Keys = []string{
+	"trace_id", "traceId", "traceID", "trace.id",
+	"request_id", "requestId", "requestID", "req_id", "request.id",
+	"correlation_id", "correlationId", "x_request_id",
+	"span_id", "spanId", "span.id",
+}
+
+// Detect returns the first correlation key from Keys that an entry has,
+// with its value
+func Detect(e *parser.LogEntry) (key, id string, ok bool) {
+	for _, k := range Keys {
+		if id, ok := ID(e, k); ok {
+			return k, id, true
+		}
+	}
+	return "", "", false
+}
+
+// ID returns an entry's value for a correlation key, if not empty
+func ID(e *parser.LogEntry, key string) (string, bool) {
+	v, ok := parser.Lookup(e.Parsed, key)
+	if !ok || v == nil {
+		return "", false
+	}
+	id := parser.ValueString(v)
+	return id, id != ""
+}
+
+// Group sums up the entries sharing an ID
+type Group struct {
+	ID          string
+	Count       int
+	Errors      int       // Entries at error level or above
+	First, Last time.Time // Earliest and latest timestamps, zero without any
+	lastSeen    int       // Order of the latest entry
+}
+
+// Span is the time between the group's first and last entry
+func (g Group) Span() time.Duration {
+	return g.Last.Sub(g.First)
+}
+
+// Index counts the entries of every ID of one correlation key
+type Index struct {
+	key    string
+	groups map[string]*Group
+	added  int
+}
+
+// NewIndex creates an Index for a correlation key, e.g. "request_id"
+func NewIndex(key string) *Index {
+	return &Index{key: key, groups: make(map[string]*Group)}
+}
+
+// Key returns the correlation key
+func (x *Index) Key() string {
+	return x.key
+}
+
+// Add counts an entry in the group of its ID, if it has one
+func (x *Index) Add(e *parser.LogEntry) {
+	id, ok := ID(e, x.key)
+	if !ok {
+		return
+	}
+	x.added++
+	g := x.groups[id]
+	if g == nil {
+		if len(x.groups) >= maxGroups {
+			return
+		}
+		g = &Group{ID: id}
+		x.groups[id] = g
+	}
+	g.Count++
+	g.lastSeen = x.added
+	if e.Level >= parser.LevelError {
+		g.Errors++
+	}
+	if t := e.Time; !t.IsZero() {
+		if g.First.IsZero() || t.Before(g.First) {
+			g.First = t
+		}
+		if t.After(g.Last) {
+			g.Last = t
+		}
+	}
+}
+
+// Len returns the number of IDs
+func (x *Index) Len() int {
+	return len(x.groups)
+}
+
+// Group returns the group of an ID
+func (x *Index) Group(id string) (Group, bool) {
+	g, ok := x.groups[id]
+	if !ok {
+		return Group{}, false
+	}
+	return *g, true
+}
+
+// Groups lists the groups with errors first, then the most recently active
+func (x *Index) Groups() []Group {
+	groups := make([]Group, 0, len(x.groups))
+	for _, g := range x.groups {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Errors > 0) != (groups[j].Errors > 0) {
+			return groups[i].Errors > 0
+		}
+		return groups[i].lastSeen > groups[j].lastSeen
+	})
+	return groups
+}
@@ -0,0 +1,62 @@
+package correlate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantKey string
+		wantID  string
+	}{
+		{`{"msg":"a","request_id":"r1"}`, "request_id", "r1"},
+		{`{"msg":"a","request_id":"r1","trace_id":"t1"}`, "trace_id", "t1"},
+		{`{"msg":"a","trace":{"id":"t2"}}`, "trace.id", "t2"},
+		{`level=info requestId=42 msg=hi`, "requestId", "42"},
+		{`{"msg":"a","request_id":""}`, "", ""},
+		{`plain text`, "", ""},
+	}
+	for _, tt := range tests {
+		key, id, ok := Detect(parser.Parse(tt.line))
+		if key != tt.wantKey || id != tt.wantID || ok != (tt.wantKey != "") {
+			t.Errorf("Detect(%s) = %q, %q, %v, want %q, %q", tt.line, key, id, ok, tt.wantKey, tt.wantID)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	x := NewIndex("request_id")
+	for _, line := range []string{
+		`{"time":"2025-01-01T10:00:00Z","level":"info","request_id":"a"}`,
+		`{"time":"2025-01-01T10:00:01Z","level":"info","request_id":"b"}`,
+		`{"time":"2025-01-01T10:00:02.5Z","level":"error","request_id":"a"}`,
+		`{"time":"2025-01-01T10:00:03Z","level":"info","request_id":"c"}`,
+		`{"level":"info","msg":"no id"}`,
+	} {
+		x.Add(parser.Parse(line))
+	}
+
+	if x.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", x.Len())
+	}
+	var ids []string
+	for _, g := range x.Groups() {
+		ids = append(ids, g.ID)
+	}
+	// Errors first, then the most recently active
+	if got, want := ids, []string{"a", "c", "b"}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Groups() = %v, want %v", got, want)
+	}
+
+	g, ok := x.Group("a")
+	if !ok || g.Count != 2 || g.Errors != 1 || g.Span() != 2500*time.Millisecond {
+		t.Errorf("Group(a) = %+v, want 2 entries, 1 error and a 2.5s span", g)
+	}
+	if _, ok := x.Group("missing"); ok {
+		t.Error("Group(missing) should not be found")
+	}
+}
@@ -0,0 +1,143 @@
+// Package facets keeps running counts of the key paths seen across log
+// entries and of their most common values.
+package facets
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+)
+
+const (
+	maxKeys     = 500 // Key paths tracked; later new keys are ignored
+	maxValues   = 200 // Distinct values counted per key before the rest are lumped together
+	maxDepth    = 4   // Nesting depth of objects flattened into key paths
+	maxValueLen = 80  // Longer values are not counted, as they are rarely useful facets
+)
+
+// Index counts key paths and values. Entries are added one at a time as they
+// arrive, so it never needs to rescan the buffer.
+type Index struct {
+	keys    map[string]*Key
+	entries int
+}
+
+// Key holds the counts for one key path
+type Key struct {
+	Path   string
+	Count  int // Entries with the key
+	Other  int // Occurrences of values beyond the distinct values tracked
+	values map[string]int
+}
+
+// Value is a key value and the number of entries with it
+type Value struct {
+	Value string
+	Count int
+}
+
+// New creates an empty Index
+func New() *Index {
+	return &Index{keys: make(map[string]*Key)}
+}
+
+// Add counts the fields of an entry. Nested objects are flattened into
+// dotted paths such as "user.id"; arrays are counted as a single value.
+// The entry's source is counted under query.SourceField.
+func (ix *Index) Add(e *parser.LogEntry) {
+	if e.Source != "" {
+		ix.addValue(query.SourceField, e.Source)
+	}
+	if e.Parsed == nil {
+		return
+	}
+	ix.entries++
+	ix.addObject("", e.Parsed, 0)
+}
+
+func (ix *Index) addObject(prefix string, obj *parser.Object, depth int) {
+	for _, k := range obj.Keys() {
+		v, _ := obj.Get(k)
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if nested, ok := v.(*parser.Object); ok && depth < maxDepth && nested.Len() > 0 {
+			ix.addObject(path, nested, depth+1)
+			continue
+		}
+		ix.addValue(path, parser.ValueString(v))
+	}
+}
+
+func (ix *Index) addValue(path, value string) {
+	key := ix.keys[path]
+	if key == nil {
+		if len(ix.keys) >= maxKeys {
+			return
+		}
+		key = &Key{Path: path, values: make(map[string]int)}
+		ix.keys[path] = key
+	}
+	key.Count++
+
+	switch _, seen := key.values[value]; {
+	case seen:
+		key.values[value]++
+	case len(key.values) < maxValues && len(value) <= maxValueLen && !strings.Contains(value, "\n"):
+		key.values[value] = 1
+	default:
+		key.Other++
+	}
+}
+
+// Entries returns the number of structured entries counted
+func (ix *Index) Entries() int {
+	return ix.entries
+}
+
+// Keys returns the key paths, most common first
+func (ix *Index) Keys() []*Key {
+	keys := make([]*Key, 0, len(ix.keys))
+	for _, k := range ix.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Path < keys[j].Path
+	})
+	return keys
+}
+
+// Key returns the counts for a key path, or nil if it was never seen
+func (ix *Index) Key(path string) *Key {
+	return ix.keys[path]
+}
+
+// Distinct returns the number of distinct values counted, which stops
+// growing once the limit is reached
+func (k *Key) Distinct() int {
+	return len(k.values)
+}
+
+// Top returns the n most common values, most common first
+func (k *Key) Top(n int) []Value {
+	values := make([]Value, 0, len(k.values))
+	for v, c := range k.values {
+		values = append(values, Value{Value: v, Count: c})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if n > 0 && len(values) > n {
+		values = values[:n]
+	}
+	return values
+}
@@ -0,0 +1,89 @@
+package facets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+)
+
+func TestIndex(t *testing.T) {
+	ix := New()
+	for _, line := range []string{
+		`{"level":"info","status":200,"user":{"id":1}}`,
+		`{"level":"error","status":502,"user":{"id":2}}`,
+		`{"level":"info","status":200,"tags":["a","b"]}`,
+		`level=info service=api`,
+		`plain text`,
+	} {
+		ix.Add(parser.Parse(line))
+	}
+
+	if ix.Entries() != 4 {
+		t.Errorf("Entries() = %d, want 4", ix.Entries())
+	}
+
+	keys := ix.Keys()
+	if keys[0].Path != "level" || keys[0].Count != 4 {
+		t.Errorf("Keys()[0] = %s (%d), want level (4)", keys[0].Path, keys[0].Count)
+	}
+
+	tests := []struct {
+		path string
+		want []Value
+	}{
+		{"level", []Value{{"info", 3}, {"error", 1}}},
+		{"status", []Value{{"200", 2}, {"502", 1}}},
+		{"user.id", []Value{{"1", 1}, {"2", 1}}},
+		{"tags", []Value{{`["a","b"]`, 1}}},
+		{"service", []Value{{"api", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			key := ix.Key(tt.path)
+			if key == nil {
+				t.Fatalf("Key(%q) = nil", tt.path)
+			}
+			got := key.Top(0)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Top() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if ix.Key(query.SourceField) != nil {
+		t.Error("untagged entries should not count a source")
+	}
+	if ix.Key("user") != nil {
+		t.Error("nested objects should be counted by their leaf paths only")
+	}
+}
+
+func TestIndex_Source(t *testing.T) {
+	ix := New()
+	for _, src := range []string{"api", "worker", "api"} {
+		e := parser.Parse("plain text")
+		e.Source = src
+		ix.Add(e)
+	}
+	if got := fmt.Sprint(ix.Key(query.SourceField).Top(0)); got != "[{api 2} {worker 1}]" {
+		t.Errorf("sources = %s", got)
+	}
+}
+
+func TestIndex_HighCardinality(t *testing.T) {
+	ix := New()
+	for i := 0; i < maxValues+50; i++ {
+		ix.Add(parser.Parse(fmt.Sprintf(`{"request_id":"r%d","level":"info"}`, i)))
+	}
+	ix.Add(parser.Parse(`{"request_id":"r0"}`))
+
+	key := ix.Key("request_id")
+	if key.Distinct() != maxValues || key.Other != 50 || key.Count != maxValues+51 {
+		t.Errorf("Distinct, Other, Count = %d, %d, %d", key.Distinct(), key.Other, key.Count)
+	}
+	if top := key.Top(1); top[0] != (Value{"r0", 2}) {
+		t.Errorf("Top(1) = %v, want r0 (2)", top)
+	}
+}
@@ -0,0 +1,213 @@
+// Package histogram counts log entries per level over time and measures the
+// ingest rate.
+package histogram
+
+import (
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// DefaultBuckets is how many buckets a Histogram keeps before it widens them
+const DefaultBuckets = 512
+
+// widths are the bucket widths tried in turn as the time span grows. Each is
+// a multiple of the previous one, so buckets merge without splitting.
+var widths = []time.Duration{
+	time.Second,
+	2 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// maxWidth stops widening before durations overflow
+const maxWidth = 100 * 365 * 24 * time.Hour
+
+// Counts holds the number of entries per level
+type Counts [parser.LevelFatal + 1]int
+
+// Total returns the number of entries across all levels
+func (c Counts) Total() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// Histogram counts entries per level in fixed-width time buckets. Buckets
+// get wider as the span of times grows, so memory stays bounded however long
+// the stream runs.
+type Histogram struct {
+	width      time.Duration
+	start      time.Time // Start of the first bucket
+	buckets    []Counts
+	maxBuckets int
+}
+
+// Bin is a time window and the entries counted in it
+type Bin struct {
+	From, To time.Time // To is exclusive
+	Counts   Counts
+}
+
+// New creates an empty Histogram that keeps at most maxBuckets buckets
+func New(maxBuckets int) *Histogram {
+	if maxBuckets <= 0 {
+		maxBuckets = DefaultBuckets
+	}
+	return &Histogram{width: widths[0], maxBuckets: maxBuckets}
+}
+
+// Add counts an entry at time t
+func (h *Histogram) Add(t time.Time, l parser.Level) {
+	if t.IsZero() {
+		return
+	}
+	if len(h.buckets) == 0 {
+		h.start = t.Truncate(h.width)
+		h.buckets = make([]Counts, 1)
+	}
+
+	// Widen the buckets until t fits alongside the existing ones
+	for h.span(t) > h.maxBuckets {
+		if !h.widen() {
+			return
+		}
+	}
+
+	if b := t.Truncate(h.width); b.Before(h.start) {
+		n := int(h.start.Sub(b) / h.width)
+		h.buckets = append(make([]Counts, n, n+len(h.buckets)), h.buckets...)
+		h.start = b
+	}
+	i := int(t.Sub(h.start) / h.width)
+	for i >= len(h.buckets) {
+		h.buckets = append(h.buckets, Counts{})
+	}
+	h.buckets[i][l]++
+}
+
+// span returns the number of buckets needed to also cover t
+func (h *Histogram) span(t time.Time) int {
+	from := h.start
+	to := h.start.Add(time.Duration(len(h.buckets)) * h.width)
+	if b := t.Truncate(h.width); b.Before(from) {
+		from = b
+	} else if !b.Before(to) {
+		to = b.Add(h.width)
+	}
+	return int(to.Sub(from) / h.width)
+}
+
+// widen merges the buckets into the next bucket width. It returns false
+// once the widest width is reached.
+func (h *Histogram) widen() bool {
+	next := h.width * 2
+	for _, w := range widths {
+		if w > h.width {
+			next = w
+			break
+		}
+	}
+	if next > maxWidth {
+		return false
+	}
+
+	start := h.start.Truncate(next)
+	end := h.start.Add(time.Duration(len(h.buckets)) * h.width)
+	merged := make([]Counts, int((end.Sub(start)+next-1)/next))
+	for i, c := range h.buckets {
+		j := int(h.start.Add(time.Duration(i)*h.width).Sub(start) / next)
+		for l, n := range c {
+			merged[j][l] += n
+		}
+	}
+	h.width, h.start, h.buckets = next, start, merged
+	return true
+}
+
+// Width returns the current bucket width
+func (h *Histogram) Width() time.Duration {
+	return h.width
+}
+
+// Len returns the number of buckets
+func (h *Histogram) Len() int {
+	return len(h.buckets)
+}
+
+// Bins groups the buckets into at most n bins of equal width, oldest first
+func (h *Histogram) Bins(n int) []Bin {
+	if len(h.buckets) == 0 || n <= 0 {
+		return nil
+	}
+	per := (len(h.buckets) + n - 1) / n
+	width := time.Duration(per) * h.width
+
+	bins := make([]Bin, 0, (len(h.buckets)+per-1)/per)
+	for i, c := range h.buckets {
+		if i%per == 0 {
+			from := h.start.Add(time.Duration(i) * h.width)
+			bins = append(bins, Bin{From: from, To: from.Add(width)})
+		}
+		b := &bins[len(bins)-1]
+		for l, v := range c {
+			b.Counts[l] += v
+		}
+	}
+	return bins
+}
+
+// Meter measures how many entries arrive per second over a sliding window
+type Meter struct {
+	slots  []int // Entries per second, indexed by Unix time modulo len
+	newest int64 // Unix time of the newest slot
+}
+
+// NewMeter creates a Meter averaging over window, in whole seconds
+func NewMeter(window time.Duration) *Meter {
+	return &Meter{slots: make([]int, max(int(window/time.Second), 1))}
+}
+
+// Add records n entries arriving at now
+func (m *Meter) Add(now time.Time, n int) {
+	sec := m.advance(now)
+	m.slots[sec%int64(len(m.slots))] += n
+}
+
+// Rate returns the average entries per second over the window ending at now
+func (m *Meter) Rate(now time.Time) float64 {
+	m.advance(now)
+	total := 0
+	for _, n := range m.slots {
+		total += n
+	}
+	return float64(total) / float64(len(m.slots))
+}
+
+// advance clears the slots of the seconds elapsed since the newest one
+func (m *Meter) advance(now time.Time) int64 {
+	sec := now.Unix()
+	if sec <= m.newest {
+		return m.newest
+	}
+	if sec-m.newest >= int64(len(m.slots)) {
+		clear(m.slots)
+	} else {
+		for s := m.newest + 1; s <= sec; s++ {
+			m.slots[s%int64(len(m.slots))] = 0
+		}
+	}
+	m.newest = sec
+	return sec
+}
@@ -0,0 +1,112 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+var base = time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestHistogram_Add(t *testing.T) {
+	h := New(10)
+	h.Add(base, parser.LevelInfo)
+	h.Add(base.Add(500*time.Millisecond), parser.LevelError)
+	h.Add(base.Add(3*time.Second), parser.LevelInfo)
+	h.Add(base.Add(-2*time.Second), parser.LevelWarn)
+	h.Add(time.Time{}, parser.LevelInfo)
+
+	if h.Width() != time.Second || h.Len() != 6 {
+		t.Fatalf("Width, Len = %v, %d, want 1s, 6", h.Width(), h.Len())
+	}
+	bins := h.Bins(10)
+	tests := []struct {
+		bin   int
+		from  time.Time
+		level parser.Level
+		want  int
+	}{
+		{0, base.Add(-2 * time.Second), parser.LevelWarn, 1},
+		{2, base, parser.LevelInfo, 1},
+		{2, base, parser.LevelError, 1},
+		{5, base.Add(3 * time.Second), parser.LevelInfo, 1},
+	}
+	for _, tt := range tests {
+		b := bins[tt.bin]
+		if !b.From.Equal(tt.from) || b.Counts[tt.level] != tt.want {
+			t.Errorf("bin %d = %v %v, want %v with %s=%d", tt.bin, b.From, b.Counts, tt.from, tt.level, tt.want)
+		}
+	}
+}
+
+func TestHistogram_Widen(t *testing.T) {
+	h := New(10)
+	for i := 0; i < 60; i++ {
+		h.Add(base.Add(time.Duration(i)*time.Second), parser.LevelInfo)
+	}
+	h.Add(base.Add(59*time.Second), parser.LevelError)
+
+	if h.Width() != 10*time.Second || h.Len() != 6 {
+		t.Fatalf("Width, Len = %v, %d, want 10s, 6", h.Width(), h.Len())
+	}
+	total := 0
+	for _, b := range h.Bins(100) {
+		if b.To.Sub(b.From) != 10*time.Second {
+			t.Errorf("bin %v-%v is not 10s wide", b.From, b.To)
+		}
+		total += b.Counts.Total()
+	}
+	if total != 61 {
+		t.Errorf("total = %d, want 61", total)
+	}
+}
+
+func TestHistogram_Bins(t *testing.T) {
+	h := New(100)
+	for i := 0; i < 10; i++ {
+		h.Add(base.Add(time.Duration(i)*time.Second), parser.LevelInfo)
+	}
+
+	tests := []struct {
+		n        int
+		wantBins int
+		wantSpan time.Duration
+	}{
+		{20, 10, time.Second},
+		{5, 5, 2 * time.Second},
+		{3, 3, 4 * time.Second},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		bins := h.Bins(tt.n)
+		if len(bins) != tt.wantBins {
+			t.Errorf("Bins(%d) = %d bins, want %d", tt.n, len(bins), tt.wantBins)
+			continue
+		}
+		if len(bins) > 0 && bins[0].To.Sub(bins[0].From) != tt.wantSpan {
+			t.Errorf("Bins(%d) span = %v, want %v", tt.n, bins[0].To.Sub(bins[0].From), tt.wantSpan)
+		}
+	}
+}
+
+func TestMeter(t *testing.T) {
+	m := NewMeter(10 * time.Second)
+	m.Add(base, 50)
+	m.Add(base.Add(time.Second), 50)
+
+	tests := []struct {
+		at   time.Duration
+		want float64
+	}{
+		{time.Second, 10},
+		{9 * time.Second, 10},
+		{10 * time.Second, 5},
+		{time.Minute, 0},
+	}
+	for _, tt := range tests {
+		if got := m.Rate(base.Add(tt.at)); got != tt.want {
+			t.Errorf("Rate(+%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
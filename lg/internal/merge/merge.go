@@ -0,0 +1,154 @@
+// Package merge interleaves the entries of several inputs into a single
+// timeline ordered by timestamp.
+package merge
+
+import (
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// DefaultWait is how long an input with nothing pending holds back the
+// others
+const DefaultWait = 250 * time.Millisecond
+
+// maxPending is how many entries of an input are held while it is ahead of
+// the others; reading it waits past that, so a newer file does not pile up
+// in memory while an older one is read
+const maxPending = 1024
+
+// event is an entry from input i, or the end of input i when e is nil
+type event struct {
+	i int
+	e *parser.LogEntry
+}
+
+// queue holds an input's entries that were read but not sent yet
+type queue struct {
+	entries []*parser.LogEntry
+	keys    []time.Time // Time each entry is ordered by
+	last    time.Time   // Key of the newest entry, for entries without a timestamp
+	idle    time.Time   // When the queue last became empty
+	closed  bool
+	slots   chan struct{} // One per pending entry, up to maxPending
+}
+
+// Merge sends the entries of every input to out, ordered by timestamp, and
+// closes out once all inputs are closed. Each input is assumed to be in
+// time order already. An entry is sent once every other input has an entry
+// waiting, has ended, or has had nothing for wait, so a quiet stream
+// delays the others by at most wait. Entries without a timestamp keep their
+// place after the previous entry of their input.
+func Merge(inputs []<-chan *parser.LogEntry, wait time.Duration, out chan<- *parser.LogEntry) {
+	defer close(out)
+
+	if len(inputs) == 1 {
+		for e := range inputs[0] {
+			out <- e
+		}
+		return
+	}
+
+	now := time.Now()
+	queues := make([]*queue, len(inputs))
+	for i := range queues {
+		queues[i] = &queue{idle: now, slots: make(chan struct{}, maxPending)}
+	}
+
+	events := make(chan event)
+	for i, in := range inputs {
+		go func() {
+			for e := range in {
+				queues[i].slots <- struct{}{}
+				events <- event{i: i, e: e}
+			}
+			events <- event{i: i}
+		}()
+	}
+
+	open := len(inputs)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for open > 0 || pending(queues) {
+		// Send what can be sent, then wait for more input or for a quiet
+		// input to become idle
+		next := flush(queues, wait, time.Now(), out)
+		var timeout <-chan time.Time
+		if next > 0 {
+			timer.Reset(next)
+			timeout = timer.C
+		}
+		if open == 0 {
+			continue
+		}
+
+		select {
+		case ev := <-events:
+			q := queues[ev.i]
+			if ev.e == nil {
+				q.closed = true
+				open--
+				continue
+			}
+			key := ev.e.Time
+			if key.IsZero() {
+				key = q.last
+			}
+			q.last = key
+			q.entries = append(q.entries, ev.e)
+			q.keys = append(q.keys, key)
+		case <-timeout:
+		}
+	}
+}
+
+// flush sends entries while the oldest pending one cannot be preceded by
+// anything still to come. It returns how long until a blocking input
+// becomes idle, or 0 when nothing is blocked.
+func flush(queues []*queue, wait time.Duration, now time.Time, out chan<- *parser.LogEntry) time.Duration {
+	for {
+		// The oldest pending entry across inputs
+		oldest := -1
+		for i, q := range queues {
+			if len(q.entries) > 0 && (oldest < 0 || q.keys[0].Before(queues[oldest].keys[0])) {
+				oldest = i
+			}
+		}
+		if oldest < 0 {
+			return 0
+		}
+
+		// Inputs with nothing pending may still deliver something older
+		var blocked time.Duration
+		for _, q := range queues {
+			if len(q.entries) == 0 && !q.closed {
+				if left := wait - now.Sub(q.idle); left > 0 {
+					blocked = max(blocked, left)
+				}
+			}
+		}
+		if blocked > 0 {
+			return blocked
+		}
+
+		q := queues[oldest]
+		out <- q.entries[0]
+		q.entries = q.entries[1:]
+		q.keys = q.keys[1:]
+		<-q.slots
+		if len(q.entries) == 0 {
+			q.idle = now
+		}
+	}
+}
+
+// pending reports whether any input has entries left to send
+func pending(queues []*queue) bool {
+	for _, q := range queues {
+		if len(q.entries) > 0 {
+			return true
+		}
+	}
+	return false
+}
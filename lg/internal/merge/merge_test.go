@@ -0,0 +1,147 @@
+package merge
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func feed(lines ...string) <-chan *parser.LogEntry {
+	ch := make(chan *parser.LogEntry, len(lines))
+	for _, l := range lines {
+		ch <- parser.Parse(l)
+	}
+	close(ch)
+	return ch
+}
+
+func collect(out <-chan *parser.LogEntry) []string {
+	var got []string
+	for e := range out {
+		got = append(got, e.Raw)
+	}
+	return got
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs [][]string
+		want   []string
+	}{
+		{
+			name:   "single input",
+			inputs: [][]string{{"ts=3 b", "ts=1 a"}},
+			want:   []string{"ts=3 b", "ts=1 a"},
+		},
+		{
+			name: "by timestamp",
+			inputs: [][]string{
+				{"time=2025-01-01T10:00:00Z msg=a1", "time=2025-01-01T10:00:02Z msg=a2"},
+				{"time=2025-01-01T10:00:01Z msg=b1", "time=2025-01-01T10:00:03Z msg=b2"},
+			},
+			want: []string{
+				"time=2025-01-01T10:00:00Z msg=a1",
+				"time=2025-01-01T10:00:01Z msg=b1",
+				"time=2025-01-01T10:00:02Z msg=a2",
+				"time=2025-01-01T10:00:03Z msg=b2",
+			},
+		},
+		{
+			name: "untimed entries follow their input",
+			inputs: [][]string{
+				{"time=2025-01-01T10:00:00Z msg=a1", "a1 detail", "time=2025-01-01T10:00:02Z msg=a2"},
+				{"time=2025-01-01T10:00:01Z msg=b1"},
+			},
+			want: []string{
+				"time=2025-01-01T10:00:00Z msg=a1",
+				"a1 detail",
+				"time=2025-01-01T10:00:01Z msg=b1",
+				"time=2025-01-01T10:00:02Z msg=a2",
+			},
+		},
+		{
+			name: "no timestamps",
+			inputs: [][]string{
+				{"a1", "a2"},
+				{"b1"},
+			},
+			want: []string{"a1", "a2", "b1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputs []<-chan *parser.LogEntry
+			for _, lines := range tt.inputs {
+				inputs = append(inputs, feed(lines...))
+			}
+			out := make(chan *parser.LogEntry, 100)
+			Merge(inputs, time.Second, out)
+			got := collect(out)
+			if tt.name == "no timestamps" {
+				// Untimed inputs interleave in arrival order
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Merge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge_QuietInput(t *testing.T) {
+	quiet := make(chan *parser.LogEntry)
+	defer close(quiet)
+	busy := make(chan *parser.LogEntry, 1)
+	busy <- parser.Parse("time=2025-01-01T10:00:00Z msg=a1")
+
+	out := make(chan *parser.LogEntry, 1)
+	go Merge([]<-chan *parser.LogEntry{busy, quiet}, 50*time.Millisecond, out)
+
+	select {
+	case e := <-out:
+		if e.Raw != "time=2025-01-01T10:00:00Z msg=a1" {
+			t.Errorf("got %q", e.Raw)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a quiet input held back the others")
+	}
+}
+
+func TestMerge_NewerInputWaits(t *testing.T) {
+	// The newer input is longer than maxPending, and must wait for the
+	// older one rather than be sent ahead of it
+	n := 3 * maxPending
+	newer := make(chan *parser.LogEntry)
+	older := make(chan *parser.LogEntry)
+	go func() {
+		defer close(newer)
+		for i := range n {
+			newer <- parser.Parse(fmt.Sprintf("time=2025-01-02T10:00:%02dZ msg=new%d", i%60, i))
+		}
+	}()
+	go func() {
+		defer close(older)
+		time.Sleep(20 * time.Millisecond)
+		for i := range n {
+			older <- parser.Parse(fmt.Sprintf("time=2025-01-01T10:00:%02dZ msg=old%d", i%60, i))
+		}
+	}()
+
+	out := make(chan *parser.LogEntry)
+	go Merge([]<-chan *parser.LogEntry{newer, older}, time.Second, out)
+	got := collect(out)
+	if len(got) != 2*n {
+		t.Fatalf("Merge() sent %d entries, want %d", len(got), 2*n)
+	}
+	for i, raw := range got {
+		if old := strings.Contains(raw, "msg=old"); old != (i < n) {
+			t.Fatalf("entry %d = %q, want every older entry first", i, raw)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package multiline groups stack traces, panics and other continuation
+// lines with the log line that started them, so a Java exception or a Go
+// panic becomes a single entry instead of dozens of plain text ones.
+package multiline
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long a streaming entry waits for more continuation
+// lines before it is flushed
+const DefaultTimeout = 300 * time.Millisecond
+
+// maxLines caps a single entry so a runaway dump cannot grow without bound
+const maxLines = 2000
+
+var (
+	javaFrameRe   = regexp.MustCompile(`^(at |Caused by:|Suppressed:|\.\.\. \d+ (more|common frames omitted))`)
+	goroutineRe   = regexp.MustCompile(`^goroutine \d+ \[`)
+	goFrameRe     = regexp.MustCompile(`^([\w.\-/]+\.)?[\w\-*()\[\]]+(\.[\w\-*()\[\]]+)*\(.*\)$|^created by `)
+	pyExceptionRe = regexp.MustCompile(`^[A-Za-z_][\w.]*(Error|Exception|Exit|Interrupt|Warning|Iteration)\b(:.*)?$`)
+	pyChainRe     = regexp.MustCompile(`^(During handling of the above exception|The above exception was the direct cause)`)
+)
+
+// Aggregator collects lines into entries. Add and Flush are not safe for
+// concurrent use; Run drives them from a single goroutine.
+type Aggregator struct {
+	pending []string // Lines of the entry being built
+	blanks  int      // Blank lines seen since the last pending line
+	goPanic bool     // Pending entry is a Go panic or goroutine dump
+	pyTrace bool     // Pending entry is an open Python traceback
+}
+
+// New creates an Aggregator
+func New() *Aggregator {
+	return &Aggregator{}
+}
+
+// Add feeds a line and returns the previous entry when the line starts a
+// new one
+func (a *Aggregator) Add(line string) []string {
+	line = strings.TrimRight(line, "\r")
+
+	if strings.TrimSpace(line) == "" {
+		// Blank lines only survive when a continuation line follows them,
+		// as between the goroutines of a panic
+		if len(a.pending) > 0 {
+			a.blanks++
+		}
+		return nil
+	}
+
+	if len(a.pending) > 0 && len(a.pending) < maxLines && a.isContinuation(line) {
+		for ; a.blanks > 0; a.blanks-- {
+			a.pending = append(a.pending, "")
+		}
+		a.pending = append(a.pending, line)
+		a.track(line)
+		return nil
+	}
+
+	done := a.Flush()
+	a.pending = []string{line}
+	a.track(line)
+	return done
+}
+
+// Flush returns the pending entry, if any, and resets the aggregator
+func (a *Aggregator) Flush() []string {
+	done := a.pending
+	a.pending = nil
+	a.blanks = 0
+	a.goPanic = false
+	a.pyTrace = false
+	if len(done) == 0 {
+		return nil
+	}
+	return done
+}
+
+// isContinuation reports whether line belongs to the pending entry
+func (a *Aggregator) isContinuation(line string) bool {
+	switch {
+	case line[0] == ' ' || line[0] == '\t':
+		return true
+	case javaFrameRe.MatchString(line), goroutineRe.MatchString(line):
+		return true
+	case a.goPanic && goFrameRe.MatchString(line):
+		return true
+	case a.pyTrace && (pyExceptionRe.MatchString(line) || pyChainRe.MatchString(line)):
+		return true
+	}
+	return false
+}
+
+// track updates the trace kind from a line just added to the pending entry
+func (a *Aggregator) track(line string) {
+	switch {
+	case strings.HasPrefix(line, "panic: "), strings.HasPrefix(line, "fatal error: "), goroutineRe.MatchString(line):
+		a.goPanic = true
+	case strings.HasPrefix(line, "Traceback (most recent call last):"):
+		a.pyTrace = true
+	case a.pyTrace && pyExceptionRe.MatchString(line):
+		// The exception line closes the traceback
+		a.pyTrace = false
+	case pyChainRe.MatchString(line):
+		a.pyTrace = true
+	}
+}
+
+// Run reads lines until the channel is closed and calls emit with each
+// complete entry. An entry still waiting for continuation lines is flushed
+// once no new line arrives within timeout, so the last log of a quiet stream
+// is not held back. A timeout of zero disables aggregation.
+func (a *Aggregator) Run(lines <-chan string, timeout time.Duration, emit func([]string)) {
+	if timeout <= 0 {
+		for line := range lines {
+			emit([]string{line})
+		}
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if done := a.Flush(); done != nil {
+					emit(done)
+				}
+				return
+			}
+			if done := a.Add(line); done != nil {
+				emit(done)
+			}
+			timer.Reset(timeout)
+
+		case <-timer.C:
+			if done := a.Flush(); done != nil {
+				emit(done)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package multiline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// aggregate feeds every line and returns the resulting entries
+func aggregate(input string) [][]string {
+	a := New()
+	var entries [][]string
+	for _, line := range strings.Split(input, "\n") {
+		if done := a.Add(line); done != nil {
+			entries = append(entries, done)
+		}
+	}
+	if done := a.Flush(); done != nil {
+		entries = append(entries, done)
+	}
+	return entries
+}
+
+func TestAggregator_JavaStackTrace(t *testing.T) {
+	input := `{"level":"error","msg":"request failed"}
+Exception in thread "main" java.lang.IllegalStateException: boom
+	at com.example.App.run(App.java:12)
+	at com.example.App.main(App.java:5)
+Caused by: java.io.IOException: disk full
+	at com.example.Store.write(Store.java:88)
+	... 2 more
+{"level":"info","msg":"next"}`
+
+	entries := aggregate(input)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %q", len(entries), entries)
+	}
+	if len(entries[1]) != 6 {
+		t.Errorf("stack trace has %d lines, want 6: %q", len(entries[1]), entries[1])
+	}
+}
+
+func TestAggregator_IndentedTraceAttachesToJSON(t *testing.T) {
+	input := `{"level":"error","msg":"failed"}
+    at handler (/app/index.js:10:5)
+    at process (/app/index.js:20:3)`
+
+	entries := aggregate(input)
+	if len(entries) != 1 || len(entries[0]) != 3 {
+		t.Errorf("got %q, want one entry with 3 lines", entries)
+	}
+}
+
+func TestAggregator_GoPanic(t *testing.T) {
+	input := `panic: runtime error: index out of range [3] with length 2
+
+goroutine 1 [running]:
+main.lookup(...)
+	/app/main.go:12
+main.main()
+	/app/main.go:7 +0x1d
+
+goroutine 6 [chan receive]:
+github.com/acme/worker.(*Pool).run(0xc000010000)
+	/app/worker.go:40 +0x55
+created by github.com/acme/worker.New in goroutine 1
+	/app/worker.go:20 +0x7a
+exit status 2`
+
+	entries := aggregate(input)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %q", len(entries), entries)
+	}
+	if got := entries[0][len(entries[0])-1]; got != "\t/app/worker.go:20 +0x7a" {
+		t.Errorf("panic should end with the last frame, got %q", got)
+	}
+	if !reflect.DeepEqual(entries[1], []string{"exit status 2"}) {
+		t.Errorf("entry after the panic = %q", entries[1])
+	}
+}
+
+func TestAggregator_PythonTraceback(t *testing.T) {
+	input := `Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ValueError: bad value
+INFO: next line`
+
+	entries := aggregate(input)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %q", len(entries), entries)
+	}
+	if entries[0][len(entries[0])-1] != "ValueError: bad value" {
+		t.Errorf("traceback should include the exception line, got %q", entries[0])
+	}
+}
+
+func TestAggregator_PlainLinesStaySeparate(t *testing.T) {
+	input := `Starting application...
+DEBUG: Loading configuration
+
+WARNING: This is a plain text warning`
+
+	entries := aggregate(input)
+	if len(entries) != 3 {
+		t.Errorf("got %d entries, want 3: %q", len(entries), entries)
+	}
+}
+
+func TestAggregator_RunFlushesAfterTimeout(t *testing.T) {
+	lines := make(chan string)
+	emitted := make(chan []string, 10)
+
+	go New().Run(lines, 20*time.Millisecond, func(entry []string) {
+		emitted <- entry
+	})
+
+	lines <- "panic: boom"
+	lines <- "goroutine 1 [running]:"
+
+	select {
+	case entry := <-emitted:
+		if len(entry) != 2 {
+			t.Errorf("flushed entry = %q, want 2 lines", entry)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending entry was not flushed after the timeout")
+	}
+	close(lines)
+}
+
+func TestAggregator_RunDisabled(t *testing.T) {
+	lines := make(chan string, 3)
+	lines <- "first"
+	lines <- "    indented"
+	close(lines)
+
+	var entries [][]string
+	New().Run(lines, 0, func(entry []string) {
+		entries = append(entries, entry)
+	})
+	if len(entries) != 2 {
+		t.Errorf("got %d entries with aggregation disabled, want 2", len(entries))
+	}
+}
@@ -0,0 +1,377 @@
+// Package output writes log entries to a stream, for using lg in scripts
+// and pipelines instead of the TUI.
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/redact"
+	"github.com/thalessoares/lg/internal/table"
+	"github.com/thalessoares/lg/internal/theme"
+)
+
+// Format is how entries are written
+type Format string
+
+const (
+	Pretty   Format = "pretty"   // Indented, colorized fields as in the TUI
+	Compact  Format = "compact"  // One line per entry with the selected columns
+	JSONL    Format = "jsonl"    // One JSON object per line
+	CSV      Format = "csv"      // The selected columns, with a header row
+	Markdown Format = "markdown" // A table of the selected columns
+)
+
+// Formats lists the accepted output formats
+var Formats = []Format{Pretty, Compact, JSONL, CSV, Markdown}
+
+// Styles for compact lines, set by SetTheme
+var (
+	extraStyle lipgloss.Style
+	plainStyle lipgloss.Style
+)
+
+func init() {
+	SetTheme(theme.Dark)
+}
+
+// SetTheme colors compact lines with a theme
+func SetTheme(t theme.Theme) {
+	extraStyle = theme.Fg(t.Muted)
+	plainStyle = theme.Fg(t.Faint).Italic(true)
+}
+
+// ParseFormat converts an --output name to a Format
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(strings.TrimSpace(name)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (available: %v)", name, Formats)
+}
+
+// Options selects and formats the entries to write
+type Options struct {
+	Format   Format
+	Columns  []string         // Columns for the compact, CSV and Markdown formats (defaults to table.DefaultColumns)
+	Query    *query.Query     // Only write matching entries (nil for all)
+	MinLevel parser.Level     // Skip entries below this level
+	Since    time.Time        // Skip entries older than this (zero for no bound)
+	Until    time.Time        // Skip entries newer than this (zero for no bound)
+	Head     int              // Stop after this many matching entries and the context after the last (0 for no limit)
+	Plain    bool             // Strip colors from pretty and compact lines
+	SortKeys bool             // Sort the keys of pretty entries instead of keeping their order
+	Context  int              // Also write this many entries before and after each query match, like grep -C
+	Redact   *redact.Redactor // Mask sensitive values in what is written (nil for none)
+}
+
+// Writer filters entries and writes them in the chosen format
+type Writer struct {
+	w       *bufio.Writer
+	opts    Options
+	written int
+	matched int
+	header  bool // Whether the CSV or Markdown header was written
+
+	// Context around query matches
+	before []*parser.LogEntry // Entries since the last one written, at most Context
+	after  int                // Entries left to write after the last match
+	gap    bool               // Whether entries were skipped since the last one written
+}
+
+// New creates a Writer. Output is buffered until Flush.
+func New(w io.Writer, opts Options) *Writer {
+	if len(opts.Columns) == 0 {
+		opts.Columns = table.DefaultColumns
+	}
+	return &Writer{w: bufio.NewWriter(w), opts: opts}
+}
+
+// Match reports whether an entry passes the query, level and time range
+func (w *Writer) Match(e *parser.LogEntry) bool {
+	return w.inRange(e) && (w.opts.Query == nil || w.opts.Query.Match(e))
+}
+
+// inRange reports whether an entry passes the level and time range, which
+// also apply to context entries
+func (w *Writer) inRange(e *parser.LogEntry) bool {
+	if w.opts.MinLevel != parser.LevelUnknown && e.Level < w.opts.MinLevel {
+		return false
+	}
+	if !e.Time.IsZero() {
+		if !w.opts.Since.IsZero() && e.Time.Before(w.opts.Since) {
+			return false
+		}
+		if !w.opts.Until.IsZero() && e.Time.After(w.opts.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes the entry if it matches, or if it is within Context entries
+// of a match. It returns false once Head matching entries have been written
+// and the caller should stop.
+func (w *Writer) Write(e *parser.LogEntry) (bool, error) {
+	if w.full() {
+		return false, nil
+	}
+	if !w.inRange(e) {
+		return true, nil
+	}
+
+	switch {
+	case w.opts.Query == nil || w.opts.Query.Match(e):
+		for _, b := range w.before {
+			if err := w.write(b); err != nil {
+				return false, err
+			}
+		}
+		w.before = w.before[:0]
+		w.after = w.opts.Context
+		w.matched++
+	case w.after > 0:
+		w.after--
+	case w.opts.Context > 0:
+		if len(w.before) == w.opts.Context {
+			w.before = append(w.before[:0], w.before[1:]...)
+			w.gap = true
+		}
+		w.before = append(w.before, e)
+		return true, nil
+	default:
+		w.gap = true
+		return true, nil
+	}
+
+	if err := w.write(e); err != nil {
+		return false, err
+	}
+	return !w.full(), nil
+}
+
+// full reports whether Head matching entries and the context after them
+// have been written
+func (w *Writer) full() bool {
+	return w.opts.Head > 0 && w.matched >= w.opts.Head && w.after == 0
+}
+
+// write writes an entry in the chosen format. With context, a "--" line
+// separates entries that are not consecutive in the text formats, as grep
+// does.
+func (w *Writer) write(e *parser.LogEntry) error {
+	if w.gap && w.written > 0 && w.opts.Context > 0 && w.isText() {
+		if err := w.line(extraStyle.Render("--")); err != nil {
+			return err
+		}
+	}
+	w.gap = false
+	e = w.opts.Redact.Entry(e)
+
+	var err error
+	switch w.opts.Format {
+	case Compact:
+		err = w.line(sourceTag(e) + w.compact(e))
+	case JSONL:
+		err = w.jsonl(e)
+	case CSV, Markdown:
+		err = w.row(w.cells(e))
+	default:
+		err = w.line(sourceTag(e) + w.pretty(e))
+	}
+	if err != nil {
+		return err
+	}
+	w.written++
+	return nil
+}
+
+// Flush writes any buffered output, and the header of a CSV or Markdown
+// table without rows
+func (w *Writer) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// isText reports whether the format is pretty or compact text
+func (w *Writer) isText() bool {
+	switch w.opts.Format {
+	case JSONL, CSV, Markdown:
+		return false
+	}
+	return true
+}
+
+// line writes a pretty or compact line, without colors if asked to
+func (w *Writer) line(s string) error {
+	if w.opts.Plain {
+		s = ansi.Strip(s)
+	}
+	_, err := w.w.WriteString(s + "\n")
+	return err
+}
+
+// sourceTag names the input of entries read from several, e.g. the stdout
+// and stderr of a command
+func sourceTag(e *parser.LogEntry) string {
+	if e.Source == "" {
+		return ""
+	}
+	return parser.SourceStyle(e.Source).Render("["+e.Source+"]") + " "
+}
+
+// pretty renders the entry as in the TUI, including any stack trace
+func (w *Writer) pretty(e *parser.LogEntry) string {
+	formatted := e.Formatted
+	if w.opts.SortKeys {
+		formatted = e.FormattedSorted()
+	}
+	if len(e.Continuation) == 0 {
+		return formatted
+	}
+	return formatted + "\n" + e.FormattedBody()
+}
+
+// compact renders the selected columns and the remaining fields on one line
+func (w *Writer) compact(e *parser.LogEntry) string {
+	if e.Parsed == nil {
+		return plainStyle.Render(strings.ReplaceAll(e.Raw, "\n", "⏎"))
+	}
+
+	cells := make([]string, 0, len(w.opts.Columns)+1)
+	for _, col := range w.opts.Columns {
+		v, _, ok := table.Value(e, col)
+		if !ok {
+			continue
+		}
+		if col == "level" {
+			v = e.Level.Style().Render(v)
+		}
+		cells = append(cells, v)
+	}
+	if extra := table.Extra(e, w.opts.Columns); extra != "" {
+		cells = append(cells, extraStyle.Render(extra))
+	}
+	if len(e.Continuation) > 0 {
+		cells = append(cells, extraStyle.Render("⏎"+strings.Join(e.Continuation, "⏎")))
+	}
+	return strings.Join(cells, " ")
+}
+
+// jsonl writes an entry as one line of JSON
+func (w *Writer) jsonl(e *parser.LogEntry) error {
+	line, err := JSON(e)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(line, '\n'))
+	return err
+}
+
+// cells returns the values of the selected columns for the CSV and
+// Markdown formats. Plain text goes in the message column, followed by
+// any stack trace.
+func (w *Writer) cells(e *parser.LogEntry) []string {
+	cells := make([]string, len(w.opts.Columns))
+	for i, col := range w.opts.Columns {
+		v, _, _ := table.Value(e, col)
+		if col == "msg" || col == "message" {
+			if e.Parsed == nil {
+				v, _, _ = strings.Cut(e.Raw, "\n")
+			}
+			if len(e.Continuation) > 0 {
+				v += "\n" + strings.Join(e.Continuation, "\n")
+			}
+		}
+		cells[i] = v
+	}
+	return cells
+}
+
+// row writes one row of a CSV or Markdown table, after the header
+func (w *Writer) row(cells []string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if w.opts.Format == CSV {
+		return w.csv(cells)
+	}
+	return w.markdown(cells)
+}
+
+// writeHeader writes the column names of a CSV or Markdown table once
+func (w *Writer) writeHeader() error {
+	if w.header || (w.opts.Format != CSV && w.opts.Format != Markdown) {
+		return nil
+	}
+	w.header = true
+	if w.opts.Format == CSV {
+		return w.csv(w.opts.Columns)
+	}
+	if err := w.markdown(w.opts.Columns); err != nil {
+		return err
+	}
+	rule := make([]string, len(w.opts.Columns))
+	for i := range rule {
+		rule[i] = "---"
+	}
+	return w.markdown(rule)
+}
+
+// csv writes one CSV record
+func (w *Writer) csv(cells []string) error {
+	c := csv.NewWriter(w.w)
+	if err := c.Write(cells); err != nil {
+		return err
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// markdownEscaper keeps cells on one line and pipes from ending them
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+// markdown writes one row of a Markdown table
+func (w *Writer) markdown(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = markdownEscaper.Replace(c)
+	}
+	_, err := w.w.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+	return err
+}
+
+// JSON encodes an entry as one line of JSON. JSON entries are kept as they
+// were read; for the others, structured fields become an object in the
+// order they were read, plain text goes in "msg", and stack traces in
+// "body".
+func JSON(e *parser.LogEntry) ([]byte, error) {
+	if e.IsJSON && len(e.Continuation) == 0 {
+		return []byte(e.Raw), nil
+	}
+
+	fields := parser.NewObject()
+	for _, k := range e.Parsed.Keys() {
+		v, _ := e.Parsed.Get(k)
+		fields.Set(k, v)
+	}
+	if e.Parsed == nil {
+		first, _, _ := strings.Cut(e.Raw, "\n")
+		fields.Set("msg", first)
+	}
+	if len(e.Continuation) > 0 {
+		fields.Set("body", strings.Join(e.Continuation, "\n"))
+	}
+	return fields.MarshalJSON()
+}
@@ -0,0 +1,261 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/redact"
+)
+
+var testEntries = []*parser.LogEntry{
+	parser.Parse(`{"time":"10:00:00","level":"info","msg":"started","port":8080}`),
+	parser.Parse(`time=10:00:01 level=error msg="request failed" status=502`),
+	parser.Parse(`plain text line`),
+	parser.ParseLines([]string{`{"level":"error","msg":"boom"}`, `    at Main.run(Main.java:1)`}, nil),
+}
+
+func write(t *testing.T, opts Options) string {
+	t.Helper()
+	var b bytes.Buffer
+	w := New(&b, opts)
+	for _, e := range testEntries {
+		more, err := w.Write(e)
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if !more {
+			break
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	return ansi.Strip(b.String())
+}
+
+func TestWriter_Formats(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "compact",
+			opts: Options{Format: Compact},
+			want: "10:00:00 info started port=8080\n" +
+				"10:00:01 error request failed status=502\n" +
+				"plain text line\n" +
+				"error boom ⏎    at Main.run(Main.java:1)\n",
+		},
+		{
+			name: "compact columns",
+			opts: Options{Format: Compact, Columns: []string{"msg", "status"}},
+			want: "started time=10:00:00 level=info port=8080\n" +
+				"request failed 502 time=10:00:01 level=error\n" +
+				"plain text line\n" +
+				"boom level=error ⏎    at Main.run(Main.java:1)\n",
+		},
+		{
+			name: "jsonl",
+			opts: Options{Format: JSONL},
+			want: `{"time":"10:00:00","level":"info","msg":"started","port":8080}` + "\n" +
+				`{"time":"10:00:01","level":"error","msg":"request failed","status":"502"}` + "\n" +
+				`{"msg":"plain text line"}` + "\n" +
+				`{"level":"error","msg":"boom","body":"    at Main.run(Main.java:1)"}` + "\n",
+		},
+		{
+			name: "csv",
+			opts: Options{Format: CSV, Columns: []string{"time", "msg", "status"}},
+			want: "time,msg,status\n" +
+				"10:00:00,started,\n" +
+				"10:00:01,request failed,502\n" +
+				",plain text line,\n" +
+				",\"boom\n    at Main.run(Main.java:1)\",\n",
+		},
+		{
+			name: "markdown",
+			opts: Options{Format: Markdown, Columns: []string{"level", "msg"}},
+			want: "| level | msg |\n" +
+				"| --- | --- |\n" +
+				"| info | started |\n" +
+				"| error | request failed |\n" +
+				"|  | plain text line |\n" +
+				"| error | boom<br>    at Main.run(Main.java:1) |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := write(t, tt.opts); got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_Pretty(t *testing.T) {
+	got := write(t, Options{Format: Pretty})
+	for _, want := range []string{`"port": 8080`, `"status": "502"`, "plain text line", "at Main.run"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("pretty output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriter_TableHeader(t *testing.T) {
+	for format, want := range map[Format]string{
+		CSV:      "level,msg\n",
+		Markdown: "| level | msg |\n| --- | --- |\n",
+	} {
+		var b bytes.Buffer
+		w := New(&b, Options{Format: format, Columns: []string{"level", "msg"}})
+		w.Write(parser.Parse(`{"level":"info","msg":"a | b"}`))
+		w.Flush()
+		if got := b.String(); !strings.HasPrefix(got, want) || strings.Count(got, want) != 1 {
+			t.Errorf("%s output = %q, want one header %q", format, got, want)
+		}
+
+		// Without rows, the header alone
+		b.Reset()
+		New(&b, Options{Format: format, Columns: []string{"level", "msg"}}).Flush()
+		if got := b.String(); got != want {
+			t.Errorf("%s output without rows = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestWriter_Plain(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(1) // 256 colors
+
+	e := parser.Parse(`time=10:00:00 level=error msg=boom`)
+	e.Source = "api"
+	for _, format := range []Format{Pretty, Compact} {
+		for _, plain := range []bool{false, true} {
+			var b bytes.Buffer
+			w := New(&b, Options{Format: format, Plain: plain})
+			w.Write(e)
+			w.Flush()
+			if got := b.String(); (got == ansi.Strip(got)) != plain {
+				t.Errorf("%s output with Plain %v = %q", format, plain, got)
+			}
+		}
+	}
+}
+
+func TestWriter_SourceTag(t *testing.T) {
+	e := parser.Parse(`panic: boom`)
+	e.Source = "stderr"
+
+	for _, format := range []Format{Pretty, Compact} {
+		var b bytes.Buffer
+		w := New(&b, Options{Format: format})
+		w.Write(e)
+		w.Flush()
+		if got := ansi.Strip(b.String()); got != "[stderr] panic: boom\n" {
+			t.Errorf("%s output = %q, want the source tag", format, got)
+		}
+	}
+}
+
+func TestWriter_Filters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want int
+	}{
+		{"all", Options{}, 4},
+		{"query", Options{Query: query.MustCompile("level=error")}, 2},
+		{"level", Options{MinLevel: parser.LevelError}, 2},
+		{"head", Options{Head: 3}, 3},
+		{"head with query", Options{Query: query.MustCompile("level=error"), Head: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = Compact
+			if got := strings.Count(write(t, tt.opts), "\n"); got != tt.want {
+				t.Errorf("wrote %d entries, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_Redact(t *testing.T) {
+	e := parser.Parse(`{"level":"info","msg":"login bob@example.com","password":"hunter2"}`)
+	for _, format := range Formats {
+		var b bytes.Buffer
+		w := New(&b, Options{Format: format, Columns: []string{"msg", "password"}, Redact: redact.Default()})
+		if _, err := w.Write(e); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		w.Flush()
+		got := b.String()
+		if strings.Contains(got, "hunter2") || strings.Contains(got, "bob@example.com") || !strings.Contains(got, redact.Mask) {
+			t.Errorf("%s output = %q, want the password and email masked", format, got)
+		}
+	}
+}
+
+func TestWriter_Context(t *testing.T) {
+	var lines []*parser.LogEntry
+	for _, msg := range []string{"a", "b", "boom", "c", "d", "e", "f", "boom", "g", "boom", "h"} {
+		lines = append(lines, parser.Parse(msg))
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"no context", Options{}, "boom\nboom\nboom\n"},
+		{"one", Options{Context: 1}, "b\nboom\nc\n--\nf\nboom\ng\nboom\nh\n"},
+		{"two", Options{Context: 2}, "a\nb\nboom\nc\nd\ne\nf\nboom\ng\nboom\nh\n"},
+		{"head", Options{Context: 1, Head: 2}, "b\nboom\nc\n--\nf\nboom\ng\n"},
+		{"jsonl without separators", Options{Context: 1, Format: JSONL}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.Format == "" {
+				tt.opts.Format = Compact
+			}
+			tt.opts.Query = query.MustCompile("boom")
+			tt.opts.Columns = []string{"msg"}
+			var b bytes.Buffer
+			w := New(&b, tt.opts)
+			for _, e := range lines {
+				if more, _ := w.Write(e); !more {
+					break
+				}
+			}
+			w.Flush()
+			got := ansi.Strip(b.String())
+			if tt.opts.Format == JSONL {
+				if strings.Contains(got, "--") || strings.Count(got, "\n") != 8 {
+					t.Errorf("output =\n%s\nwant 8 entries without separators", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"pretty", "compact", "JSONL", "csv", "markdown"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(\"xml\") should fail")
+	}
+}
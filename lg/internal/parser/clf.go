@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// clfParser parses Common Log Format access logs and the nginx/Apache
+// "combined" variant, which adds the referer and user agent:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/5.0"
+type clfParser struct{}
+
+func (clfParser) Name() string { return "clf" }
+
+var clfRe = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?(.*)$`)
+
+func (clfParser) Parse(line string) (*Object, bool) {
+	m := clfRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+
+	fields := NewObject()
+	fields.Set("remote_addr", m[1])
+	if m[2] != "-" {
+		fields.Set("ident", m[2])
+	}
+	if m[3] != "-" {
+		fields.Set("remote_user", m[3])
+	}
+	fields.Set("time", m[4])
+	fields.Set("request", m[5])
+
+	// Split "GET /path HTTP/1.1" so method and path can be filtered on
+	if parts := strings.Fields(m[5]); len(parts) == 3 {
+		fields.Set("method", parts[0])
+		fields.Set("path", parts[1])
+		fields.Set("protocol", parts[2])
+	}
+
+	status, _ := strconv.Atoi(m[6])
+	fields.Set("status", json.Number(strconv.Itoa(status)))
+	if bytes, err := strconv.Atoi(m[7]); err == nil {
+		fields.Set("bytes", json.Number(strconv.Itoa(bytes)))
+	}
+
+	if m[8] != "" && m[8] != "-" {
+		fields.Set("referer", m[8])
+	}
+	if m[9] != "" && m[9] != "-" {
+		fields.Set("user_agent", m[9])
+	}
+	if extra := strings.TrimSpace(m[10]); extra != "" {
+		fields.Set("extra", extra)
+	}
+	return fields, true
+}
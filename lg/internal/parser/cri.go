@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// criParser parses lines with a timestamp prefix, as written by containerd
+// and CRI-O, or by kubectl logs --timestamps and docker logs -t:
+//
+//	2025-01-01T10:00:00.123456789Z stdout F {"level":"info","msg":"ready"}
+//	2025-01-01T10:00:00.123Z {"level":"info","msg":"ready"}
+//	2025-01-01T10:00:00.123Z stderr F panic: boom
+//
+// The JSON object after the prefix is decoded, and the prefix's time and
+// stream become fields, unless the object has its own: a time under any of
+// the keys read for timestamps, such as zap's ts, is kept over the prefix's. With the stream
+// there, plain text after the prefix becomes msg; without it, only a JSON
+// object is claimed.
+type criParser struct{}
+
+func (criParser) Name() string { return "cri" }
+
+// criRe splits a prefixed line into its time, its stream and tag (F for a
+// full line, P for part of one), and the rest
+var criRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})) (?:(stdout|stderr) ([FP]) ?)?(.*)$`)
+
+func (criParser) Parse(line string) (*Object, bool) {
+	m := criRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	if _, ok := ParseTimestamp(m[1]); !ok {
+		return nil, false
+	}
+
+	rest := strings.TrimSpace(m[4])
+	var obj *Object
+	if strings.HasPrefix(rest, "{") {
+		obj, _ = decodeObject(rest)
+	}
+
+	fields := NewObject()
+	if !hasTime(obj) {
+		fields.Set("time", m[1])
+	}
+	if m[2] != "" {
+		fields.Set("stream", m[2])
+	}
+	switch {
+	case obj != nil:
+		for _, k := range obj.Keys() {
+			v, _ := obj.Get(k)
+			fields.Set(k, v)
+		}
+	case m[2] != "":
+		fields.Set("msg", rest)
+		if m[3] == "P" {
+			fields.Set("partial", true)
+		}
+	default:
+		return nil, false
+	}
+	return fields, true
+}
+
+// hasTime reports whether an object has a field read for the timestamp
+func hasTime(obj *Object) bool {
+	if obj == nil {
+		return false
+	}
+	for _, k := range timeKeys {
+		if _, ok := obj.Get(k); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Lookup resolves a dotted key path (e.g. "user.id", "items.0" or "items[0]")
+// against parsed log data. Keys that themselves contain dots, like the
+// "log.level" key used by ECS loggers, are matched before descending.
+func Lookup(data *Object, path string) (any, bool) {
+	if data == nil || path == "" {
+		return nil, false
+	}
+	return lookup(data, splitPath(path))
+}
+
+func lookup(v any, segments []string) (any, bool) {
+	if len(segments) == 0 {
+		return v, true
+	}
+
+	switch node := v.(type) {
+	case *Object:
+		// Prefer the longest literal key so "log.level" wins over log -> level
+		for i := len(segments); i > 0; i-- {
+			key := strings.Join(segments[:i], ".")
+			if child, ok := node.Get(key); ok {
+				if found, ok := lookup(child, segments[i:]); ok {
+					return found, true
+				}
+			}
+		}
+	case []any:
+		idx, err := strconv.Atoi(segments[0])
+		if err != nil || idx < 0 || idx >= len(node) {
+			return nil, false
+		}
+		return lookup(node[idx], segments[1:])
+	}
+	return nil, false
+}
+
+// splitPath splits a key path on dots and array brackets
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	parts := strings.Split(path, ".")
+
+	segments := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			segments = append(segments, p)
+		}
+	}
+	return segments
+}
+
+// ValueString returns the plain text form of a parsed value: strings are
+// returned as-is and everything else is rendered as compact JSON
+func ValueString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case nil:
+		return "null"
+	default:
+		return formatAny(val)
+	}
+}
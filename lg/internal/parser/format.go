@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parser turns a single log line into structured fields. Parse returns false
+// when the line is not in the parser's format.
+type Parser interface {
+	Name() string
+	Parse(line string) (*Object, bool)
+}
+
+// detectOrder lists the parsers tried, in order, when auto-detecting the
+// format of a line. Stricter formats go first so logfmt, the most lenient,
+// does not claim lines meant for the others.
+var detectOrder = []Parser{
+	jsonParser{},
+	criParser{},
+	syslogParser{},
+	klogParser{},
+	clfParser{},
+	logfmtParser{},
+}
+
+// formatAliases maps alternative --format names to parsers
+var formatAliases = map[string]string{
+	"nginx":      "clf",
+	"combined":   "clf",
+	"glog":       "klog",
+	"rfc5424":    "syslog",
+	"containerd": "cri",
+}
+
+// ParserFor returns the parser for a --format name. "auto" (or an empty
+// name) returns nil, which makes ParseWith detect the format of every line.
+func ParserFor(name string) (Parser, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "auto" {
+		return nil, nil
+	}
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	for _, p := range detectOrder {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown format %q (available: %s)", name, strings.Join(Formats(), ", "))
+}
+
+// Reparse rebuilds an entry from its Raw text and the Format it was parsed
+// with, e.g. after it was stored on disk. Entries that were plain text stay
+// plain even if another format would now claim them.
+func Reparse(raw, format string) *LogEntry {
+	var p Parser = plainParser{}
+	if format != "" {
+		if fp, err := ParserFor(format); err == nil && fp != nil {
+			p = fp
+		}
+	}
+	return ParseLines(strings.Split(raw, "\n"), p)
+}
+
+// plainParser never understands a line, so it keeps entries as plain text
+type plainParser struct{}
+
+func (plainParser) Name() string { return "" }
+
+func (plainParser) Parse(string) (*Object, bool) { return nil, false }
+
+// Formats returns the names accepted by ParserFor
+func Formats() []string {
+	names := []string{"auto"}
+	for _, p := range detectOrder {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// detect returns the first parser that understands the line
+func detect(line string) (Parser, *Object) {
+	for _, p := range detectOrder {
+		if fields, ok := p.Parse(line); ok {
+			return p, fields
+		}
+	}
+	return nil, nil
+}
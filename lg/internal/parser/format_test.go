@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParse_DetectsFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+		fields map[string]string
+	}{
+		{
+			name:   "logfmt",
+			input:  `level=info msg="request done" status=200 path=/api/users`,
+			format: "logfmt",
+			fields: map[string]string{"level": "info", "msg": "request done", "status": "200", "path": "/api/users"},
+		},
+		{
+			name:   "logfmt with escapes",
+			input:  `ts=2025-01-01T10:00:00Z msg="say \"hi\""`,
+			format: "logfmt",
+			fields: map[string]string{"msg": `say "hi"`},
+		},
+		{
+			name:   "rfc5424 syslog",
+			input:  `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			format: "syslog",
+			fields: map[string]string{
+				"facility":  "20",
+				"severity":  "5",
+				"timestamp": "2003-10-11T22:14:15.003Z",
+				"hostname":  "mymachine.example.com",
+				"app":       "evntslog",
+				"msgid":     "ID47",
+				"msg":       "An application event",
+				"structured_data.exampleSDID@32473.eventSource": "Application",
+			},
+		},
+		{
+			name:   "rfc5424 syslog without structured data",
+			input:  `<34>1 2003-10-11T22:14:15.003Z host su - - - 'su root' failed`,
+			format: "syslog",
+			fields: map[string]string{"severity": "2", "app": "su", "msg": "'su root' failed"},
+		},
+		{
+			name:   "bsd syslog",
+			input:  `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed`,
+			format: "syslog",
+			fields: map[string]string{"hostname": "mymachine", "app": "su", "procid": "123", "msg": "'su root' failed"},
+		},
+		{
+			name:   "nginx combined",
+			input:  `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+			format: "clf",
+			fields: map[string]string{
+				"remote_addr": "127.0.0.1",
+				"remote_user": "frank",
+				"time":        "10/Oct/2000:13:55:36 -0700",
+				"method":      "GET",
+				"path":        "/apache_pb.gif",
+				"status":      "200",
+				"bytes":       "2326",
+				"referer":     "http://www.example.com/start.html",
+				"user_agent":  "Mozilla/4.08",
+			},
+		},
+		{
+			name:   "common log format",
+			input:  `10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "POST /login HTTP/1.1" 500 -`,
+			format: "clf",
+			fields: map[string]string{"method": "POST", "status": "500"},
+		},
+		{
+			name:   "klog",
+			input:  `I0102 15:04:05.123456   12345 server.go:42] Serving on :8080`,
+			format: "klog",
+			fields: map[string]string{"level": "info", "thread_id": "12345", "source": "server.go:42", "msg": "Serving on :8080"},
+		},
+		{
+			name:   "structured klog",
+			input:  `E0102 15:04:05.123456       1 pod.go:7] "Sync failed" pod="default/web" err="timeout"`,
+			format: "klog",
+			fields: map[string]string{"level": "error", "msg": "Sync failed", "pod": "default/web", "err": "timeout"},
+		},
+		{
+			name:   "cri with json",
+			input:  `2025-01-01T10:00:00.123456789Z stdout F {"level":"info","msg":"ready","port":8080}`,
+			format: "cri",
+			fields: map[string]string{"time": "2025-01-01T10:00:00.123456789Z", "stream": "stdout", "level": "info", "msg": "ready", "port": "8080"},
+		},
+		{
+			name:   "cri with text",
+			input:  `2025-01-01T10:00:00.123Z stderr F panic: boom`,
+			format: "cri",
+			fields: map[string]string{"stream": "stderr", "msg": "panic: boom"},
+		},
+		{
+			name:   "timestamp before json",
+			input:  `2025-01-01T10:00:00+02:00 {"time":"10:00:00.5","msg":"ready"}`,
+			format: "cri",
+			fields: map[string]string{"time": "10:00:00.5", "msg": "ready"},
+		},
+		{
+			name:   "cri with zap json",
+			input:  `2025-01-01T10:00:01Z stdout F {"level":"info","ts":1735725600.5,"msg":"ready"}`,
+			format: "cri",
+			fields: map[string]string{"stream": "stdout", "ts": "1735725600.5", "msg": "ready"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Parse(tt.input)
+			if entry == nil || entry.Parsed == nil {
+				t.Fatalf("Parse(%q) did not produce fields", tt.input)
+			}
+			if entry.Format != tt.format {
+				t.Errorf("Format = %q, want %q", entry.Format, tt.format)
+			}
+			if entry.IsJSON {
+				t.Error("IsJSON should be false for non-JSON formats")
+			}
+			for path, want := range tt.fields {
+				v, ok := Lookup(entry.Parsed, path)
+				if !ok {
+					t.Errorf("missing field %q in %v", path, entry.Parsed)
+					continue
+				}
+				if got := ValueString(v); got != want {
+					t.Errorf("field %q = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_PlainTextNotDetected(t *testing.T) {
+	inputs := []string{
+		"Starting application...",
+		"DEBUG: Loading configuration",
+		"WARNING: This is a plain text warning",
+		"Not valid JSON {",
+		"retrying with timeout=5s",
+		`key="unterminated value=1`,
+		"<999>1 not syslog",
+		"2025-01-01T10:00:00Z starting up",
+		"2025-13-01T10:00:00Z stdout F bad month",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			entry := Parse(input)
+			if entry.Parsed != nil {
+				t.Errorf("Parse(%q) detected format %q, want plain text", input, entry.Format)
+			}
+		})
+	}
+}
+
+func TestParseWith_Override(t *testing.T) {
+	p, err := ParserFor("logfmt")
+	if err != nil {
+		t.Fatalf("ParserFor() error = %v", err)
+	}
+
+	// A JSON line is plain text when the format is forced to logfmt
+	entry := ParseWith(`{"level":"info"}`, p)
+	if entry.Parsed != nil {
+		t.Error("ParseWith(logfmt) should not parse JSON")
+	}
+
+	entry = ParseWith(`level=info msg=hi`, p)
+	if entry.Format != "logfmt" {
+		t.Errorf("Format = %q, want logfmt", entry.Format)
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"auto", "", false},
+		{"", "", false},
+		{"json", "json", false},
+		{"nginx", "clf", false},
+		{"GLOG", "klog", false},
+		{"containerd", "cri", false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParserFor(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParserFor(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			got := ""
+			if p != nil {
+				got = p.Name()
+			}
+			if got != tt.want {
+				t.Errorf("ParserFor(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReparse(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		p     Parser
+	}{
+		{"json", []string{`{"level":"error","msg":"boom"}`}, nil},
+		{"logfmt", []string{`level=warn msg="disk full"`}, nil},
+		{"forced plain", []string{`level=warn msg="disk full"`}, jsonParser{}},
+		{"stack trace", []string{`java.lang.IllegalStateException: boom`, `    at com.example.Main.run(Main.java:1)`}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := ParseLines(tt.lines, tt.p)
+			got := Reparse(orig.Raw, orig.Format)
+			if got.Raw != orig.Raw || got.Format != orig.Format || got.Level != orig.Level ||
+				(got.Parsed == nil) != (orig.Parsed == nil) || len(got.Continuation) != len(orig.Continuation) {
+				t.Errorf("Reparse() = %+v, want %+v", got, orig)
+			}
+		})
+	}
+}
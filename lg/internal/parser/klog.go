@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// klogParser parses the glog/klog header used by Kubernetes components,
+// including klog's structured form with a quoted message and key=value pairs:
+//
+//	I0102 15:04:05.123456   12345 server.go:42] Serving on :8080
+//	E0102 15:04:05.123456       1 pod.go:7] "Sync failed" pod="default/web" err="timeout"
+type klogParser struct{}
+
+func (klogParser) Name() string { return "klog" }
+
+var klogRe = regexp.MustCompile(`^([IWEF])(\d{4} \d\d:\d\d:\d\d\.\d+)\s+(\d+) ([^ \]]+:\d+)\] ?(.*)$`)
+
+var klogSeverities = map[string]string{
+	"I": "info",
+	"W": "warning",
+	"E": "error",
+	"F": "fatal",
+}
+
+func (klogParser) Parse(line string) (*Object, bool) {
+	m := klogRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+
+	fields := NewObject()
+	fields.Set("level", klogSeverities[m[1]])
+	fields.Set("time", m[2])
+	threadID, _ := strconv.Atoi(m[3])
+	fields.Set("thread_id", json.Number(strconv.Itoa(threadID)))
+	fields.Set("source", m[4])
+
+	msg := m[5]
+	var pairs *Object
+	if strings.HasPrefix(msg, `"`) {
+		if quoted, rest, ok := readQuoted(msg); ok {
+			if kv, leftover, ok := parseLogfmtPairs(rest); ok && strings.TrimSpace(leftover) == "" {
+				pairs = kv
+				msg = quoted
+			} else if strings.TrimSpace(rest) == "" {
+				msg = quoted
+			}
+		}
+	}
+	fields.Set("msg", msg)
+	for _, k := range pairs.Keys() {
+		if _, exists := fields.Get(k); !exists {
+			v, _ := pairs.Get(k)
+			fields.Set(k, v)
+		}
+	}
+	return fields, true
+}
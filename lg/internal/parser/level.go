@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Level is the normalized severity of an entry
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// Levels lists the known levels from least to most severe
+var Levels = []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+// levelKeys are the fields checked, in order, for an entry's level
+var levelKeys = []string{"level", "lvl", "severity", "log.level", "levelname", "loglevel"}
+
+var levelNames = map[string]Level{
+	"trace":         LevelTrace,
+	"debug":         LevelDebug,
+	"dbg":           LevelDebug,
+	"d":             LevelDebug,
+	"info":          LevelInfo,
+	"information":   LevelInfo,
+	"informational": LevelInfo,
+	"notice":        LevelInfo,
+	"i":             LevelInfo,
+	"warn":          LevelWarn,
+	"warning":       LevelWarn,
+	"w":             LevelWarn,
+	"error":         LevelError,
+	"err":           LevelError,
+	"e":             LevelError,
+	"fatal":         LevelFatal,
+	"critical":      LevelFatal,
+	"crit":          LevelFatal,
+	"panic":         LevelFatal,
+	"alert":         LevelFatal,
+	"emerg":         LevelFatal,
+	"emergency":     LevelFatal,
+	"f":             LevelFatal,
+}
+
+// Styles for level badges
+var levelStyles map[Level]lipgloss.Style
+
+// plainLevelRe finds an upper-case level word near the start of a plain
+// line, as in "WARNING: disk full" or "2025-01-01 10:00:00 [ERROR] boom"
+var plainLevelRe = regexp.MustCompile(`(?:^|[\s\[(|<])(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|ERR|FATAL|CRITICAL|CRIT|PANIC)(?:[\]\s:|)>]|$)`)
+
+// plainLevelWindow is how far into a plain line the level word may appear
+const plainLevelWindow = 48
+
+// String returns the lower-case level name
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// Badge returns a fixed-width, colored three letter tag for the level
+func (l Level) Badge() string {
+	abbr := map[Level]string{
+		LevelTrace: "TRC",
+		LevelDebug: "DBG",
+		LevelInfo:  "INF",
+		LevelWarn:  "WRN",
+		LevelError: "ERR",
+		LevelFatal: "FTL",
+	}[l]
+	if abbr == "" {
+		return "   "
+	}
+	return l.Style().Render(abbr)
+}
+
+// Style returns the style used to color the level
+func (l Level) Style() lipgloss.Style {
+	return levelStyles[l]
+}
+
+// ParseLevel converts a level name (e.g. "warn", "WARNING", "err") to a Level
+func ParseLevel(name string) (Level, bool) {
+	l, ok := levelNames[strings.ToLower(strings.TrimSpace(name))]
+	return l, ok
+}
+
+// DetectLevel finds the level of an entry from its fields, or from a level
+// word at the start of the line for plain text
+func DetectLevel(e *LogEntry) Level {
+	if e.Parsed != nil {
+		for _, key := range levelKeys {
+			if v, ok := Lookup(e.Parsed, key); ok {
+				if l := levelFromValue(v); l != LevelUnknown {
+					return l
+				}
+			}
+		}
+	}
+
+	head, _, _ := strings.Cut(e.Raw, "\n")
+	if len(head) > plainLevelWindow {
+		head = head[:plainLevelWindow]
+	}
+	if m := plainLevelRe.FindStringSubmatch(head); m != nil {
+		l, _ := ParseLevel(m[1])
+		return l
+	}
+
+	// Unlabelled stack traces are errors
+	if len(e.Continuation) > 0 {
+		switch {
+		case strings.HasPrefix(head, "panic:"), strings.HasPrefix(head, "fatal error:"):
+			return LevelFatal
+		case strings.HasPrefix(head, "Traceback"), strings.Contains(head, "Exception"), strings.Contains(head, "Error"):
+			return LevelError
+		}
+	}
+	return LevelUnknown
+}
+
+// levelFromValue maps level names, pino/bunyan numbers (10-60) and syslog
+// severities (0-7) to a Level
+func levelFromValue(v any) Level {
+	var n float64
+	switch val := v.(type) {
+	case string:
+		if l, ok := ParseLevel(val); ok {
+			return l
+		}
+		num, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return LevelUnknown
+		}
+		n = num
+	case json.Number:
+		num, err := val.Float64()
+		if err != nil {
+			return LevelUnknown
+		}
+		n = num
+	default:
+		return LevelUnknown
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return LevelUnknown
+	}
+
+	switch {
+	case n >= 10:
+		// pino/bunyan: 10 trace, 20 debug, 30 info, 40 warn, 50 error, 60 fatal;
+		// anything above counts as fatal
+		return Levels[int(min(n, 60))/10-1]
+	case n >= 0 && n <= 7:
+		// syslog: 0-2 emerg/alert/crit, 3 err, 4 warning, 5-6 notice/info, 7 debug
+		return [...]Level{LevelFatal, LevelFatal, LevelFatal, LevelError, LevelWarn, LevelInfo, LevelInfo, LevelDebug}[int(n)]
+	}
+	return LevelUnknown
+}
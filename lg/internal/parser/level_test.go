@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestDetectLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Level
+	}{
+		{"level string", `{"level":"error","msg":"x"}`, LevelError},
+		{"upper case", `{"level":"WARNING"}`, LevelWarn},
+		{"lvl key", `{"lvl":"dbg"}`, LevelDebug},
+		{"severity key", `{"severity":"CRITICAL"}`, LevelFatal},
+		{"ecs dotted key", `{"log.level":"info"}`, LevelInfo},
+		{"ecs nested key", `{"log":{"level":"warn"}}`, LevelWarn},
+		{"pino info", `{"level":30,"msg":"x"}`, LevelInfo},
+		{"pino error", `{"level":50}`, LevelError},
+		{"bunyan fatal", `{"level":60}`, LevelFatal},
+		{"pino trace", `{"level":10}`, LevelTrace},
+		{"numeric string", `{"level":"40"}`, LevelWarn},
+		{"above fatal", `{"level":75}`, LevelFatal},
+		{"huge number", `{"level":1e300,"msg":"x"}`, LevelFatal},
+		{"huge numeric string", `{"level":"1e300"}`, LevelFatal},
+		{"infinite string", `{"level":"inf"}`, LevelUnknown},
+		{"nan string", `{"level":"NaN"}`, LevelUnknown},
+		{"syslog severity", `<11>1 2003-10-11T22:14:15.003Z host app - - - disk failure`, LevelError},
+		{"syslog info", `<14>Oct 11 22:14:15 host app: started`, LevelInfo},
+		{"logfmt", `level=warn msg="slow query"`, LevelWarn},
+		{"klog", `E0102 15:04:05.123456 1 pod.go:7] failed`, LevelError},
+		{"plain warning", "WARNING: This is a plain text warning", LevelWarn},
+		{"plain debug", "DEBUG: Loading configuration", LevelDebug},
+		{"plain bracketed", "2025-01-01 10:00:00 [ERROR] connection refused", LevelError},
+		{"plain without level", "Starting application...", LevelUnknown},
+		{"lower case prose", "an error occurred", LevelUnknown},
+		{"no level field", `{"msg":"hello"}`, LevelUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Parse(tt.input)
+			if entry.Level != tt.want {
+				t.Errorf("Level = %v, want %v", entry.Level, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLevel_StackTrace(t *testing.T) {
+	entry := ParseLines([]string{"panic: boom", "", "goroutine 1 [running]:"}, nil)
+	if entry.Level != LevelFatal {
+		t.Errorf("Level = %v, want fatal", entry.Level)
+	}
+
+	entry = ParseLines([]string{"java.lang.IllegalStateException: boom", "\tat App.run(App.java:1)"}, nil)
+	if entry.Level != LevelError {
+		t.Errorf("Level = %v, want error", entry.Level)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   Level
+		wantOK bool
+	}{
+		{"warn", LevelWarn, true},
+		{"WARNING", LevelWarn, true},
+		{" err ", LevelError, true},
+		{"verbose", LevelUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ParseLevel(tt.input)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseLevel(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
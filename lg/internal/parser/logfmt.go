@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"strings"
+)
+
+// logfmtParser parses key=value lines such as
+//
+//	level=info msg="request done" status=200 duration=12ms
+type logfmtParser struct{}
+
+func (logfmtParser) Name() string { return "logfmt" }
+
+// minLogfmtPairs keeps prose with a single "=" from being read as logfmt
+const minLogfmtPairs = 2
+
+func (logfmtParser) Parse(line string) (*Object, bool) {
+	fields, rest, ok := parseLogfmtPairs(line)
+	if !ok || strings.TrimSpace(rest) != "" || fields.Len() < minLogfmtPairs {
+		return nil, false
+	}
+	return fields, true
+}
+
+// parseLogfmtPairs reads key=value pairs until it reaches something that is
+// not a pair, returning the fields and the unparsed remainder
+func parseLogfmtPairs(s string) (*Object, string, bool) {
+	fields := NewObject()
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return fields, "", true
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || !isLogfmtKey(s[:eq]) {
+			return fields, s, fields.Len() > 0
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			v, rest, ok := readQuoted(s)
+			if !ok {
+				return nil, "", false
+			}
+			value, s = v, rest
+			if s != "" && s[0] != ' ' && s[0] != '\t' {
+				return nil, "", false
+			}
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+			if strings.ContainsRune(value, '"') {
+				return nil, "", false
+			}
+		}
+		fields.Set(key, value)
+	}
+}
+
+// isLogfmtKey reports whether s is a plausible logfmt key
+func isLogfmtKey(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_', r == '-', r == '.', r == '/', r == '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// readQuoted reads a double-quoted string with backslash escapes from the
+// start of s, returning the unquoted value and the rest of s
+func readQuoted(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", false
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
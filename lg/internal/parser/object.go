@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Object holds the fields of a structured entry, or a nested JSON object,
+// with its keys in the order they were read. Numbers are json.Number, so
+// 64-bit IDs keep every digit.
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewObject creates an empty object
+func NewObject() *Object {
+	return &Object{values: make(map[string]any)}
+}
+
+// Set sets the value of a key. New keys go after the others; a key set
+// again keeps its place.
+func (o *Object) Set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Get returns the value of a key
+func (o *Object) Get(key string) (any, bool) {
+	if o == nil {
+		return nil, false
+	}
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Len returns the number of keys
+func (o *Object) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.keys)
+}
+
+// Keys returns the keys in the order they were read, which callers must
+// not modify
+func (o *Object) Keys() []string {
+	if o == nil {
+		return nil
+	}
+	return o.keys
+}
+
+// SortedKeys returns the keys in alphabetical order
+func (o *Object) SortedKeys() []string {
+	keys := slices.Clone(o.Keys())
+	sort.Strings(keys)
+	return keys
+}
+
+// MarshalJSON encodes the object with its keys in order
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.Keys() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(marshalString(k))
+		b.WriteByte(':')
+		v, err := marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// marshal encodes a value as compact JSON without escaping HTML
+func marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// marshalString encodes a string as JSON
+func marshalString(s string) string {
+	b, _ := marshal(s)
+	return string(b)
+}
+
+// decodeObject decodes a JSON object, keeping its key order and decoding
+// numbers as json.Number. Anything after the object is an error.
+func decodeObject(s string) (*Object, error) {
+	v, err := decodeAll(s)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(*Object)
+	if !ok {
+		return nil, errors.New("not a JSON object")
+	}
+	return obj, nil
+}
+
+// decodeAll decodes a JSON value that must make up all of s
+func decodeAll(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("data after the JSON value")
+	}
+	return v, nil
+}
+
+// decodeValue decodes the next JSON value: an *Object, []any, string,
+// json.Number, bool or nil
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := NewObject()
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := tok.(string)
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key, v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case '[':
+		items := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return nil, errors.New("unexpected " + delim.String())
+}
+
+// expandEmbedded replaces string values holding a JSON object or array,
+// such as an escaped payload in a message field, with the decoded value,
+// and does the same inside it
+func expandEmbedded(v any) any {
+	switch val := v.(type) {
+	case *Object:
+		for _, k := range val.keys {
+			val.values[k] = expandEmbedded(val.values[k])
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = expandEmbedded(item)
+		}
+	case string:
+		if decoded, ok := decodeEmbedded(val); ok {
+			return expandEmbedded(decoded)
+		}
+	}
+	return v
+}
+
+// decodeEmbedded decodes a string that is a whole JSON object or array
+func decodeEmbedded(s string) (any, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || !(s[0] == '{' && s[len(s)-1] == '}' || s[0] == '[' && s[len(s)-1] == ']') {
+		return nil, false
+	}
+	v, err := decodeAll(s)
+	return v, err == nil
+}
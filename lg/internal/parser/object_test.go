@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParse_KeepsKeyOrder(t *testing.T) {
+	entry := Parse(`{"time":"t","level":"info","msg":"hi","user":{"name":"a","id":1},"b":2,"a":1}`)
+	if entry == nil || entry.Parsed == nil {
+		t.Fatal("Parse() returned no fields")
+	}
+
+	if got := strings.Join(entry.Parsed.Keys(), ","); got != "time,level,msg,user,b,a" {
+		t.Errorf("Keys() = %q, want read order", got)
+	}
+	if got := strings.Join(entry.Parsed.SortedKeys(), ","); got != "a,b,level,msg,time,user" {
+		t.Errorf("SortedKeys() = %q, want alphabetical order", got)
+	}
+	user, _ := entry.Parsed.Get("user")
+	if got := strings.Join(user.(*Object).Keys(), ","); got != "name,id" {
+		t.Errorf("nested Keys() = %q, want read order", got)
+	}
+
+	formatted := ansi.Strip(entry.Formatted)
+	if strings.Index(formatted, `"b":`) > strings.Index(formatted, `"a":`) {
+		t.Errorf("Formatted = %q, want b before a", formatted)
+	}
+	sorted := ansi.Strip(entry.FormattedSorted())
+	if strings.Index(sorted, `"a":`) > strings.Index(sorted, `"b":`) {
+		t.Errorf("FormattedSorted() = %q, want a before b", sorted)
+	}
+}
+
+func TestParse_ExactNumbers(t *testing.T) {
+	entry := Parse(`{"id":12345678901234567891,"ratio":1.50,"small":-3}`)
+	if entry == nil || entry.Parsed == nil {
+		t.Fatal("Parse() returned no fields")
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"id", "12345678901234567891"},
+		{"ratio", "1.50"},
+		{"small", "-3"},
+	}
+	for _, tt := range tests {
+		v, _ := entry.Parsed.Get(tt.key)
+		if got := ValueString(v); got != tt.want {
+			t.Errorf("ValueString(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+		if !strings.Contains(ansi.Strip(entry.Formatted), tt.want) {
+			t.Errorf("Formatted = %q, want it to contain %s", entry.Formatted, tt.want)
+		}
+	}
+}
+
+func TestParse_TrailingDataNotJSON(t *testing.T) {
+	for _, input := range []string{`{"a":1} {"b":2}`, `{"a":1} trailing`, `{"a":1`} {
+		if entry := Parse(input); entry.IsJSON {
+			t.Errorf("Parse(%q).IsJSON = true, want false", input)
+		}
+	}
+}
+
+func TestObject_MarshalJSON(t *testing.T) {
+	obj := NewObject()
+	obj.Set("z", "first")
+	obj.Set("a", []any{json.Number("1"), NewObject()})
+	obj.Set("html", "<b>")
+	obj.Set("z", "again") // Keeps its place
+
+	got, err := obj.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if want := `{"z":"again","a":[1,{}],"html":"<b>"}`; string(got) != want {
+		t.Errorf("MarshalJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestParse_EmbeddedJSON(t *testing.T) {
+	entry := Parse(`{"level":"info","message":"{\"user\":{\"id\":7},\"tags\":\"[\\\"a\\\"]\"}","note":"{not json}"}`)
+	if entry == nil || entry.Parsed == nil {
+		t.Fatal("Parse() returned no fields")
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"message.user.id", "7"},
+		{"message.tags.0", "a"},
+		{"note", "{not json}"},
+	}
+	for _, tt := range tests {
+		v, ok := Lookup(entry.Parsed, tt.path)
+		if got := ValueString(v); !ok || got != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", tt.path, got, ok, tt.want)
+		}
+	}
+	if !entry.IsJSON || entry.Raw == "" {
+		t.Error("the entry should stay JSON with its raw line")
+	}
+}
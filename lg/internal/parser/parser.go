@@ -1,71 +1,163 @@
 package parser
 
 import (
-	"bytes"
 	"encoding/json"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-// LogEntry represents a parsed log entry (JSON or plain text)
+// LogEntry represents a parsed log entry (structured or plain text)
 type LogEntry struct {
-	Raw       string         // Original line
-	Parsed    map[string]any // Parsed JSON data (nil for non-JSON)
-	Formatted string         // Pretty-printed and colorized output
-	IsJSON    bool           // Whether the entry is valid JSON
+	Raw       string    // Original line
+	Parsed    *Object   // Parsed fields, in the order they were read (nil for plain text)
+	Formatted string    // Pretty-printed and colorized output
+	IsJSON    bool      // Whether the entry is valid JSON
+	Format    string    // Name of the parser that understood the line ("" for plain text)
+	Level     Level     // Detected severity (LevelUnknown when there is none)
+	Time      time.Time // Detected timestamp (zero when there is none)
+	Source    string    // Input the entry was read from, e.g. "stderr" ("" when there is only one)
+	Highlight string    // Name of the rule highlighting the entry ("" for none)
+
+	// Continuation holds the lines merged into the entry after the first
+	// one, such as a stack trace. Raw then contains every line.
+	Continuation []string
 }
 
-// Styles for JSON colorization
+// Styles for JSON colorization, set by SetTheme
 var (
-	keyStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))  // Cyan
-	stringStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("82"))  // Green
-	numberStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange
-	boolStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("205")) // Pink
-	nullStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241")) // Gray
-	braceStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245")) // Light gray
-	plainTextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Italic(true) // Dimmed for non-JSON
+	keyStyle       lipgloss.Style
+	stringStyle    lipgloss.Style
+	numberStyle    lipgloss.Style
+	boolStyle      lipgloss.Style
+	nullStyle      lipgloss.Style
+	braceStyle     lipgloss.Style
+	plainTextStyle lipgloss.Style // Dimmed for non-JSON
+	traceStyle     lipgloss.Style // Stack trace lines
 )
 
-// Parse attempts to parse a line as JSON and returns a LogEntry
-// For valid JSON, returns a pretty-printed colorized entry
-// For non-JSON, returns a dimmed plain text entry
+// Parse auto-detects the format of a line and returns a LogEntry.
+// Structured lines (JSON, logfmt, syslog, ...) are pretty-printed and
+// colorized, anything else becomes a dimmed plain text entry. String fields
+// holding JSON are decoded and shown as nested objects.
 func Parse(line string) *LogEntry {
+	return ParseWith(line, nil)
+}
+
+// ParseWith parses a line with the given parser, or auto-detects the format
+// when p is nil. Lines the parser does not understand become plain text.
+func ParseWith(line string, p Parser) *LogEntry {
 	line = strings.TrimSpace(line)
 	if line == "" {
 		return nil
 	}
 
-	var parsed map[string]any
-	if err := json.Unmarshal([]byte(line), &parsed); err != nil {
-		// Non-JSON line - return dimmed plain text
-		return &LogEntry{
+	var parsed *Object
+	if p == nil {
+		p, parsed = detect(line)
+	} else if fields, ok := p.Parse(line); ok {
+		parsed = fields
+	}
+
+	var entry *LogEntry
+	if parsed == nil {
+		// Unstructured line - return dimmed plain text
+		entry = &LogEntry{
 			Raw:       line,
 			Parsed:    nil,
 			Formatted: plainTextStyle.Render(line),
 			IsJSON:    false,
 		}
+	} else {
+		expandEmbedded(parsed)
+		entry = &LogEntry{
+			Raw:       line,
+			Parsed:    parsed,
+			Formatted: formatJSON(parsed, 0, false),
+			IsJSON:    p.Name() == "json",
+			Format:    p.Name(),
+		}
+	}
+
+	entry.Level = DetectLevel(entry)
+	entry.Time = DetectTime(entry)
+	return entry
+}
+
+// ParseLines parses a multi-line entry, as grouped by the multiline
+// package: the first line is parsed as usual and the rest are kept as its
+// continuation
+func ParseLines(lines []string, p Parser) *LogEntry {
+	if len(lines) == 0 {
+		return nil
+	}
+	entry := ParseWith(lines[0], p)
+	if entry == nil || len(lines) == 1 {
+		return entry
 	}
 
-	formatted := formatJSON(parsed, 0)
+	entry.Continuation = lines[1:]
+	entry.Raw = entry.Raw + "\n" + strings.Join(entry.Continuation, "\n")
+	if entry.Level == LevelUnknown {
+		entry.Level = DetectLevel(entry)
+	}
+	return entry
+}
 
-	return &LogEntry{
-		Raw:       line,
-		Parsed:    parsed,
-		Formatted: formatted,
-		IsJSON:    true,
+// Reformat rebuilds Formatted after Parsed or Raw were changed
+func (e *LogEntry) Reformat() {
+	if e.Parsed == nil {
+		first, _, _ := strings.Cut(e.Raw, "\n")
+		e.Formatted = plainTextStyle.Render(first)
+		return
 	}
+	e.Formatted = formatJSON(e.Parsed, 0, false)
 }
 
-// formatJSON recursively formats and colorizes JSON
-func formatJSON(data any, indent int) string {
+// FormattedSorted returns the pretty-printed fields with their keys in
+// alphabetical order, rather than the order they were read in
+func (e *LogEntry) FormattedSorted() string {
+	if e.Parsed == nil {
+		return e.Formatted
+	}
+	return formatJSON(e.Parsed, 0, true)
+}
+
+// FormattedBody returns the colorized continuation lines of the entry
+func (e *LogEntry) FormattedBody() string {
+	lines := make([]string, len(e.Continuation))
+	for i, l := range e.Continuation {
+		lines[i] = traceStyle.Render(strings.ReplaceAll(l, "\t", "    "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonParser parses single-line JSON objects
+type jsonParser struct{}
+
+func (jsonParser) Name() string { return "json" }
+
+func (jsonParser) Parse(line string) (*Object, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	parsed, err := decodeObject(line)
+	if err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// formatJSON recursively formats and colorizes JSON, with the keys of
+// objects in the order they were read or, with sorted, alphabetically
+func formatJSON(data any, indent int, sorted bool) string {
 	indentStr := strings.Repeat("  ", indent)
 	nextIndent := strings.Repeat("  ", indent+1)
 
 	switch v := data.(type) {
-	case map[string]any:
-		if len(v) == 0 {
+	case *Object:
+		if v.Len() == 0 {
 			return braceStyle.Render("{}")
 		}
 
@@ -73,18 +165,16 @@ func formatJSON(data any, indent int) string {
 		b.WriteString(braceStyle.Render("{"))
 		b.WriteString("\n")
 
-		// Sort keys for consistent output
-		keys := make([]string, 0, len(v))
-		for k := range v {
-			keys = append(keys, k)
+		keys := v.Keys()
+		if sorted {
+			keys = v.SortedKeys()
 		}
-		sort.Strings(keys)
-
 		for i, k := range keys {
+			value, _ := v.Get(k)
 			b.WriteString(nextIndent)
 			b.WriteString(keyStyle.Render("\"" + k + "\""))
 			b.WriteString(": ")
-			b.WriteString(formatJSON(v[k], indent+1))
+			b.WriteString(formatJSON(value, indent+1, sorted))
 			if i < len(keys)-1 {
 				b.WriteString(",")
 			}
@@ -106,7 +196,7 @@ func formatJSON(data any, indent int) string {
 
 		for i, item := range v {
 			b.WriteString(nextIndent)
-			b.WriteString(formatJSON(item, indent+1))
+			b.WriteString(formatJSON(item, indent+1, sorted))
 			if i < len(v)-1 {
 				b.WriteString(",")
 			}
@@ -120,8 +210,8 @@ func formatJSON(data any, indent int) string {
 	case string:
 		return stringStyle.Render("\"" + escapeString(v) + "\"")
 
-	case float64:
-		return numberStyle.Render(formatNumber(v))
+	case json.Number:
+		return numberStyle.Render(v.String())
 
 	case bool:
 		if v {
@@ -147,22 +237,10 @@ func escapeString(s string) string {
 	return s
 }
 
-// formatNumber formats a number, removing trailing zeros for integers
-func formatNumber(n float64) string {
-	if n == float64(int64(n)) {
-		return strings.TrimSuffix(strings.TrimSuffix(
-			strings.ReplaceAll(formatAny(int64(n)), ",", ""), ".0"), ".00")
-	}
-	return formatAny(n)
-}
-
-// formatAny formats any value as a string
+// formatAny formats any value as compact JSON
 func formatAny(v any) string {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	enc.SetEscapeHTML(false)
-	_ = enc.Encode(v)
-	return strings.TrimSpace(buf.String())
+	b, _ := marshal(v)
+	return string(b)
 }
 
 // MatchesFilter checks if the log entry matches a search query
@@ -162,3 +162,60 @@ func TestParse_NonJSON_Formatted(t *testing.T) {
 		t.Errorf("Raw = %q, want %q", entry.Raw, "Starting application...")
 	}
 }
+
+func TestLookup(t *testing.T) {
+	entry := Parse(`{"user":{"id":42,"name":"John"},"log.level":"warn","items":[{"sku":"a1"}]}`)
+
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"user.id", "42", true},
+		{"user.name", "John", true},
+		{"log.level", "warn", true},
+		{"items.0.sku", "a1", true},
+		{"items[0].sku", "a1", true},
+		{"items.1.sku", "", false},
+		{"user.missing", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, ok := Lookup(entry.Parsed, tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("Lookup(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if ok && ValueString(v) != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.path, ValueString(v), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLines_Continuation(t *testing.T) {
+	lines := []string{
+		`{"level":"error","message":"Request failed"}`,
+		"java.lang.IllegalStateException: boom",
+		"\tat com.example.App.run(App.java:12)",
+	}
+
+	entry := ParseLines(lines, nil)
+	if entry == nil || !entry.IsJSON {
+		t.Fatal("ParseLines() should parse the first line as JSON")
+	}
+	if len(entry.Continuation) != 2 {
+		t.Errorf("Continuation len = %d, want 2", len(entry.Continuation))
+	}
+	if !entry.MatchesFilter("App.java") {
+		t.Error("search should match text inside the continuation lines")
+	}
+	if entry.FormattedBody() == "" {
+		t.Error("FormattedBody() should render the continuation lines")
+	}
+
+	if entry := ParseLines(lines[:1], nil); len(entry.Continuation) != 0 {
+		t.Error("single line entries should have no continuation")
+	}
+}
@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"hash/fnv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	// sourceStyles are picked from for source tags, by name
+	sourceStyles []lipgloss.Style
+
+	// stderrStyle makes a command's stderr stand out from its stdout
+	stderrStyle lipgloss.Style
+)
+
+// SourceStyle returns the style for an entry's source tag. Every name keeps
+// the same color, so entries from one input are easy to follow.
+func SourceStyle(name string) lipgloss.Style {
+	if name == "stderr" {
+		return stderrStyle
+	}
+	if len(sourceStyles) == 0 {
+		return lipgloss.NewStyle().Bold(true)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return sourceStyles[h.Sum32()%uint32(len(sourceStyles))]
+}
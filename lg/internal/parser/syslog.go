@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// syslogParser parses RFC5424 syslog lines, and the older BSD (RFC3164)
+// format that many daemons still send:
+//
+//	<165>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [id@1 k="v"] message
+//	<34>Oct 11 22:14:15 host su[123]: 'su root' failed
+type syslogParser struct{}
+
+func (syslogParser) Name() string { return "syslog" }
+
+var (
+	syslogPriRe = regexp.MustCompile(`^<(\d{1,3})>`)
+	bsdHeaderRe = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?`)
+	sdParamRe   = regexp.MustCompile(`([^\s=\]"]+)="((?:[^"\\]|\\.)*)"`)
+)
+
+func (syslogParser) Parse(line string) (*Object, bool) {
+	m := syslogPriRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	pri, _ := strconv.Atoi(m[1])
+	if pri > 191 {
+		return nil, false
+	}
+
+	fields := NewObject()
+	fields.Set("facility", json.Number(strconv.Itoa(pri/8)))
+	fields.Set("severity", json.Number(strconv.Itoa(pri%8)))
+	rest := line[len(m[0]):]
+
+	if strings.HasPrefix(rest, "1 ") {
+		if !parseRFC5424(rest[2:], fields) {
+			return nil, false
+		}
+		return fields, true
+	}
+
+	if h := bsdHeaderRe.FindStringSubmatch(rest); h != nil {
+		fields.Set("timestamp", h[1])
+		fields.Set("hostname", h[2])
+		fields.Set("app", h[3])
+		if h[4] != "" {
+			fields.Set("procid", h[4])
+		}
+		rest = rest[len(h[0]):]
+	}
+	fields.Set("msg", rest)
+	return fields, true
+}
+
+// parseRFC5424 reads the header fields, structured data and message that
+// follow "<PRI>1 "
+func parseRFC5424(s string, fields *Object) bool {
+	header := []string{"timestamp", "hostname", "app", "procid", "msgid"}
+	for _, name := range header {
+		end := strings.IndexByte(s, ' ')
+		if end <= 0 {
+			return false
+		}
+		if value := s[:end]; value != "-" {
+			fields.Set(name, value)
+		}
+		s = s[end+1:]
+	}
+
+	// Structured data: "-" or one or more [id key="value" ...] elements
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "[") {
+		sd := NewObject()
+		for strings.HasPrefix(s, "[") {
+			end := sdElementEnd(s)
+			if end < 0 {
+				return false
+			}
+			element := s[1:end]
+			id, params, _ := strings.Cut(element, " ")
+			values := NewObject()
+			for _, p := range sdParamRe.FindAllStringSubmatch(params, -1) {
+				values.Set(p[1], strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`).Replace(p[2]))
+			}
+			sd.Set(id, values)
+			s = s[end+1:]
+		}
+		fields.Set("structured_data", sd)
+	} else {
+		return false
+	}
+
+	// The message may start with a UTF-8 byte order mark
+	msg := strings.TrimPrefix(strings.TrimPrefix(s, " "), "\ufeff")
+	if msg != "" {
+		fields.Set("msg", msg)
+	}
+	return true
+}
+
+// sdElementEnd finds the "]" closing a structured data element, skipping
+// escaped brackets inside quoted values
+func sdElementEnd(s string) int {
+	inQuotes := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case ']':
+			if !inQuotes {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/theme"
+)
+
+func init() {
+	SetTheme(theme.Dark)
+}
+
+// SetTheme colors levels, source tags and the Formatted output of entries
+// parsed from now on with a theme
+func SetTheme(t theme.Theme) {
+	keyStyle = theme.Fg(t.Primary)
+	stringStyle = theme.Fg(t.Success)
+	numberStyle = theme.Fg(t.Secondary)
+	boolStyle = theme.Fg(t.Accent)
+	nullStyle = theme.Fg(t.Muted)
+	braceStyle = theme.Fg(t.Dim)
+	plainTextStyle = theme.Fg(t.Faint).Italic(true)
+	traceStyle = theme.Fg(t.Dim)
+
+	levelStyles = map[Level]lipgloss.Style{
+		LevelUnknown: theme.Fg(t.Faint),
+		LevelTrace:   theme.Fg(t.Faint),
+		LevelDebug:   theme.Fg(t.Dim),
+		LevelInfo:    theme.Fg(t.Primary),
+		LevelWarn:    theme.Fg(t.Warning).Bold(true),
+		LevelError:   theme.Fg(t.Error).Bold(true),
+		LevelFatal:   theme.Fg(t.Inverse).Background(theme.Color(t.Error)).Bold(true),
+	}
+	if t.Mono {
+		levelStyles[LevelFatal] = lipgloss.NewStyle().Reverse(true).Bold(true)
+	}
+
+	sourceStyles = nil
+	for _, c := range t.Sources {
+		sourceStyles = append(sourceStyles, theme.Fg(c))
+	}
+	stderrStyle = theme.Fg(t.Warning)
+}
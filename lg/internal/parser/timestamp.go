@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeKeys are the fields checked, in order, for an entry's timestamp
+var timeKeys = []string{"@timestamp", "timestamp", "time", "ts", "datetime", "date", "eventTime"}
+
+// timeLayouts are the textual formats tried for timestamp values. Layouts
+// without a zone are read as local time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999", // Python logging
+	"02/Jan/2006:15:04:05 -0700",    // CLF
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+}
+
+// yearlessLayouts lack a year (BSD syslog, klog), which is taken from now:
+// the current year, or the previous one for dates ahead of now, as when
+// December logs are read in January
+var yearlessLayouts = []string{
+	"Jan _2 15:04:05.999999999",
+	"Jan _2 15:04:05",
+	"0102 15:04:05.999999999",
+}
+
+// leadingTimeRe finds a timestamp at the start of a plain line, optionally
+// in brackets: "2025-01-01T10:00:00Z msg" or "[2025-01-01 10:00:00,123] msg"
+var leadingTimeRe = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\]?(?:\s|$)`)
+
+// DetectTime finds the timestamp of an entry from its fields, or from a
+// timestamp at the start of the line for plain text. It returns the zero
+// time when there is none.
+func DetectTime(e *LogEntry) time.Time {
+	if e.Parsed != nil {
+		for _, key := range timeKeys {
+			if v, ok := Lookup(e.Parsed, key); ok {
+				if t, ok := timeFromValue(v); ok {
+					return t
+				}
+			}
+		}
+	}
+
+	if m := leadingTimeRe.FindStringSubmatch(e.Raw); m != nil {
+		if t, ok := ParseTimestamp(m[1]); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// timeFromValue converts a string or epoch number to a time
+func timeFromValue(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case string:
+		return ParseTimestamp(val)
+	case json.Number:
+		return ParseTimestamp(val.String())
+	}
+	return time.Time{}, false
+}
+
+// ParseTimestamp parses the timestamp formats commonly found in logs,
+// including epoch seconds, milliseconds, microseconds and nanoseconds
+func ParseTimestamp(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	if epochRe.MatchString(s) {
+		return epochDecimal(s)
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return epochTime(n)
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range yearlessLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			now := time.Now()
+			t = t.AddDate(now.Year(), 0, 0)
+			// A day of slack for clocks ahead of ours
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochRe matches an epoch written out in digits, with an optional fraction
+var epochRe = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+
+// epochDecimal is epochTime for a number written out in digits, read
+// exactly: a float64 has too few digits for nanoseconds since the epoch
+func epochDecimal(s string) (time.Time, bool) {
+	whole, frac, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Nanoseconds per unit, and the fraction digits it has room for
+	var unit int64
+	var digits int
+	switch {
+	case n >= 1e17:
+		unit, digits = 1, 0
+	case n >= 1e14:
+		unit, digits = 1e3, 3
+	case n >= 1e11:
+		unit, digits = 1e6, 6
+	case n >= 1e8:
+		unit, digits = 1e9, 9
+	default:
+		return time.Time{}, false
+	}
+	var ns int64
+	if digits > 0 {
+		ns, _ = strconv.ParseInt((frac + strings.Repeat("0", digits))[:digits], 10, 64)
+	}
+	// Past 2262 the nanoseconds no longer fit
+	if n > (math.MaxInt64-ns)/unit {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n*unit+ns), true
+}
+
+// epochTime interprets a number as seconds, milliseconds, microseconds or
+// nanoseconds since the epoch, depending on its magnitude
+func epochTime(n float64) (time.Time, bool) {
+	switch {
+	case n >= math.MaxInt64:
+		return time.Time{}, false
+	case n >= 1e17:
+		return time.Unix(0, int64(n)), true
+	case n >= 1e14:
+		return time.UnixMicro(int64(n)), true
+	case n >= 1e11:
+		return time.UnixMilli(int64(n)), true
+	case n >= 1e8:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeBound parses a --since/--until style bound: a duration ago
+// ("10m", "1h30m"), a timestamp ("2025-01-01T10:00:03Z"), a date
+// ("2025-01-01") or a time of day ("10:00", "10:00:03") on the day of ref
+func ParseTimeBound(s string, now, ref time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(strings.TrimPrefix(s, "-")); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, ok := ParseTimestamp(s); ok {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	if ref.IsZero() {
+		ref = now
+	}
+	for _, layout := range []string{"15:04:05.999999999", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, ref.Location()); err == nil {
+			y, mo, d := ref.Date()
+			return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), ref.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use e.g. 10m, 10:00:03 or 2025-01-01T10:00:03Z)", s)
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectTime(t *testing.T) {
+	want := time.Date(2025, 1, 1, 10, 0, 3, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{"rfc3339 timestamp", `{"timestamp":"2025-01-01T10:00:03Z"}`, want},
+		{"rfc3339 with offset", `{"time":"2025-01-01T07:00:03-03:00"}`, want},
+		{"rfc3339 nano", `{"ts":"2025-01-01T10:00:03.250Z"}`, want.Add(250 * time.Millisecond)},
+		{"elastic @timestamp", `{"@timestamp":"2025-01-01T10:00:03Z"}`, want},
+		{"epoch seconds", `{"ts":1735725603}`, want},
+		{"epoch seconds fraction", `{"ts":1735725603.5}`, want.Add(500 * time.Millisecond)},
+		{"epoch millis", `{"time":1735725603000}`, want},
+		{"epoch micros", `{"time":1735725603000000}`, want},
+		{"epoch nanos", `{"time":1735725603000000000}`, want},
+		{"epoch nanos exact", `{"time":1735725603123456789}`, want.Add(123456789 * time.Nanosecond)},
+		{"epoch millis fraction", `{"time":1735725603123.456}`, want.Add(123456 * time.Microsecond)},
+		{"epoch seconds nanos", `{"ts":"1735725603.123456789"}`, want.Add(123456789 * time.Nanosecond)},
+		{"epoch exponent", `{"ts":1.735725603e9}`, want},
+		{"epoch string", `{"ts":"1735725603"}`, want},
+		{"logfmt", `ts=2025-01-01T10:00:03Z level=info`, want},
+		{"clf", `127.0.0.1 - - [01/Jan/2025:10:00:03 +0000] "GET / HTTP/1.1" 200 5`, want},
+		{"plain leading", `2025-01-01T10:00:03Z stdout ready`, want},
+		{"cri prefix", `2025-01-01T10:00:03Z stdout F {"msg":"ready"}`, want},
+		{"cri with zap ts", `2025-01-01T10:00:09Z stdout F {"ts":1735725603,"msg":"ready"}`, want},
+		{"plain bracketed", `[2025-01-01T10:00:03Z] ready`, want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Parse(tt.input)
+			if !entry.Time.Equal(tt.want) {
+				t.Errorf("Time = %v, want %v", entry.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTime_Missing(t *testing.T) {
+	inputs := []string{
+		`{"msg":"no time"}`,
+		"Starting application...",
+		`{"time":"45ms"}`,
+		`{"ts":9300000000}`,            // Seconds past 2262
+		`{"ts":"9300000000.5"}`,        // Same, as a string
+		`{"time":9300000000000}`,       // Milliseconds past 2262
+		`{"time":9300000000000000}`,    // Microseconds past 2262
+		`{"time":9300000000000000000}`, // Nanoseconds past int64
+		`{"time":1e300}`,               // Too large for any unit
+		`{"time":"inf"}`,
+	}
+	for _, input := range inputs {
+		if entry := Parse(input); !entry.Time.IsZero() {
+			t.Errorf("Parse(%q).Time = %v, want zero", input, entry.Time)
+		}
+	}
+}
+
+func TestDetectTime_Yearless(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	// Dates ahead of now are from last year
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"earlier", now.AddDate(0, 0, -2), now.AddDate(0, 0, -2)},
+		{"now", now, now},
+		{"slightly ahead", now.Add(time.Hour), now.Add(time.Hour)},
+		{"ahead", now.AddDate(0, 0, 2), now.AddDate(0, 0, 2).AddDate(-1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := Parse("<34>" + tt.at.Format(time.Stamp) + " mymachine su: failed")
+			if !entry.Time.Equal(tt.want) {
+				t.Errorf("Time = %v, want %v", entry.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ref := time.Date(2025, 1, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"10m", now.Add(-10 * time.Minute), false},
+		{"-1h30m", now.Add(-90 * time.Minute), false},
+		{"2025-01-01T10:00:03Z", time.Date(2025, 1, 1, 10, 0, 3, 0, time.UTC), false},
+		{"10:00:03", time.Date(2025, 1, 1, 10, 0, 3, 0, time.UTC), false},
+		{"10:15", time.Date(2025, 1, 1, 10, 15, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTimeBound(tt.input, now, ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeBound(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseTimeBound(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package pattern clusters log messages into templates, with the tokens
+// that change between otherwise identical lines masked, and detects repeated
+// messages.
+package pattern
+
+import (
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/table"
+)
+
+// maxPatterns caps the templates counted; entries with new templates past
+// it are not counted
+const maxPatterns = 5000
+
+// timestamps match the times found in plain text lines: ISO 8601, syslog
+// and bare clock times
+var timestamps = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\b[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}\b|\b\d{2}:\d{2}:\d{2}(?:[.,]\d+)?`)
+
+// Masks for the variable tokens of a template, applied in order
+var (
+	uuidToken = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	ipv4Token = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d{1,5})?\b`)
+	ipv6Token = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:)*[0-9a-f]{0,4}::(?:[0-9a-f]{1,4}:)*[0-9a-f]{1,4}\b`)
+	hexToken  = regexp.MustCompile(`(?i)\b(?:0x)?[0-9a-f]{8,}\b`)
+	numToken  = regexp.MustCompile(`\b\d+(?:\.\d+)?`)
+)
+
+// Message returns the message of an entry: its msg field, or else its
+// first line without timestamps
+func Message(e *parser.LogEntry) string {
+	if msg, _, ok := table.Value(e, "msg"); ok {
+		return msg
+	}
+	first, _, _ := strings.Cut(e.Raw, "\n")
+	return strings.Join(strings.Fields(timestamps.ReplaceAllString(first, "")), " ")
+}
+
+// Template masks the variable tokens of a message: UUIDs, IP addresses,
+// long hex IDs and numbers
+func Template(msg string) string {
+	msg = uuidToken.ReplaceAllString(msg, "<uuid>")
+	msg = ipv4Token.ReplaceAllString(msg, "<ip>")
+	msg = ipv6Token.ReplaceAllString(msg, "<ip>")
+	msg = hexToken.ReplaceAllStringFunc(msg, func(s string) string {
+		// Words such as "deadbeef" are left alone
+		if strings.ContainsAny(s, "0123456789") {
+			return "<hex>"
+		}
+		return s
+	})
+	return numToken.ReplaceAllString(msg, "<num>")
+}
+
+// Key identifies the entries that dedup folds together: the same level,
+// source and message, whatever their timestamps
+func Key(e *parser.LogEntry) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte{byte(e.Level)})
+	h.Write([]byte(e.Source))
+	h.Write([]byte{0})
+	h.Write([]byte(Message(e)))
+	return h.Sum64()
+}
+
+// Pattern counts the entries of a template
+type Pattern struct {
+	Template string
+	Count    int
+	Errors   int // Entries at error level or above
+}
+
+// Index counts entries by template
+type Index struct {
+	patterns map[string]*Pattern
+}
+
+// New creates an empty Index
+func New() *Index {
+	return &Index{patterns: make(map[string]*Pattern)}
+}
+
+// Add counts an entry under its template
+func (x *Index) Add(e *parser.LogEntry) {
+	t := Template(Message(e))
+	p := x.patterns[t]
+	if p == nil {
+		if len(x.patterns) >= maxPatterns {
+			return
+		}
+		p = &Pattern{Template: t}
+		x.patterns[t] = p
+	}
+	p.Count++
+	if e.Level >= parser.LevelError {
+		p.Errors++
+	}
+}
+
+// Len returns the number of templates
+func (x *Index) Len() int {
+	return len(x.patterns)
+}
+
+// Patterns lists the templates, most common first
+func (x *Index) Patterns() []Pattern {
+	patterns := make([]Pattern, 0, len(x.patterns))
+	for _, p := range x.patterns {
+		patterns = append(patterns, *p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		return patterns[i].Template < patterns[j].Template
+	})
+	return patterns
+}
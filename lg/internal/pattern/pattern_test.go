@@ -0,0 +1,80 @@
+package pattern
+
+import (
+	"testing"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"retrying in 5s (attempt 3/10)", "retrying in <num>s (attempt <num>/<num>)"},
+		{"user 42 logged in from 10.0.0.5", "user <num> logged in from <ip>"},
+		{"dial tcp 10.0.0.5:5432: connection refused", "dial tcp <ip>: connection refused"},
+		{"request 3f2504e0-4f89-11d3-9a0c-0305e82c3301 done", "request <uuid> done"},
+		{"commit a1b2c3d4e5f6 pushed", "commit <hex> pushed"},
+		{"cache deadbeef warmed", "cache deadbeef warmed"},
+		{"peer fe80::1ff:fe23:4567:890a joined", "peer <ip> joined"},
+		{"took 1.5ms", "took <num>ms"},
+		{"no variables here", "no variables here"},
+	}
+	for _, tt := range tests {
+		if got := Template(tt.msg); got != tt.want {
+			t.Errorf("Template(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"time":"2025-01-01T10:00:00Z","msg":"retrying"}`, "retrying"},
+		{`{"time":"2025-01-01T10:00:00Z","message":"retrying"}`, "retrying"},
+		{`2025-01-01 10:00:00,123 WARN retrying`, "WARN retrying"},
+		{`Jan  2 10:00:00 host app: retrying`, "host app: retrying"},
+		{"retrying\n  at stack", "retrying"},
+	}
+	for _, tt := range tests {
+		if got := Message(parser.Parse(tt.line)); got != tt.want {
+			t.Errorf("Message(%s) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := parser.Parse(`{"time":"2025-01-01T10:00:00Z","level":"warn","msg":"retrying"}`)
+	b := parser.Parse(`{"time":"2025-01-01T10:00:05Z","level":"warn","msg":"retrying"}`)
+	c := parser.Parse(`{"time":"2025-01-01T10:00:05Z","level":"error","msg":"retrying"}`)
+	if Key(a) != Key(b) {
+		t.Error("entries differing only in time should share a key")
+	}
+	if Key(a) == Key(c) {
+		t.Error("entries at different levels should not share a key")
+	}
+	b.Source = "worker"
+	if Key(a) == Key(b) {
+		t.Error("entries from different sources should not share a key")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	x := New()
+	for _, line := range []string{
+		`{"level":"warn","msg":"retrying in 1s"}`,
+		`{"level":"warn","msg":"retrying in 2s"}`,
+		`{"level":"error","msg":"retrying in 4s"}`,
+		`{"level":"info","msg":"started"}`,
+	} {
+		x.Add(parser.Parse(line))
+	}
+	got := x.Patterns()
+	want := []Pattern{{"retrying in <num>s", 3, 1}, {"started", 1, 0}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Patterns() = %+v, want %+v", got, want)
+	}
+}
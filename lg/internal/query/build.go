@@ -0,0 +1,46 @@
+package query
+
+import (
+	"strings"
+)
+
+// Quote returns s as a literal for a query, in double quotes unless it is a
+// single bare word
+func Quote(s string) string {
+	if tokens, err := lex(s); err == nil && len(tokens) == 2 && tokens[0].kind == tokWord && tokens[0].text == s {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Condition builds a comparison such as `status=502` or
+// `service!="billing api"`, quoting the path and value as needed
+func Condition(path, op, value string) string {
+	return Quote(path) + op + Quote(value)
+}
+
+// Refine narrows a query with another condition. Plain text searches are
+// quoted so they stay a single phrase, and expressions with || are
+// parenthesized so the condition applies to all of them.
+func Refine(input, cond string) string {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return cond
+	}
+
+	tokens, err := lex(input)
+	if err != nil || isPlainText(tokens) {
+		return Quote(input) + " && " + cond
+	}
+	if _, err := Compile(input); err != nil {
+		// Invalid expressions are searched as text, see buffer.Filter
+		return Quote(input) + " && " + cond
+	}
+	for _, tok := range tokens {
+		if tok.kind == tokOr {
+			return "(" + input + ") && " + cond
+		}
+	}
+	return input + " && " + cond
+}
@@ -0,0 +1,56 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	tests := []struct {
+		path, op, value string
+		want            string
+	}{
+		{"level", "=", "error", `level=error`},
+		{"status", "!=", "502", `status!=502`},
+		{"service", "=", "billing api", `service="billing api"`},
+		{"msg", "=", `say "hi"`, `msg="say \"hi\""`},
+		{"a=b", "=", "x", `"a=b"=x`},
+		{"path", "=", "", `path=""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Condition(tt.path, tt.op, tt.value)
+			if got != tt.want {
+				t.Errorf("Condition() = %q, want %q", got, tt.want)
+			}
+			if _, err := Compile(got); err != nil {
+				t.Errorf("Compile(%q) error = %v", got, err)
+			}
+		})
+	}
+}
+
+func TestRefine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", `level=error`},
+		{"status>=500", `status>=500 && level=error`},
+		{"connection timeout", `"connection timeout" && level=error`},
+		{"a=1 || b=2", `(a=1 || b=2) && level=error`},
+		{"level=", `"level=" && level=error`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := Refine(tt.input, "level=error")
+			if got != tt.want {
+				t.Errorf("Refine(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if _, err := Compile(got); err != nil {
+				t.Errorf("Compile(%q) error = %v", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// isWordBreak reports whether the rune at i ends a bare word. "!", "&" and
+// "|" only break a word when they start an operator, so text like "failed!"
+// or "a&b" stays a single word.
+func isWordBreak(runes []rune, i int) bool {
+	r := runes[i]
+	next := rune(0)
+	if i+1 < len(runes) {
+		next = runes[i+1]
+	}
+
+	switch r {
+	case '!':
+		return next == '=' || next == '~'
+	case '&', '|':
+		return next == r
+	}
+	return unicode.IsSpace(r) || strings.ContainsRune(`()=<>~"`, r)
+}
+
+// lex splits a query into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", start})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", start})
+			i++
+
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			kind := tokAnd
+			if r == '|' {
+				kind = tokOr
+			}
+			tokens = append(tokens, token{kind, string(runes[i : i+2]), start})
+			i += 2
+
+		case r == '!':
+			if i+1 < len(runes) && (runes[i+1] == '=' || runes[i+1] == '~') {
+				tokens = append(tokens, token{tokOp, string(runes[i : i+2]), start})
+				i += 2
+			} else {
+				tokens = append(tokens, token{tokNot, "!", start})
+				i++
+			}
+
+		case r == '=' || r == '<' || r == '>':
+			op := string(r)
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				op += "="
+				i++
+			}
+			if op == "==" {
+				op = "="
+			}
+			tokens = append(tokens, token{tokOp, op, start})
+
+		case r == '~':
+			tokens = append(tokens, token{tokOp, "~", start})
+			i++
+
+		case r == '"':
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				// Only quotes and backslashes are escaped so regexes like "\d+" survive
+				if c == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if c == '"' {
+					closed = true
+					i++
+					break
+				}
+				b.WriteRune(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start+1)
+			}
+			tokens = append(tokens, token{tokString, b.String(), start})
+
+		default:
+			for i < len(runes) && !isWordBreak(runes, i) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[start:i]), start})
+		}
+	}
+
+	tokens = append(tokens, token{tokEOF, "", len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,331 @@
+// Package query implements the filter language used by the search bar.
+//
+// A query is either plain text, matched as a case-insensitive substring of
+// the raw line, or an expression evaluated against the parsed fields:
+//
+//	level=error
+//	status>=500
+//	duration_ms > 200 && path ~ "^/api"
+//	!(level=debug || user.id=42)
+//
+// Comparisons take a key path on the left (nested keys are separated by dots)
+// and one of =, !=, >, >=, <, <=, ~ (regex) or !~ on the right. Terms can be
+// combined with &&, || and !, grouped with parentheses, and adjacent terms
+// are implicitly AND-ed. A bare word or quoted string that is not part of a
+// comparison is a substring search, so `timeout && service=api` works too.
+//
+// The input an entry was read from, such as a -s label or "stderr", is
+// compared as @source.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// SourceField is the key path of an entry's source
+const SourceField = "@source"
+
+// Query is a compiled filter expression
+type Query struct {
+	source string
+	root   node
+}
+
+// Compile parses a query string. An empty query compiles to nil, which
+// matches every entry.
+func Compile(input string) (*Query, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queries made only of bare words keep the original substring semantics
+	if isPlainText(tokens) {
+		return Text(input), nil
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos+1)
+	}
+
+	return &Query{source: input, root: root}, nil
+}
+
+// Text returns a query that does a plain substring search, without
+// interpreting any operators in the input
+func Text(input string) *Query {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+	return &Query{source: input, root: textNode{text: strings.ToLower(input)}}
+}
+
+// MustCompile is like Compile but panics on error
+func MustCompile(input string) *Query {
+	q, err := Compile(input)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Match reports whether the entry satisfies the query. A nil query matches
+// everything.
+func (q *Query) Match(e *parser.LogEntry) bool {
+	if q == nil {
+		return true
+	}
+	return q.root.match(e)
+}
+
+// String returns the query as it was typed
+func (q *Query) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.source
+}
+
+func isPlainText(tokens []token) bool {
+	for _, tok := range tokens {
+		if tok.kind != tokWord && tok.kind != tokEOF {
+			return false
+		}
+	}
+	return true
+}
+
+// exprParser is a recursive descent parser over the token stream
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokString, tokNot, tokLParen:
+			// Implicit AND between adjacent terms
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *exprParser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d, got %s", closing.pos+1, closing)
+		}
+		return inner, nil
+
+	case tokWord, tokString:
+		if p.peek().kind == tokOp {
+			return p.parseComparison(tok.text)
+		}
+		return textNode{text: strings.ToLower(tok.text)}, nil
+
+	case tokOp:
+		return nil, fmt.Errorf("missing key before %q at position %d", tok.text, tok.pos+1)
+
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos+1)
+	}
+}
+
+func (p *exprParser) parseComparison(path string) (node, error) {
+	op := p.next()
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, fmt.Errorf("expected a value after %q at position %d, got %s", op.text, value.pos+1, value)
+	}
+
+	cmp := compareNode{path: path, op: op.text, value: value.text}
+	if num, err := strconv.ParseFloat(value.text, 64); err == nil {
+		cmp.num, cmp.isNum = num, true
+	}
+	if op.text == "~" || op.text == "!~" {
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", value.text, err)
+		}
+		cmp.re = re
+	}
+	return cmp, nil
+}
+
+// node is an element of the compiled expression tree
+type node interface {
+	match(e *parser.LogEntry) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(e *parser.LogEntry) bool { return n.left.match(e) && n.right.match(e) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(e *parser.LogEntry) bool { return n.left.match(e) || n.right.match(e) }
+
+type notNode struct{ inner node }
+
+func (n notNode) match(e *parser.LogEntry) bool { return !n.inner.match(e) }
+
+// textNode is a case-insensitive substring search over the raw line
+type textNode struct{ text string }
+
+func (n textNode) match(e *parser.LogEntry) bool { return e.MatchesFilter(n.text) }
+
+// compareNode compares the value at a key path against a literal
+type compareNode struct {
+	path  string
+	op    string
+	value string
+	num   float64
+	isNum bool
+	re    *regexp.Regexp
+}
+
+func (n compareNode) match(e *parser.LogEntry) bool {
+	v, ok := lookup(e, n.path)
+	if !ok {
+		// Missing keys only satisfy negative comparisons
+		return n.op == "!=" || n.op == "!~"
+	}
+	s := parser.ValueString(v)
+
+	switch n.op {
+	case "=":
+		return n.equals(s)
+	case "!=":
+		return !n.equals(s)
+	case "~":
+		return n.re.MatchString(s)
+	case "!~":
+		return !n.re.MatchString(s)
+	}
+
+	// Ordering: numeric when both sides are numbers, lexical otherwise
+	// (which also orders RFC3339 timestamps correctly)
+	var c int
+	if num, err := strconv.ParseFloat(s, 64); err == nil && n.isNum {
+		switch {
+		case num < n.num:
+			c = -1
+		case num > n.num:
+			c = 1
+		}
+	} else {
+		c = strings.Compare(s, n.value)
+	}
+
+	switch n.op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// lookup returns the value of a key path in an entry
+func lookup(e *parser.LogEntry, path string) (any, bool) {
+	if path == SourceField {
+		return e.Source, e.Source != ""
+	}
+	return parser.Lookup(e.Parsed, path)
+}
+
+func (n compareNode) equals(s string) bool {
+	// Integers compare exactly, so 64-bit IDs that round to the same float
+	// stay different
+	if a, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if b, err := strconv.ParseInt(n.value, 10, 64); err == nil {
+			return a == b
+		}
+	}
+	if n.isNum {
+		if num, err := strconv.ParseFloat(s, 64); err == nil {
+			return num == n.num
+		}
+	}
+	return strings.EqualFold(s, n.value)
+}
@@ -0,0 +1,172 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestCompile_Match(t *testing.T) {
+	entry := parser.Parse(`{"level":"error","message":"Request failed","status":502,"duration_ms":245.5,"path":"/api/users","user":{"id":42,"name":"John"},"log.level":"ERROR","tags":["a","b"]}`)
+	if entry == nil {
+		t.Fatal("Parse() returned nil")
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"request failed", true}, // plain text substring
+		{"FAILED", true},
+		{"notfound", false},
+		{"level=error", true},
+		{"level==ERROR", true}, // case insensitive equality
+		{"level=info", false},
+		{"level!=info", true},
+		{"status>=500", true},
+		{"status>502", false},
+		{"status<600", true},
+		{"status=502", true},
+		{"duration_ms > 200 && path ~ \"^/api\"", true},
+		{"duration_ms > 300 && path ~ \"^/api\"", false},
+		{"duration_ms > 300 || path ~ \"^/api\"", true},
+		{"user.id=42", true},
+		{"user.name=john", true},
+		{"log.level=error", true},
+		{"tags.1=b", true},
+		{"tags[0]=a", true},
+		{"!level=error", false},
+		{"!(level=info || status<500)", true},
+		{"missing=1", false},
+		{"missing!=1", true},
+		{"path!~^/internal", true},
+		{"level=error timeout", false}, // implicit AND with a text term
+		{"level=error failed", true},
+		{`"Request failed" && status>=500`, true},
+		{`message="Request failed"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Compile(tt.query)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.query, err)
+			}
+			if got := q.Match(entry); got != tt.want {
+				t.Errorf("Compile(%q).Match() = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_BigIntegers(t *testing.T) {
+	entry := parser.Parse(`{"trace_id":1234567890123456789,"span_id":9007199254740993}`)
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"trace_id=1234567890123456789", true},
+		{"trace_id=1234567890123456788", false}, // same float64, different integer
+		{"trace_id!=1234567890123456788", true},
+		{"span_id=9007199254740993", true},
+		{"span_id=9007199254740992", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := MustCompile(tt.query).Match(entry); got != tt.want {
+				t.Errorf("Compile(%q).Match() = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_PlainTextEntry(t *testing.T) {
+	entry := parser.Parse("WARNING: disk usage at 91%! check a&b")
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"warning", true},
+		{"usage at 91%!", true},
+		{"a&b", true},
+		{"level=warning", false},
+		{"warning && !error", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Compile(tt.query)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.query, err)
+			}
+			if got := q.Match(entry); got != tt.want {
+				t.Errorf("Compile(%q).Match() = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Source(t *testing.T) {
+	api := parser.Parse(`{"level":"info","source":"handler.go:12"}`)
+	api.Source = "api"
+	untagged := parser.Parse(`{"level":"info"}`)
+
+	tests := []struct {
+		query string
+		entry *parser.LogEntry
+		want  bool
+	}{
+		{"@source=api", api, true},
+		{"@source!=api", api, false},
+		{"@source=worker", api, false},
+		{`source="handler.go:12"`, api, true},
+		{"@source=api", untagged, false},
+		{"@source!=api", untagged, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := MustCompile(tt.query).Match(tt.entry); got != tt.want {
+				t.Errorf("Compile(%q).Match() = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []string{
+		"level=",
+		"=error",
+		"(level=error",
+		"level=error)",
+		`path~"("`,
+		`msg="unterminated`,
+		"level=error &&",
+		"status >= || x",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Compile(input); err == nil {
+				t.Errorf("Compile(%q) should return an error", input)
+			}
+		})
+	}
+}
+
+func TestQuery_NilMatchesAll(t *testing.T) {
+	q, err := Compile("   ")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if q != nil {
+		t.Fatal("Compile() of blank input should return nil")
+	}
+	if !q.Match(&parser.LogEntry{Raw: "anything"}) {
+		t.Error("nil query should match every entry")
+	}
+}
@@ -0,0 +1,344 @@
+// Package redact masks sensitive values in entries before they are shown or
+// written: the values of fields with sensitive names, such as password or
+// authorization, and text that looks like a secret wherever it appears,
+// such as JWTs, email addresses and card numbers.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// Mask replaces the values hidden
+const Mask = "[REDACTED]"
+
+// DefaultKeys are the field names masked by the default preset
+var DefaultKeys = []string{"password", "passwd", "secret", "secret_key", "token", "authorization", "api_key", "apikey", "cookie", "credential", "credentials", "private_key"}
+
+// pattern is text masked wherever it appears, when valid accepts it
+type pattern struct {
+	re    *regexp.Regexp
+	valid func(string) bool
+}
+
+// defaultPatterns are the values masked by the default preset
+var defaultPatterns = []pattern{
+	{re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)},         // JWT
+	{re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)}, // Email
+	{re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhn},                             // Card number
+}
+
+// Redactor masks the values matching its rules. A nil Redactor masks
+// nothing.
+type Redactor struct {
+	keys     [][]string // Split into words, see words
+	patterns []pattern
+}
+
+// New creates a Redactor with the default preset and extra field names and
+// regular expressions, e.g. from the configuration file. A field is masked
+// when its name ends in the words of one of the names, ignoring case, so
+// token masks access_token and refreshToken but not token_count.
+func New(keys, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, k := range slices.Concat(DefaultKeys, keys) {
+		if w := words(k); len(w) > 0 {
+			r.keys = append(r.keys, w)
+		}
+	}
+	r.patterns = append([]pattern(nil), defaultPatterns...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, pattern{re: re})
+	}
+	return r, nil
+}
+
+// Default creates a Redactor with the default preset only
+func Default() *Redactor {
+	r, _ := New(nil, nil)
+	return r
+}
+
+// words splits a field name into lowercase words at "_", "-", "." and
+// changes of case, so api_key, apiKey and X-Api-Key all end in api, key
+func words(key string) []string {
+	var out []string
+	var word []rune
+	runes := []rune(key)
+	for i, c := range runes {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if len(word) > 0 {
+				out = append(out, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+		// A new word starts at an upper case letter after a lower case one,
+		// or at the last upper case letter of an acronym, as in APIKey
+		if unicode.IsUpper(c) && len(word) > 0 {
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				out = append(out, string(word))
+				word = word[:0]
+			}
+		}
+		word = append(word, unicode.ToLower(c))
+	}
+	if len(word) > 0 {
+		out = append(out, string(word))
+	}
+	return out
+}
+
+// sensitive reports whether the value of a field is masked by its name: its
+// words end with the words of one of the keys
+func (r *Redactor) sensitive(key string) bool {
+	w := words(key)
+	for _, k := range r.keys {
+		if len(k) <= len(w) && slices.Equal(w[len(w)-len(k):], k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text masks the patterns in a string
+func (r *Redactor) Text(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+			return Mask
+		})
+	}
+	return s
+}
+
+// Entry returns a copy of an entry with its sensitive values masked in
+// every form it is shown or written in, or the entry itself when there is
+// nothing to mask
+func (r *Redactor) Entry(e *parser.LogEntry) *parser.LogEntry {
+	if r == nil || e == nil {
+		return e
+	}
+
+	fields, changed := r.value("", e.Parsed)
+
+	// Structured text, such as JSON or logfmt, keeps its layout: only the
+	// values of sensitive fields are masked in it, so strings holding JSON
+	// stay strings rather than the objects they are shown as
+	first, _, _ := strings.Cut(e.Raw, "\n")
+	if changed {
+		first = r.fields(first)
+	}
+	lines := make([]string, 0, len(e.Continuation)+1)
+	lines = append(lines, r.Text(first))
+	for _, l := range e.Continuation {
+		lines = append(lines, r.Text(l))
+	}
+	raw := strings.Join(lines, "\n")
+	if !changed && raw == e.Raw {
+		return e
+	}
+
+	c := *e
+	if e.Parsed != nil {
+		c.Parsed = fields.(*parser.Object)
+	}
+	c.Raw = raw
+	if len(e.Continuation) > 0 {
+		c.Continuation = lines[1:]
+	}
+	c.Reformat()
+	return &c
+}
+
+// value masks a value found under key, copying the objects and arrays
+// that change
+func (r *Redactor) value(key string, v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if key != "" && r.sensitive(key) {
+		return Mask, true
+	}
+
+	switch val := v.(type) {
+	case *parser.Object:
+		var out *parser.Object
+		keys := val.Keys()
+		for i, k := range keys {
+			item, _ := val.Get(k)
+			masked, changed := r.value(k, item)
+			if changed && out == nil {
+				out = parser.NewObject()
+				for _, prev := range keys[:i] {
+					v, _ := val.Get(prev)
+					out.Set(prev, v)
+				}
+			}
+			if out != nil {
+				out.Set(k, masked)
+			}
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	case []any:
+		var out []any
+		for i, item := range val {
+			masked, changed := r.value("", item)
+			if changed && out == nil {
+				out = slices.Clone(val)
+			}
+			if out != nil {
+				out[i] = masked
+			}
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	case string:
+		masked := r.Text(val)
+		return masked, masked != val
+	}
+	return v, false
+}
+
+// keyRe finds a field name and what separates it from its value, as in
+// key=value, "key": value or key: value, with the quotes escaped when the
+// JSON is itself quoted in a string
+var keyRe = regexp.MustCompile(`(\\?"?)([A-Za-z_][\w.-]*)\\?"?[ \t]*[=:][ \t]*`)
+
+// fields masks the values of sensitive fields in a line of structured text,
+// such as logfmt or a JSON object after a prefix, leaving the rest of the
+// line as it was. Quoted values are skipped, so text inside them is never
+// taken for a field; objects are looked into.
+func (r *Redactor) fields(line string) string {
+	var b strings.Builder
+	pos := 0
+	for {
+		m := keyRe.FindStringSubmatchIndex(line[pos:])
+		if m == nil {
+			break
+		}
+		end := pos + m[1]
+		key := line[pos+m[4] : pos+m[5]]
+		n := valueLen(line[end:], m[3] > m[2])
+
+		value := line[end : end+n]
+		switch {
+		case n > 0 && r.sensitive(key):
+			b.WriteString(line[pos:end])
+			switch {
+			case strings.HasPrefix(value, `\"`):
+				b.WriteString(`\"` + Mask + `\"`) // Inside a quoted JSON string
+			case strings.ContainsRune(`"{[`, rune(value[0])):
+				b.WriteString(`"` + Mask + `"`) // Still a JSON string
+			default:
+				b.WriteString(Mask)
+			}
+			pos = end + n
+		case strings.HasPrefix(value, "{"), strings.HasPrefix(value, "["),
+			strings.HasPrefix(value, `"{`), strings.HasPrefix(value, `"[`):
+			// JSON, or JSON quoted in a string, whose fields are looked into
+			b.WriteString(line[pos : end+1])
+			pos = end + 1
+		default:
+			b.WriteString(line[pos : end+n])
+			pos = end + n
+		}
+	}
+	b.WriteString(line[pos:])
+	return b.String()
+}
+
+// valueLen returns the length of the value at the start of s: a quoted
+// string, an object or array, or a bare word. Bare words after a quoted
+// key, as in JSON, also end at ",", "}" and "]".
+func valueLen(s string, quotedKey bool) int {
+	if s == "" {
+		return 0
+	}
+	if strings.HasPrefix(s, `\"`) {
+		// A string in JSON that is itself quoted in a string
+		if i := strings.Index(s[2:], `\"`); i >= 0 {
+			return i + 4
+		}
+		return len(s)
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(s)
+	case '{', '[':
+		depth, inString := 0, false
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case inString && c == '\\':
+				i++
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(s)
+	}
+	stop := " \t"
+	if quotedKey {
+		stop += ",}]"
+	}
+	if i := strings.IndexAny(s, stop); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of card
+// numbers
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,193 @@
+// Package rules evaluates the highlight rules of the configuration file
+// against entries as they are read, and fires their actions: ringing the
+// terminal bell, counting alerts and running shell commands.
+package rules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/config"
+	"github.com/thalessoares/lg/internal/output"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/redact"
+)
+
+const (
+	bellInterval = time.Second      // The bell rings at most this often
+	execWorkers  = 4                // Commands run at the same time
+	execQueue    = 256              // Commands waiting to run; more are dropped
+	execTimeout  = 30 * time.Second // Commands still running are killed
+)
+
+type rule struct {
+	config.Rule
+	query *query.Query
+}
+
+type execJob struct {
+	rule  string
+	cmd   string
+	entry []byte
+}
+
+// Set is the compiled rules of a configuration. A nil Set has no rules.
+type Set struct {
+	rules  []rule
+	styles map[string]lipgloss.Style // Highlights by rule name
+	bell   io.Writer
+	alerts atomic.Int64
+
+	execs  chan execJob
+	wg     sync.WaitGroup   // Running exec workers
+	redact *redact.Redactor // Masks the entries given to commands
+
+	mu       sync.Mutex
+	lastBell time.Time
+	closed   bool
+}
+
+// New compiles rules. The bell is rung by writing to bell, usually the
+// terminal.
+func New(defs []config.Rule, bell io.Writer) (*Set, error) {
+	s := &Set{
+		styles: make(map[string]lipgloss.Style),
+		bell:   bell,
+		execs:  make(chan execJob, execQueue),
+	}
+	for _, def := range defs {
+		q, err := query.Compile(def.When)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		s.rules = append(s.rules, rule{Rule: def, query: q})
+		if _, ok := s.styles[def.Name]; !ok && !def.Style.IsZero() {
+			s.styles[def.Name] = def.Style.Lipgloss()
+		}
+	}
+
+	for range execWorkers {
+		s.wg.Add(1)
+		go s.runExecs()
+	}
+	return s, nil
+}
+
+// Apply matches an entry against every rule, in order. The first matching
+// rule with a style highlights it, and every matching rule fires its
+// actions. Commands run in the background. It is safe to call from several
+// goroutines.
+func (s *Set) Apply(e *parser.LogEntry) {
+	if s == nil {
+		return
+	}
+	for i := range s.rules {
+		r := &s.rules[i]
+		if !r.query.Match(e) {
+			continue
+		}
+		if _, ok := s.styles[r.Name]; ok && e.Highlight == "" {
+			e.Highlight = r.Name
+		}
+		if r.Alert {
+			s.alerts.Add(1)
+		}
+		if r.Bell {
+			s.ring()
+		}
+		if r.Exec != "" {
+			s.exec(r, e)
+		}
+	}
+}
+
+// Redact masks sensitive values in the entries given to commands. It must
+// be called before Apply.
+func (s *Set) Redact(r *redact.Redactor) {
+	if s != nil {
+		s.redact = r
+	}
+}
+
+// Style returns the highlight of a rule
+func (s *Set) Style(name string) (lipgloss.Style, bool) {
+	if s == nil {
+		return lipgloss.Style{}, false
+	}
+	style, ok := s.styles[name]
+	return style, ok
+}
+
+// Alerts returns the number of entries counted by alert rules
+func (s *Set) Alerts() int {
+	if s == nil {
+		return 0
+	}
+	return int(s.alerts.Load())
+}
+
+// ring rings the bell, unless it rang less than bellInterval ago
+func (s *Set) ring() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bell == nil || time.Since(s.lastBell) < bellInterval {
+		return
+	}
+	s.lastBell = time.Now()
+	s.bell.Write([]byte("\a"))
+}
+
+// exec queues a rule's command for an entry, dropping it when too many are
+// waiting
+func (s *Set) exec(r *rule, e *parser.LogEntry) {
+	entry, err := output.JSON(s.redact.Entry(e))
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.execs <- execJob{rule: r.Name, cmd: r.Exec, entry: entry}:
+	default:
+	}
+}
+
+// runExecs runs queued commands with the entry's JSON on stdin and the
+// rule's name in $LG_RULE. Their output and failures are ignored.
+func (s *Set) runExecs() {
+	defer s.wg.Done()
+	for job := range s.execs {
+		ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+		cmd := exec.CommandContext(ctx, "sh", "-c", job.cmd)
+		cmd.Stdin = bytes.NewReader(append(job.entry, '\n'))
+		cmd.Env = append(os.Environ(), "LG_RULE="+job.rule)
+		cmd.Run()
+		cancel()
+	}
+}
+
+// Close waits for the queued commands to run
+func (s *Set) Close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.execs)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
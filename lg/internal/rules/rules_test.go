@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/thalessoares/lg/internal/config"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/redact"
+)
+
+func TestApply(t *testing.T) {
+	red := config.Style{Fg: "196", Bold: true}
+	s, err := New([]config.Rule{
+		{Name: "alert only", When: "status>=500", Alert: true},
+		{Name: "server errors", When: "status>=500", Style: red},
+		{Name: "slow", When: "duration_ms>1000", Style: config.Style{Bg: "52"}, Alert: true},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"status":200,"duration_ms":10}`, ""},
+		{`{"status":503,"duration_ms":10}`, "server errors"},
+		{`{"status":503,"duration_ms":5000}`, "server errors"},
+		{`{"status":200,"duration_ms":5000}`, "slow"},
+		{`plain text`, ""},
+	}
+	for _, tt := range tests {
+		e := parser.Parse(tt.line)
+		s.Apply(e)
+		if e.Highlight != tt.want {
+			t.Errorf("Apply(%s) highlight = %q, want %q", tt.line, e.Highlight, tt.want)
+		}
+	}
+
+	// Two entries with status 503, two slow ones
+	if got := s.Alerts(); got != 4 {
+		t.Errorf("Alerts() = %d, want 4", got)
+	}
+	if _, ok := s.Style("server errors"); !ok {
+		t.Error(`Style("server errors") not found`)
+	}
+	if _, ok := s.Style("alert only"); ok {
+		t.Error(`Style("alert only") found for a rule without a style`)
+	}
+}
+
+func TestApply_Actions(t *testing.T) {
+	dir := t.TempDir()
+	var bell bytes.Buffer
+	s, err := New([]config.Rule{
+		{Name: "deadlock", When: `msg ~ "deadlock"`, Bell: true, Exec: `{ printf '%s ' "$LG_RULE"; cat; } > $(mktemp -p ` + dir + `)`},
+	}, &bell)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	s.Redact(redact.Default())
+
+	s.Apply(parser.Parse(`{"msg":"deadlock detected"}`))
+	s.Apply(parser.Parse(`{"msg":"all good"}`))
+	s.Apply(parser.Parse(`{"msg":"deadlock again","token":"s3cr3t"}`))
+	s.Close()
+
+	// The bell rings once for entries close together
+	if got := bell.String(); got != "\a" {
+		t.Errorf("bell = %q, want one ring", got)
+	}
+
+	// One file per command
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	var got []string
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		got = append(got, string(data))
+	}
+	sort.Strings(got)
+	want := []string{
+		`deadlock {"msg":"deadlock again","token":"[REDACTED]"}` + "\n",
+		`deadlock {"msg":"deadlock detected"}` + "\n",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("exec stdin = %q, want %q", got, want)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New([]config.Rule{{Name: "bad", When: "status>="}}, nil); err == nil {
+		t.Error("New() with an invalid query should fail")
+	}
+}
+
+func TestNil(t *testing.T) {
+	var s *Set
+	e := parser.Parse(`{"msg":"a"}`)
+	s.Apply(e)
+	s.Close()
+	if e.Highlight != "" || s.Alerts() != 0 {
+		t.Error("a nil Set should do nothing")
+	}
+}
@@ -0,0 +1,177 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stream names for the output of a command
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// waitDelay is how long a stopped command's output may stay open, e.g. held
+// by its children, before it is closed
+const waitDelay = time.Second
+
+// Command runs a program and reads its stdout and stderr as separate
+// streams. It can be restarted, for example after `kubectl logs -f` drops
+// its connection.
+type Command struct {
+	args    []string
+	restart chan struct{} // Signals Loop to start the command again
+	done    chan struct{} // Closed by Close
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Stops the running command, nil when none
+	closed bool
+}
+
+// NewCommand creates a Command for a program and its arguments
+func NewCommand(args []string) *Command {
+	return &Command{
+		args:    args,
+		restart: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// String returns the command line
+func (c *Command) String() string {
+	return strings.Join(c.args, " ")
+}
+
+// Run starts the command and sends its output lines to stdout and stderr
+// until it exits. It returns nil when the command exits successfully, or
+// the error describing why it failed or could not start.
+func (c *Command) Run(stdout, stderr chan<- string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The output goes through pipes closed once the command is waited for,
+	// so children still holding it after a restart cannot block the reads
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdout = outW
+	cmd.Stderr = errW
+	cmd.WaitDelay = waitDelay
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errors.New("stopped")
+	}
+	if err := cmd.Start(); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, r := range []struct {
+		name  string
+		pipe  io.Reader
+		lines chan<- string
+	}{{Stdout, outR, stdout}, {Stderr, errR, stderr}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ReadLines(r.pipe, r.lines); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", r.name, err)
+			}
+			// Keep draining after an error so the command does not block
+			io.Copy(io.Discard, r.pipe)
+		}()
+	}
+	err := cmd.Wait()
+	outW.Close()
+	errW.Close()
+	wg.Wait()
+
+	c.mu.Lock()
+	c.cancel = nil
+	c.mu.Unlock()
+
+	if err == nil {
+		err = errors.Join(errs...)
+	}
+	return err
+}
+
+// Loop runs the command, and runs it again after every Restart, until
+// Close. status is called when the command starts and when it exits, with
+// the error from Run. Loop closes stdout and stderr when it returns.
+func (c *Command) Loop(stdout, stderr chan<- string, status func(running bool, err error)) {
+	defer close(stdout)
+	defer close(stderr)
+
+	for {
+		status(true, nil)
+		err := c.Run(stdout, stderr)
+		status(false, err)
+
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		select {
+		case <-c.restart:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Restart stops the running command, if any, and has Loop start it again
+func (c *Command) Restart() {
+	select {
+	case c.restart <- struct{}{}:
+	default:
+	}
+	c.stop()
+}
+
+// Close stops the running command, if any, and ends Loop
+func (c *Command) Close() {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	c.mu.Unlock()
+	c.stop()
+}
+
+func (c *Command) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// ExitStatus describes how a command run ended, for display
+func ExitStatus(err error) string {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return "exited 0"
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		return fmt.Sprintf("exited %d", exitErr.ExitCode())
+	case errors.As(err, &exitErr):
+		// Killed by a signal, e.g. by a restart
+		return exitErr.String()
+	}
+	return "failed: " + err.Error()
+}
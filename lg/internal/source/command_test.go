@@ -0,0 +1,89 @@
+package source
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+func collect(lines <-chan string) []string {
+	var got []string
+	for l := range lines {
+		got = append(got, l)
+	}
+	return got
+}
+
+func TestCommand_Run(t *testing.T) {
+	stdout := make(chan string, 10)
+	stderr := make(chan string, 10)
+
+	err := NewCommand([]string{"sh", "-c", "echo out1; echo err1 >&2; echo out2; exit 3"}).Run(stdout, stderr)
+	close(stdout)
+	close(stderr)
+
+	if got := ExitStatus(err); got != "exited 3" {
+		t.Errorf("ExitStatus() = %q, want %q", got, "exited 3")
+	}
+	if got := collect(stdout); !slices.Equal(got, []string{"out1", "out2"}) {
+		t.Errorf("stdout = %q", got)
+	}
+	if got := collect(stderr); !slices.Equal(got, []string{"err1"}) {
+		t.Errorf("stderr = %q", got)
+	}
+}
+
+func TestCommand_Loop(t *testing.T) {
+	cmd := NewCommand([]string{"sh", "-c", "echo started; exec sleep 10"})
+	stdout := make(chan string, 10)
+	stderr := make(chan string, 10)
+	events := make(chan error, 10)
+	go cmd.Loop(stdout, stderr, func(running bool, err error) {
+		if !running {
+			events <- err
+		}
+	})
+
+	expect := func() {
+		t.Helper()
+		select {
+		case l := <-stdout:
+			if l != "started" {
+				t.Fatalf("stdout = %q, want started", l)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("command did not start")
+		}
+	}
+
+	expect()
+	cmd.Restart()
+	var exitErr *exec.ExitError
+	if err := <-events; !errors.As(err, &exitErr) || exitErr.Exited() {
+		t.Fatalf("restarted run error = %v, want a signal exit", err)
+	}
+	expect()
+
+	cmd.Close()
+	<-events
+	if _, ok := <-stdout; ok {
+		t.Error("stdout still open after Close")
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "exited 0"},
+		{errors.New("exec: \"nope\": executable file not found in $PATH"), "failed: exec: \"nope\": executable file not found in $PATH"},
+	}
+	for _, tt := range tests {
+		if got := ExitStatus(tt.err); got != tt.want {
+			t.Errorf("ExitStatus(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// pollInterval is how often a followed file is checked for new data
+const pollInterval = 250 * time.Millisecond
+
+// Magic numbers at the start of compressed input
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// File reads a log file. Compressed files are decompressed. Followed files
+// wait for appended data at their end, are read again from the start when
+// truncated, and are reopened when a new file replaces them, as logrotate
+// does.
+type File struct {
+	name   string
+	follow bool
+	file   *os.File
+	next   *os.File  // File that replaced file, read once file is drained
+	offset int64     // Position in file, to detect truncation
+	r      io.Reader // Decompresses file, nil for plain text
+
+	size int64        // Size when opened
+	read atomic.Int64 // Bytes read from the files, before decompression
+}
+
+// OpenFile opens a file for reading. Compressed files are not followed,
+// since they are done being written.
+func OpenFile(path string, follow bool) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f := &File{name: path, file: file, size: info.Size()}
+
+	magic := make([]byte, len(zstdMagic))
+	n, _ := file.ReadAt(magic, 0)
+	switch {
+	case isCompressed(magic[:n]):
+		if f.r, err = decompress(magic[:n], rawReader{f}); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		f.follow = follow
+	}
+	return f, nil
+}
+
+// Decompress detects gzip and zstd input, such as stdin, by its first bytes
+// and returns a reader of its content, or of r as is when it is not
+// compressed
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	if !isCompressed(magic) {
+		return br, nil
+	}
+	return decompress(magic, br)
+}
+
+func isCompressed(magic []byte) bool {
+	return bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, zstdMagic)
+}
+
+func decompress(magic []byte, r io.Reader) (io.Reader, error) {
+	if bytes.HasPrefix(magic, gzipMagic) {
+		return gzip.NewReader(r)
+	}
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// Read reads the decompressed content, or the text of a plain file
+func (f *File) Read(p []byte) (int, error) {
+	if f.r != nil {
+		return f.r.Read(p)
+	}
+	for {
+		n, err := f.readRaw(p)
+		if n > 0 || err != io.EOF || !f.follow {
+			return n, err
+		}
+		if f.next != nil {
+			f.file.Close()
+			f.file, f.next, f.offset = f.next, nil, 0
+			continue
+		}
+		if !f.rotated() {
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
+// readRaw reads from the current file, before decompression
+func (f *File) readRaw(p []byte) (int, error) {
+	n, err := f.file.Read(p)
+	f.offset += int64(n)
+	f.read.Add(int64(n))
+	return n, err
+}
+
+// rotated checks, at the end of a followed file, whether it was truncated
+// or replaced. It returns true when there may be more to read.
+func (f *File) rotated() bool {
+	info, err := os.Stat(f.name)
+	if err != nil {
+		// Moved away and not created again yet
+		return false
+	}
+	cur, err := f.file.Stat()
+	if err != nil {
+		return false
+	}
+
+	if !os.SameFile(info, cur) {
+		// Read what was written to the old file before switching
+		next, err := os.Open(f.name)
+		if err != nil {
+			return false
+		}
+		f.next = next
+		return true
+	}
+	if cur.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		f.offset = 0
+		return true
+	}
+	return false
+}
+
+// Name returns the name of the file
+func (f *File) Name() string {
+	return f.name
+}
+
+// Progress returns how many bytes of the file were read, before
+// decompression, and its size when opened
+func (f *File) Progress() (read, size int64) {
+	return f.read.Load(), f.size
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	if f.next != nil {
+		f.next.Close()
+	}
+	return f.file.Close()
+}
+
+// rawReader reads a File before decompression
+type rawReader struct {
+	f *File
+}
+
+func (r rawReader) Read(p []byte) (int, error) {
+	return r.f.readRaw(p)
+}
@@ -0,0 +1,376 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxDatagramSize = 64 * 1024   // Largest UDP datagram read
+	peerIdle        = time.Minute // UDP peers silent this long are done
+)
+
+// Conn is a stream of lines from one connection or peer of a Listener
+type Conn struct {
+	Name  string        // Peer address, e.g. "10.0.0.5:41234" over TCP, "10.0.0.5" otherwise
+	Lines <-chan string // Closed once the peer is done
+}
+
+// Listener receives logs over the network: newline-delimited lines over
+// TCP, one or more lines per datagram over UDP, such as syslog, and POSTed
+// batches over HTTP, either newline-delimited or a JSON array. Each TCP
+// connection, and each UDP or HTTP peer IP, is a separate Conn. A UDP peer
+// that goes quiet for a while is done; if it sends again, it is a new Conn.
+type Listener struct {
+	scheme string
+	path   string         // HTTP path accepting POSTs
+	ln     net.Listener   // tcp and http
+	pc     net.PacketConn // udp
+	srv    *http.Server   // http
+	done   chan struct{}  // Closed by Close
+	conns  chan<- Conn    // Set by Serve
+	idle   time.Duration  // How long a UDP peer may be silent
+
+	// UDP lines dropped because their peer's stream was full
+	dropped atomic.Uint64
+
+	mu       sync.Mutex
+	closed   bool
+	open     map[net.Conn]bool      // Open TCP connections
+	peers    map[string]chan string // UDP and HTTP streams by peer
+	handlers sync.WaitGroup         // Running HTTP handlers
+}
+
+// Listen starts listening on an address such as tcp://:5170, udp://:5514
+// or http://:8088/ingest
+func Listen(addr string) (*Listener, error) {
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid listen address %q (want tcp://host:port, udp://host:port or http://host:port/path)", addr)
+	}
+	l := &Listener{
+		scheme: u.Scheme,
+		path:   u.Path,
+		done:   make(chan struct{}),
+		open:   make(map[net.Conn]bool),
+		peers:  make(map[string]chan string),
+		idle:   peerIdle,
+	}
+	if l.path == "" {
+		l.path = "/"
+	}
+	l.srv = &http.Server{Handler: http.HandlerFunc(l.serveHTTP)}
+
+	switch u.Scheme {
+	case "tcp", "http":
+		l.ln, err = net.Listen("tcp", u.Host)
+	case "udp":
+		l.pc, err = net.ListenPacket("udp", u.Host)
+	default:
+		return nil, fmt.Errorf("unsupported listen scheme %q (available: tcp, udp, http)", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Addr returns the address listened on, with the port picked by the system
+// when none was given
+func (l *Listener) Addr() string {
+	switch l.scheme {
+	case "udp":
+		return "udp://" + l.pc.LocalAddr().String()
+	case "http":
+		return "http://" + l.ln.Addr().String() + l.path
+	}
+	return "tcp://" + l.ln.Addr().String()
+}
+
+// Serve announces every new connection or peer on conns until Close, then
+// closes conns
+func (l *Listener) Serve(conns chan<- Conn) error {
+	defer close(conns)
+	l.conns = conns
+
+	var err error
+	switch l.scheme {
+	case "udp":
+		err = l.serveUDP()
+	case "http":
+		err = l.srv.Serve(l.ln)
+	default:
+		err = l.serveTCP()
+	}
+	if l.isClosed() {
+		err = nil
+	}
+
+	// No more lines can be sent once the handlers are done
+	l.handlers.Wait()
+	l.mu.Lock()
+	for name, lines := range l.peers {
+		close(lines)
+		delete(l.peers, name)
+	}
+	l.mu.Unlock()
+	return err
+}
+
+func (l *Listener) serveTCP() error {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		l.open[conn] = true
+		l.mu.Unlock()
+
+		lines := make(chan string, 1024)
+		if !l.announce(Conn{Name: conn.RemoteAddr().String(), Lines: lines}) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer close(lines)
+			ReadLines(conn, lines)
+			l.mu.Lock()
+			delete(l.open, conn)
+			l.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// serveUDP reads datagrams until Close. Lines are never waited on: one
+// peer whose stream is full must not hold up the others, so its lines are
+// dropped and counted instead.
+func (l *Listener) serveUDP() error {
+	buf := make([]byte, maxDatagramSize)
+	seen := make(map[string]time.Time) // Last datagram of every peer
+	swept := time.Now()
+	for {
+		// Wake up now and then to end quiet peers even when nothing arrives
+		l.pc.SetReadDeadline(time.Now().Add(l.idle / 2))
+		n, addr, err := l.pc.ReadFrom(buf)
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			if err != nil {
+				return err
+			}
+			host := peerHost(addr.String())
+			seen[host] = time.Now()
+			lines := l.peer(host)
+			for _, line := range splitLines(buf[:n]) {
+				select {
+				case lines <- line:
+				default:
+					l.dropped.Add(1)
+				}
+			}
+		}
+
+		if now := time.Now(); now.Sub(swept) >= l.idle/2 {
+			l.expire(seen, now)
+			swept = now
+		}
+	}
+}
+
+// expire ends the UDP peers that have been quiet for too long
+func (l *Listener) expire(seen map[string]time.Time, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for host, last := range seen {
+		if now.Sub(last) < l.idle {
+			continue
+		}
+		if lines, ok := l.peers[host]; ok {
+			close(lines)
+			delete(l.peers, host)
+		}
+		delete(seen, host)
+	}
+}
+
+// Dropped returns how many UDP lines were dropped because they arrived
+// faster than they were read
+func (l *Listener) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// serveHTTP handles a POSTed batch
+func (l *Listener) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	l.handlers.Add(1)
+	l.mu.Unlock()
+	defer l.handlers.Done()
+
+	switch {
+	case r.URL.Path != l.path:
+		http.NotFound(w, r)
+	case r.Method != http.MethodPost:
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+	default:
+		if err := l.readBatch(r.Body, l.peer(peerHost(r.RemoteAddr))); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readBatch sends the lines of a POSTed body, newline-delimited or a JSON
+// array with one entry per element
+func (l *Listener) readBatch(body io.Reader, lines chan<- string) error {
+	br := bufio.NewReader(body)
+	if first, err := peekNonSpace(br); err == nil && first == '[' {
+		var batch []json.RawMessage
+		if err := json.NewDecoder(br).Decode(&batch); err != nil {
+			return fmt.Errorf("invalid JSON array: %w", err)
+		}
+		for _, raw := range batch {
+			var line bytes.Buffer
+			if err := json.Compact(&line, raw); err != nil {
+				return err
+			}
+			if !l.send(lines, line.String()) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if !l.send(lines, scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// peekNonSpace skips leading whitespace and returns the next byte without
+// consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			return b[0], nil
+		}
+		br.Discard(1)
+	}
+}
+
+// peer returns the stream of a UDP or HTTP peer, announcing new ones to
+// Serve's caller
+func (l *Listener) peer(name string) chan string {
+	l.mu.Lock()
+	lines, ok := l.peers[name]
+	if !ok {
+		lines = make(chan string, 1024)
+		l.peers[name] = lines
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		l.announce(Conn{Name: name, Lines: lines})
+	}
+	return lines
+}
+
+// peerHost returns the IP of a peer address, so every port a peer sends
+// from is the same peer
+func peerHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// announce hands a new Conn to Serve's caller unless the listener is
+// closed first
+func (l *Listener) announce(c Conn) bool {
+	select {
+	case l.conns <- c:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+// send sends a line unless the listener is closed first
+func (l *Listener) send(lines chan<- string, line string) bool {
+	select {
+	case lines <- line:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+// splitLines splits a datagram into lines, dropping empty ones
+func splitLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r\x00"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (l *Listener) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// Close stops listening and ends every connection
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+	for conn := range l.open {
+		conn.Close()
+	}
+	l.mu.Unlock()
+
+	switch l.scheme {
+	case "udp":
+		return l.pc.Close()
+	case "http":
+		return l.srv.Close()
+	}
+	return l.ln.Close()
+}
@@ -0,0 +1,216 @@
+package source
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListener(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		send func(t *testing.T, addr string)
+		want []string
+	}{
+		{
+			name: "tcp",
+			addr: "tcp://127.0.0.1:0",
+			send: func(t *testing.T, addr string) {
+				conn, err := net.Dial("tcp", strings.TrimPrefix(addr, "tcp://"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				conn.Write([]byte("{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n"))
+				conn.Close()
+			},
+			want: []string{`{"msg":"a"}`, `{"msg":"b"}`},
+		},
+		{
+			name: "udp",
+			addr: "udp://127.0.0.1:0",
+			send: func(t *testing.T, addr string) {
+				conn, err := net.Dial("udp", strings.TrimPrefix(addr, "udp://"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				conn.Write([]byte("<34>Oct 11 22:14:15 host app: first"))
+				conn.Write([]byte("<34>Oct 11 22:14:16 host app: second\n"))
+				conn.Close()
+			},
+			want: []string{"<34>Oct 11 22:14:15 host app: first", "<34>Oct 11 22:14:16 host app: second"},
+		},
+		{
+			name: "http lines",
+			addr: "http://127.0.0.1:0/ingest",
+			send: func(t *testing.T, addr string) {
+				resp, err := http.Post(addr, "application/x-ndjson", strings.NewReader("{\"msg\":\"a\"}\r\n{\"msg\":\"b\"}"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusNoContent {
+					t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+				}
+			},
+			want: []string{`{"msg":"a"}`, `{"msg":"b"}`},
+		},
+		{
+			name: "http array",
+			addr: "http://127.0.0.1:0/ingest",
+			send: func(t *testing.T, addr string) {
+				resp, err := http.Get(addr)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusMethodNotAllowed {
+					t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+				}
+
+				resp, err = http.Post(addr, "application/json", strings.NewReader(` [{"msg": "a"}, {"msg": "b"}]`))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+			},
+			want: []string{`{"msg":"a"}`, `{"msg":"b"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := Listen(tt.addr)
+			if err != nil {
+				t.Fatalf("Listen() error = %v", err)
+			}
+			conns := make(chan Conn)
+			served := make(chan error, 1)
+			go func() { served <- l.Serve(conns) }()
+
+			// Requests are answered once their lines are taken, so send
+			// while reading
+			sent := make(chan struct{})
+			go func() {
+				defer close(sent)
+				tt.send(t, l.Addr())
+			}()
+
+			var c Conn
+			select {
+			case c = <-conns:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no connection")
+			}
+			if !strings.HasPrefix(c.Name, "127.0.0.1") {
+				t.Errorf("Name = %q, want the peer address", c.Name)
+			}
+			var got []string
+			for len(got) < len(tt.want) {
+				select {
+				case line := <-c.Lines:
+					got = append(got, line)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("lines = %q, want %q", got, tt.want)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+
+			<-sent
+			l.Close()
+			if err := <-served; err != nil {
+				t.Errorf("Serve() error = %v", err)
+			}
+			for range conns {
+			}
+		})
+	}
+}
+
+func TestListen_Invalid(t *testing.T) {
+	for _, addr := range []string{":5170", "ftp://:21", "tcp://"} {
+		if _, err := Listen(addr); err == nil {
+			t.Errorf("Listen(%q) should fail", addr)
+		}
+	}
+}
+
+func TestListener_UDPPeers(t *testing.T) {
+	l, err := Listen("udp://127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	l.idle = 200 * time.Millisecond
+	conns := make(chan Conn)
+	go l.Serve(conns)
+	defer func() {
+		l.Close()
+		for range conns {
+		}
+	}()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("udp", strings.TrimPrefix(l.Addr(), "udp://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	next := func() Conn {
+		select {
+		case c := <-conns:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no connection")
+			return Conn{}
+		}
+	}
+
+	// Every port of a peer is the same peer, keyed by its IP
+	dial().Write([]byte("first"))
+	c := next()
+	if c.Name != "127.0.0.1" {
+		t.Errorf("Name = %q, want the peer IP", c.Name)
+	}
+	dial().Write([]byte("second"))
+	for _, want := range []string{"first", "second"} {
+		if line := <-c.Lines; line != want {
+			t.Errorf("line = %q, want %q", line, want)
+		}
+	}
+
+	// A quiet peer is done, and a new Conn once it sends again
+	select {
+	case _, ok := <-c.Lines:
+		if ok {
+			t.Error("got a line, want the quiet peer closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("quiet peer was not closed")
+	}
+
+	// Lines beyond a full stream are dropped without holding up the reads
+	dial().Write([]byte(strings.Repeat("x\n", 1500)))
+	c = next()
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Dropped() < 1500-1024 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := l.Dropped(); n != 1500-1024 {
+		t.Errorf("Dropped() = %d, want %d", n, 1500-1024)
+	}
+	if n := len(c.Lines); n != 1024 {
+		t.Errorf("lines = %d, want 1024", n)
+	}
+}
@@ -0,0 +1,88 @@
+// Package source reads log lines from stdin and files.
+package source
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxLineSize is the longest line read; longer lines end the input with an
+// error
+const maxLineSize = 1024 * 1024 // 1MB
+
+// Open opens the named files for reading, failing before anything is read
+// when one of them cannot be opened. gzip and zstd files are decompressed.
+// With follow, reads of the other files wait for appended data instead of
+// stopping at their end, like tail -f, and survive log rotation.
+func Open(paths []string, follow bool) ([]io.Reader, error) {
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		f, err := OpenFile(path, follow)
+		if err != nil {
+			for _, r := range readers {
+				r.(*File).Close()
+			}
+			return nil, err
+		}
+		readers = append(readers, f)
+	}
+	return readers, nil
+}
+
+// Progress sums how many bytes of the files among readers were read, out
+// of their size when opened
+func Progress(readers []io.Reader) (read, total int64) {
+	for _, r := range readers {
+		if f, ok := r.(*File); ok {
+			n, size := f.Progress()
+			read += min(n, size)
+			total += size
+		}
+	}
+	return read, total
+}
+
+// ReadAll reads lines from every reader concurrently into lines and closes
+// it once all are done. It returns the read errors joined together.
+func ReadAll(readers []io.Reader, lines chan<- string) error {
+	defer close(lines)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ReadLines(r, lines); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// ReadLines sends every line read from r to lines
+func ReadLines(r io.Reader, lines chan<- string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		lines <- scanner.Text()
+	}
+	if err := scanner.Err(); err != nil {
+		if f, ok := r.(interface{ Name() string }); ok {
+			return fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		return err
+	}
+	return nil
+}
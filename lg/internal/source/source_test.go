@@ -0,0 +1,179 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestReadAll(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	os.WriteFile(a, []byte("a1\na2\n"), 0o600)
+	os.WriteFile(b, []byte("b1\r\nb2"), 0o600)
+
+	readers, err := Open([]string{a, b}, false)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	lines := make(chan string, 10)
+	if err := ReadAll(readers, lines); err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	var got []string
+	for l := range lines {
+		got = append(got, strings.TrimRight(l, "\r"))
+	}
+	sort.Strings(got)
+	if want := []string{"a1", "a2", "b1", "b2"}; !slices.Equal(got, want) {
+		t.Errorf("ReadAll() = %v, want %v", got, want)
+	}
+
+	if _, err := Open([]string{filepath.Join(dir, "missing.log")}, false); err == nil {
+		t.Error("Open() of a missing file should fail")
+	}
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte("first\n"), 0o600)
+
+	readers, err := Open([]string{path}, true)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	lines := make(chan string, 10)
+	go ReadAll(readers, lines)
+
+	if got := <-lines; got != "first" {
+		t.Fatalf("first line = %q, want %q", got, "first")
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("second\n")
+	f.Close()
+
+	select {
+	case got := <-lines:
+		if got != "second" {
+			t.Errorf("appended line = %q, want %q", got, "second")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("appended line was not read")
+	}
+}
+
+func TestOpen_Compressed(t *testing.T) {
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("g1\ng2\n"))
+	zw.Close()
+
+	zs, _ := zstd.NewWriter(nil)
+	zstdData := zs.EncodeAll([]byte("z1\nz2\n"), nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"plain.log", []byte("p1\np2\n"), []string{"p1", "p2"}},
+		{"app.log.gz", gz.Bytes(), []string{"g1", "g2"}},
+		{"app.log.zst", zstdData, []string{"z1", "z2"}},
+		{"no-extension", gz.Bytes(), []string{"g1", "g2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			os.WriteFile(path, tt.data, 0o600)
+
+			// Compressed files are read to the end even with follow
+			readers, err := Open([]string{path}, !strings.HasPrefix(tt.name, "plain"))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			lines := make(chan string, 10)
+			if err := ReadAll(readers, lines); err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			var got []string
+			for l := range lines {
+				got = append(got, l)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lines = %v, want %v", got, tt.want)
+			}
+			if read, total := Progress(readers); read != total || total != int64(len(tt.data)) {
+				t.Errorf("Progress() = %d, %d, want %d, %d", read, total, len(tt.data), len(tt.data))
+			}
+
+			r, err := Decompress(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+			data, _ := io.ReadAll(r)
+			if got := strings.Fields(string(data)); !slices.Equal(got, tt.want) {
+				t.Errorf("Decompress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollow_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte("first\n"), 0o600)
+
+	readers, err := Open([]string{path}, true)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	lines := make(chan string, 10)
+	go ReadAll(readers, lines)
+
+	next := func() string {
+		select {
+		case l := <-lines:
+			return l
+		case <-time.After(5 * time.Second):
+			return "(timeout)"
+		}
+	}
+	if got := next(); got != "first" {
+		t.Fatalf("first line = %q, want %q", got, "first")
+	}
+
+	// logrotate's create: the file is renamed and a new one takes its place
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	os.Rename(path, path+".1")
+	f.WriteString("before rotation\n")
+	f.Close()
+	os.WriteFile(path, []byte("after rotation\n"), 0o600)
+	for _, want := range []string{"before rotation", "after rotation"} {
+		if got := next(); got != want {
+			t.Errorf("line = %q, want %q", got, want)
+		}
+	}
+
+	// logrotate's copytruncate: the file is emptied in place
+	os.WriteFile(path, nil, 0o600)
+	time.Sleep(2 * pollInterval)
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("truncated\n")
+	f.Close()
+	if got := next(); got != "truncated" {
+		t.Errorf("line = %q, want %q", got, "truncated")
+	}
+}
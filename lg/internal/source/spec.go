@@ -0,0 +1,62 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// labelRe matches the name in front of a -s spec
+var labelRe = regexp.MustCompile(`^[\w.-]+$`)
+
+// shellChars are the characters that make a -s value a shell command line
+// rather than a single path or program
+const shellChars = " \t|&;<>()$`\\\"'*?[]"
+
+// Spec is an input given with -s: a file, or a command run with sh -c,
+// under a name
+type Spec struct {
+	Name    string
+	File    string   // Path of a file to read
+	Command []string // Command to run, when File is empty
+}
+
+// ParseSpec parses a -s value of the form name=file or name='command args'.
+// A value naming an existing file is read as one, anything else is run as a
+// shell command. A single word that is neither a file nor a program fails,
+// rather than being run for sh to report. Without a name, the file's base
+// name or the command's program name is used.
+func ParseSpec(s string) (Spec, error) {
+	var spec Spec
+	value := s
+	if name, rest, ok := strings.Cut(s, "="); ok && labelRe.MatchString(name) {
+		spec.Name, value = name, rest
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Spec{}, fmt.Errorf("empty source %q (use name=file or name='command')", s)
+	}
+
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		spec.File = value
+		if spec.Name == "" {
+			spec.Name = filepath.Base(value)
+		}
+		return spec, nil
+	}
+
+	if !strings.ContainsAny(value, shellChars) {
+		if _, err := exec.LookPath(value); err != nil {
+			return Spec{}, fmt.Errorf("%s: no such file or command", value)
+		}
+	}
+
+	spec.Command = []string{"sh", "-c", value}
+	if spec.Name == "" {
+		spec.Name = filepath.Base(strings.Fields(value)[0])
+	}
+	return spec, nil
+}
@@ -0,0 +1,44 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "worker.log")
+	os.WriteFile(file, nil, 0o600)
+
+	tests := []struct {
+		in      string
+		want    Spec
+		wantErr bool
+	}{
+		{in: "worker=" + file, want: Spec{Name: "worker", File: file}},
+		{in: file, want: Spec{Name: "worker.log", File: file}},
+		{in: "api=kubectl logs -f api", want: Spec{Name: "api", Command: []string{"sh", "-c", "kubectl logs -f api"}}},
+		{in: "kubectl logs -f api", want: Spec{Name: "kubectl", Command: []string{"sh", "-c", "kubectl logs -f api"}}},
+		{in: "grep level=error app.log", want: Spec{Name: "grep", Command: []string{"sh", "-c", "grep level=error app.log"}}},
+		{in: "sys=dmesg|tail", want: Spec{Name: "sys", Command: []string{"sh", "-c", "dmesg|tail"}}},
+		{in: "sh", want: Spec{Name: "sh", Command: []string{"sh", "-c", "sh"}}},
+		{in: "new=" + filepath.Join(dir, "new.log"), wantErr: true}, // Not created yet
+		{in: "missing.log", wantErr: true},
+		{in: "api=", wantErr: true},
+		{in: "  ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSpec(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package table renders log entries as one-line rows with user-selected
+// columns.
+package table
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/theme"
+)
+
+// DefaultColumns are used when no --columns flag is given
+var DefaultColumns = []string{"time", "level", "msg"}
+
+const (
+	maxColumnWidth = 40 // Widest a fixed column grows before truncation
+	minColumnWidth = 3
+	minExtraWidth  = 10 // Below this the extra column is hidden
+	separator      = " "
+)
+
+// aliases let short column names match the keys common loggers emit
+var aliases = map[string][]string{
+	"time":  {"time", "timestamp", "ts", "@timestamp"},
+	"level": {"level", "lvl", "severity", "log.level"},
+	"msg":   {"msg", "message"},
+}
+
+// Styles for table rows, set by SetTheme
+var (
+	headerStyle lipgloss.Style
+	cellStyle   lipgloss.Style
+	extraStyle  lipgloss.Style
+	plainStyle  lipgloss.Style
+)
+
+func init() {
+	SetTheme(theme.Dark)
+}
+
+// SetTheme colors table rows with a theme
+func SetTheme(t theme.Theme) {
+	headerStyle = theme.Fg(t.Dim).Bold(true)
+	cellStyle = theme.Fg(t.Text)
+	extraStyle = theme.Fg(t.Muted)
+	plainStyle = theme.Fg(t.Faint).Italic(true)
+}
+
+// ParseColumns splits a comma separated column list, e.g. "time,level,msg"
+func ParseColumns(spec string) []string {
+	var cols []string
+	for _, c := range strings.Split(spec, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// Value returns the text for a column and the key it was read from. Column
+// names are key paths, and the aliases "time", "level" and "msg" also match
+// the usual alternative key names.
+func Value(e *parser.LogEntry, col string) (value string, key string, ok bool) {
+	if e.Parsed == nil {
+		return "", "", false
+	}
+
+	keys := aliases[col]
+	if keys == nil {
+		keys = []string{col}
+	}
+	for _, k := range keys {
+		if v, found := parser.Lookup(e.Parsed, k); found {
+			return flatten(parser.ValueString(v)), k, true
+		}
+	}
+	return "", "", false
+}
+
+// Extra renders the top-level keys not shown in any column as k=v pairs, in
+// the order they were read
+func Extra(e *parser.LogEntry, cols []string) string {
+	if e.Parsed == nil {
+		return ""
+	}
+
+	used := make(map[string]bool)
+	for _, col := range cols {
+		if _, key, ok := Value(e, col); ok {
+			used[key] = true
+		}
+	}
+
+	pairs := make([]string, 0, e.Parsed.Len())
+	for _, k := range e.Parsed.Keys() {
+		if !used[k] {
+			v, _ := e.Parsed.Get(k)
+			pairs = append(pairs, k+"="+flatten(parser.ValueString(v)))
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// Layout is a set of columns sized to fit a terminal width
+type Layout struct {
+	Columns []string
+	Widths  []int
+	Extra   int // Width of the extra column, 0 when it is hidden
+
+	// FormatTime, when set, renders the "time" column from the entry's
+	// detected timestamp instead of the raw field value
+	FormatTime func(time.Time) string
+}
+
+// NewLayout sizes each column to its widest value among entries and fits
+// the result into the given terminal width. Whatever is left goes to the
+// extra column, which is hidden when too narrow.
+func NewLayout(cols []string, entries []*parser.LogEntry, width int, formatTime func(time.Time) string) Layout {
+	l := Layout{Columns: cols, Widths: make([]int, len(cols)), FormatTime: formatTime}
+	for i, col := range cols {
+		l.Widths[i] = lipgloss.Width(col)
+	}
+	for _, e := range entries {
+		for i, col := range cols {
+			if v, ok := l.value(e, col); ok {
+				l.Widths[i] = max(l.Widths[i], min(lipgloss.Width(v), maxColumnWidth))
+			}
+		}
+	}
+
+	// Shrink the widest column until everything fits
+	available := width - len(cols)*len(separator)
+	for sum(l.Widths) > available {
+		widest := 0
+		for i := range l.Widths {
+			if l.Widths[i] > l.Widths[widest] {
+				widest = i
+			}
+		}
+		if l.Widths[widest] <= minColumnWidth {
+			break
+		}
+		l.Widths[widest]--
+	}
+
+	l.Extra = available - sum(l.Widths)
+	if l.Extra < minExtraWidth {
+		l.Extra = 0
+	}
+	return l
+}
+
+// value returns the text of a column, using FormatTime for the time column
+func (l Layout) value(e *parser.LogEntry, col string) (string, bool) {
+	if col == "time" && l.FormatTime != nil && !e.Time.IsZero() {
+		return l.FormatTime(e.Time), true
+	}
+	v, _, ok := Value(e, col)
+	return v, ok
+}
+
+// Header renders the column names
+func (l Layout) Header() string {
+	cells := make([]string, 0, len(l.Columns)+1)
+	for i, col := range l.Columns {
+		cells = append(cells, pad(strings.ToUpper(col), l.Widths[i]))
+	}
+	if l.Extra > 0 {
+		cells = append(cells, pad("EXTRA", l.Extra))
+	}
+	return headerStyle.Render(strings.Join(cells, separator))
+}
+
+// Row renders an entry as a single line. Entries without parsed fields show
+// their raw text across the whole row.
+func (l Layout) Row(e *parser.LogEntry) string {
+	if e.Parsed == nil {
+		total := sum(l.Widths) + len(l.Columns)*len(separator) + l.Extra
+		return plainStyle.Render(truncate(flatten(e.Raw), total))
+	}
+
+	cells := make([]string, 0, len(l.Columns)+1)
+	for i, col := range l.Columns {
+		v, _ := l.value(e, col)
+		style := cellStyle
+		if col == "level" {
+			style = e.Level.Style()
+		}
+		cells = append(cells, style.Render(pad(truncate(v, l.Widths[i]), l.Widths[i])))
+	}
+	if l.Extra > 0 {
+		cells = append(cells, extraStyle.Render(truncate(Extra(e, l.Columns), l.Extra)))
+	}
+	return strings.Join(cells, separator)
+}
+
+// flatten keeps multi-line values on a single row
+func flatten(s string) string {
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.ReplaceAll(s, "\n", "⏎")
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+func pad(s string, width int) string {
+	if gap := width - lipgloss.Width(s); gap > 0 {
+		return s + strings.Repeat(" ", gap)
+	}
+	return s
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,103 @@
+package table
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestParseColumns(t *testing.T) {
+	got := ParseColumns(" time, level,,msg ,request_id")
+	want := []string{"time", "level", "msg", "request_id"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseColumns() = %v, want %v", got, want)
+	}
+
+	if got := ParseColumns(""); got != nil {
+		t.Errorf("ParseColumns(\"\") = %v, want nil", got)
+	}
+}
+
+func TestValue_Aliases(t *testing.T) {
+	entry := parser.Parse(`{"timestamp":"2025-01-01T10:00:00Z","lvl":"info","message":"hello","user":{"id":42}}`)
+
+	tests := []struct {
+		col     string
+		want    string
+		wantKey string
+	}{
+		{"time", "2025-01-01T10:00:00Z", "timestamp"},
+		{"level", "info", "lvl"},
+		{"msg", "hello", "message"},
+		{"user.id", "42", "user.id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.col, func(t *testing.T) {
+			got, key, ok := Value(entry, tt.col)
+			if !ok || got != tt.want || key != tt.wantKey {
+				t.Errorf("Value(%q) = %q, %q, %v; want %q, %q", tt.col, got, key, ok, tt.want, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestExtra(t *testing.T) {
+	entry := parser.Parse(`{"time":"t","level":"info","msg":"hi","status":200,"path":"/"}`)
+
+	got := Extra(entry, []string{"time", "level", "msg"})
+	if got != "status=200 path=/" {
+		t.Errorf("Extra() = %q, want %q", got, "status=200 path=/")
+	}
+}
+
+func TestNewLayout_FitTerminal(t *testing.T) {
+	entries := []*parser.LogEntry{
+		parser.Parse(`{"time":"2025-01-01T10:00:00Z","level":"info","msg":"` + strings.Repeat("x", 100) + `"}`),
+	}
+	cols := []string{"time", "level", "msg"}
+
+	layout := NewLayout(cols, entries, 200, nil)
+	if layout.Widths[0] != 20 || layout.Widths[1] != 5 || layout.Widths[2] != maxColumnWidth {
+		t.Errorf("Widths = %v, want [20 5 %d]", layout.Widths, maxColumnWidth)
+	}
+	if layout.Extra == 0 {
+		t.Error("NewLayout() should leave room for the extra column on a wide terminal")
+	}
+
+	layout = NewLayout(cols, entries, 40, nil)
+	if layout.Extra != 0 {
+		t.Errorf("Extra = %d, want 0 on a narrow terminal", layout.Extra)
+	}
+	row := layout.Row(entries[0])
+	if w := lipgloss.Width(row); w > 40 {
+		t.Errorf("Row() width = %d, want <= 40", w)
+	}
+}
+
+func TestRow_PlainText(t *testing.T) {
+	entry := parser.Parse("Starting application...")
+	row := Layout{Columns: []string{"time"}, Widths: []int{10}, Extra: 20}.Row(entry)
+	if !strings.Contains(row, "Starting application...") {
+		t.Errorf("Row() = %q, want raw text", row)
+	}
+}
+
+func TestLayout_FormatTime(t *testing.T) {
+	entry := parser.Parse(`{"ts":1735725603,"msg":"hi"}`)
+	layout := NewLayout([]string{"time", "msg"}, []*parser.LogEntry{entry}, 80, func(ts time.Time) string {
+		return ts.UTC().Format("15:04:05")
+	})
+
+	row := layout.Row(entry)
+	if !strings.Contains(row, "10:00:03") {
+		t.Errorf("Row() = %q, want the formatted timestamp", row)
+	}
+	if layout.Widths[0] != len("10:00:03") {
+		t.Errorf("time column width = %d, want %d", layout.Widths[0], len("10:00:03"))
+	}
+}
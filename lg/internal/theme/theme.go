@@ -0,0 +1,140 @@
+// Package theme defines the color palettes lg renders with. Colors are ANSI
+// numbers ("196") or hex ("#ff0000"); an empty color leaves the terminal's
+// own.
+package theme
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme assigns colors to the roles they play on screen
+type Theme struct {
+	Primary   string `yaml:"primary"`   // Keys, info level, cursor, status mode
+	Secondary string `yaml:"secondary"` // Numbers and markers
+	Success   string `yaml:"success"`   // Strings, search prompt, running commands
+	Warning   string `yaml:"warning"`   // Warn level, paused, stderr, repeats
+	Error     string `yaml:"error"`     // Error and fatal levels, alerts
+	Accent    string `yaml:"accent"`    // Booleans
+	Text      string `yaml:"text"`      // Status bar and table cells
+	Dim       string `yaml:"dim"`       // Time, braces, stack traces, debug level
+	Muted     string `yaml:"muted"`     // Help, null, extra fields
+	Faint     string `yaml:"faint"`     // Plain text lines, trace level
+	Border    string `yaml:"border"`    // Separators and borders
+	Bar       string `yaml:"bar"`       // Status and search bar background
+	Selection string `yaml:"selection"` // Selected entry background
+	Match     string `yaml:"match"`     // Search match background
+	Contrast  string `yaml:"contrast"`  // Text on the status mode, paused and match backgrounds
+	Inverse   string `yaml:"inverse"`   // Text on the fatal badge
+
+	// Sources are picked from for source tags, by name
+	Sources []string `yaml:"sources"`
+
+	// Mono marks a theme without colors, which uses bold and reverse video
+	// where the others use a background
+	Mono bool `yaml:"-"`
+}
+
+// Dark is the default theme, for dark terminals
+var Dark = Theme{
+	Primary:   "81",
+	Secondary: "214",
+	Success:   "82",
+	Warning:   "214",
+	Error:     "196",
+	Accent:    "205",
+	Text:      "252",
+	Dim:       "245",
+	Muted:     "241",
+	Faint:     "240",
+	Border:    "238",
+	Bar:       "236",
+	Selection: "237",
+	Match:     "226",
+	Contrast:  "0",
+	Inverse:   "231",
+	Sources:   []string{"81", "141", "114", "180", "75", "176", "150", "110"},
+}
+
+// Light is for terminals with a light background
+var Light = Theme{
+	Primary:   "25",
+	Secondary: "130",
+	Success:   "28",
+	Warning:   "166",
+	Error:     "160",
+	Accent:    "127",
+	Text:      "235",
+	Dim:       "243",
+	Muted:     "245",
+	Faint:     "246",
+	Border:    "250",
+	Bar:       "254",
+	Selection: "253",
+	Match:     "220",
+	Contrast:  "231",
+	Inverse:   "231",
+	Sources:   []string{"25", "91", "28", "130", "31", "127", "64", "61"},
+}
+
+// None has no colors, for NO_COLOR (https://no-color.org)
+var None = Theme{Mono: true}
+
+// Builtin lists the themes available without a configuration file
+var Builtin = map[string]Theme{
+	"dark":  Dark,
+	"light": Light,
+	"none":  None,
+}
+
+// Lookup returns the built-in theme with a name
+func Lookup(name string) (Theme, error) {
+	t, ok := Builtin[name]
+	if !ok {
+		names := make([]string, 0, len(Builtin))
+		for n := range Builtin {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %v)", name, names)
+	}
+	return t, nil
+}
+
+// Override returns the theme with the colors set in over replacing its own
+func (t Theme) Override(over Theme) Theme {
+	fields := []struct{ dst, src *string }{
+		{&t.Primary, &over.Primary}, {&t.Secondary, &over.Secondary},
+		{&t.Success, &over.Success}, {&t.Warning, &over.Warning},
+		{&t.Error, &over.Error}, {&t.Accent, &over.Accent},
+		{&t.Text, &over.Text}, {&t.Dim, &over.Dim},
+		{&t.Muted, &over.Muted}, {&t.Faint, &over.Faint},
+		{&t.Border, &over.Border}, {&t.Bar, &over.Bar},
+		{&t.Selection, &over.Selection}, {&t.Match, &over.Match},
+		{&t.Contrast, &over.Contrast}, {&t.Inverse, &over.Inverse},
+	}
+	for _, f := range fields {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if len(over.Sources) > 0 {
+		t.Sources = over.Sources
+	}
+	return t
+}
+
+// Color returns a color for lipgloss, with "" as no color
+func Color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+// Fg returns a style with a foreground color
+func Fg(c string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(Color(c))
+}
@@ -0,0 +1,33 @@
+package theme
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"dark", "light", "none"} {
+		if _, err := Lookup(name); err != nil {
+			t.Errorf("Lookup(%q) error = %v", name, err)
+		}
+	}
+	if _, err := Lookup("solarized"); err == nil {
+		t.Error("Lookup(solarized) should fail")
+	}
+	if th, _ := Lookup("none"); !th.Mono || th.Primary != "" {
+		t.Errorf("none = %+v, want no colors", th)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	got := Dark.Override(Theme{Primary: "#268bd2", Sources: []string{"1", "2"}})
+	if got.Primary != "#268bd2" {
+		t.Errorf("Primary = %q, want #268bd2", got.Primary)
+	}
+	if got.Error != Dark.Error || got.Bar != Dark.Bar {
+		t.Errorf("unset colors changed: %+v", got)
+	}
+	if len(got.Sources) != 2 {
+		t.Errorf("Sources = %v, want [1 2]", got.Sources)
+	}
+	if Dark.Primary != "81" {
+		t.Errorf("Override changed Dark: Primary = %q", Dark.Primary)
+	}
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// frameInterval is how often batched entries are delivered to the TUI
+const frameInterval = time.Second / 60
+
+// maxBatch caps the entries delivered per frame, about a frame's worth of
+// work, so a large file loads over many frames while the TUI stays
+// responsive
+const maxBatch = 4000
+
+// Forward sends entries to the program in batches, one per frame, so a busy
+// stream costs one update per frame instead of one per line. Once a batch
+// is full, reading stops until the next frame, which holds back the input.
+// It returns once entries is closed and what was left has been sent.
+func Forward(p *tea.Program, entries <-chan *parser.LogEntry) {
+	var batch []*parser.LogEntry
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+
+	for {
+		in := entries
+		if len(batch) >= maxBatch {
+			in = nil
+		}
+		select {
+		case e, ok := <-in:
+			if !ok {
+				if len(batch) > 0 {
+					p.Send(LogBatchMsg(batch))
+				}
+				return
+			}
+			batch = append(batch, e)
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.Send(LogBatchMsg(batch))
+				batch = nil
+			}
+		}
+	}
+}
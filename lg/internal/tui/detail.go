@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+const (
+	minDetailWidth    = 30
+	detailBorderWidth = 1
+)
+
+// treeLine is one rendered line of the detail pane
+type treeLine struct {
+	text     string // Styled line content (without the cursor gutter)
+	path     string // Path of the node the line belongs to
+	foldable bool   // Whether the node is an object or array
+}
+
+// buildTree renders an entry as a foldable JSON tree. Objects and arrays
+// whose path is in collapsed are shown on a single line, long strings are
+// wrapped to width, and keys keep their order unless sorted.
+func buildTree(entry *parser.LogEntry, collapsed map[string]bool, width int, sorted bool) []treeLine {
+	if entry == nil {
+		return nil
+	}
+
+	b := &treeBuilder{collapsed: collapsed, width: width, sorted: sorted}
+	if entry.Parsed == nil {
+		head, _, _ := strings.Cut(entry.Raw, "\n")
+		for _, l := range wrapText(head, width) {
+			b.add(plainDetailStyle.Render(l), "", false)
+		}
+	} else {
+		b.node("", entry.Parsed, "", 0, true)
+	}
+	b.body(entry.Continuation)
+	return b.lines
+}
+
+// bodyPath is the tree path of an entry's continuation lines. It cannot
+// clash with a key path since those never start with a dot.
+const bodyPath = ".body"
+
+// body renders the continuation lines (e.g. a stack trace) as a foldable
+// node below the fields
+func (b *treeBuilder) body(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	summary := treeSummaryStyle.Render(fmt.Sprintf("%d more lines", len(lines)))
+	if b.collapsed[bodyPath] {
+		b.add(foldMarker(true, true)+summary, bodyPath, true)
+		return
+	}
+
+	b.add(foldMarker(true, false)+summary, bodyPath, true)
+	for _, line := range lines {
+		// Frames rarely have spaces worth breaking on, so cut them exactly
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for _, l := range strings.Split(ansi.Hardwrap(line, max(b.width-2, 10), true), "\n") {
+			b.add("  "+treeTraceStyle.Render(l), bodyPath, false)
+		}
+	}
+}
+
+type treeBuilder struct {
+	lines     []treeLine
+	collapsed map[string]bool
+	width     int
+	sorted    bool
+}
+
+// node appends the lines for a value. label is the rendered key (or array
+// index) prefix and is empty for the root.
+func (b *treeBuilder) node(label string, value any, path string, depth int, last bool) {
+	indent := strings.Repeat("  ", depth)
+	comma := ""
+	if !last {
+		comma = treeBraceStyle.Render(",")
+	}
+
+	switch v := value.(type) {
+	case *parser.Object:
+		keys := v.Keys()
+		if b.sorted {
+			keys = v.SortedKeys()
+		}
+
+		if len(keys) == 0 || b.collapsed[path] {
+			summary := treeBraceStyle.Render("{}")
+			if len(keys) > 0 {
+				summary = treeBraceStyle.Render("{…}") + treeSummaryStyle.Render(fmt.Sprintf(" %d keys", len(keys)))
+			}
+			b.add(indent+foldMarker(len(keys) > 0, true)+label+summary+comma, path, len(keys) > 0)
+			return
+		}
+
+		b.add(indent+foldMarker(true, false)+label+treeBraceStyle.Render("{"), path, true)
+		for i, k := range keys {
+			childLabel := treeKeyStyle.Render(`"`+k+`"`) + ": "
+			child, _ := v.Get(k)
+			b.node(childLabel, child, joinPath(path, k), depth+1, i == len(keys)-1)
+		}
+		b.add(indent+"  "+treeBraceStyle.Render("}")+comma, path, false)
+
+	case []any:
+		if len(v) == 0 || b.collapsed[path] {
+			summary := treeBraceStyle.Render("[]")
+			if len(v) > 0 {
+				summary = treeBraceStyle.Render("[…]") + treeSummaryStyle.Render(fmt.Sprintf(" %d items", len(v)))
+			}
+			b.add(indent+foldMarker(len(v) > 0, true)+label+summary+comma, path, len(v) > 0)
+			return
+		}
+
+		b.add(indent+foldMarker(true, false)+label+treeBraceStyle.Render("["), path, true)
+		for i, item := range v {
+			b.node("", item, joinPath(path, fmt.Sprint(i)), depth+1, i == len(v)-1)
+		}
+		b.add(indent+"  "+treeBraceStyle.Render("]")+comma, path, false)
+
+	default:
+		b.scalar(indent+"  "+label, v, path, comma)
+	}
+}
+
+// scalar renders a leaf value, wrapping long strings under the key
+func (b *treeBuilder) scalar(prefix string, value any, path, comma string) {
+	style := treeStringStyle
+	text := parser.ValueString(value)
+	switch value.(type) {
+	case string:
+		text = `"` + text + `"`
+	case bool:
+		style = treeBoolStyle
+	case nil:
+		style = treeNullStyle
+	default:
+		style = treeNumberStyle
+	}
+
+	// Continuation lines line up with the value, unless the key is so long
+	// that there is no room left
+	prefixWidth := ansi.StringWidth(prefix)
+	contIndent := prefixWidth
+	if b.width-prefixWidth < 20 {
+		contIndent = min(prefixWidth, 6)
+	}
+
+	var lines []string
+	for i, paragraph := range strings.Split(text, "\n") {
+		limit := b.width - contIndent
+		if i == 0 {
+			limit = b.width - prefixWidth
+		}
+		lines = append(lines, wrapText(paragraph, max(limit, 10))...)
+	}
+
+	for i, l := range lines {
+		lead := strings.Repeat(" ", contIndent)
+		if i == 0 {
+			lead = prefix
+		}
+		line := lead + style.Render(l)
+		if i == len(lines)-1 {
+			line += comma
+		}
+		b.add(line, path, false)
+	}
+}
+
+func (b *treeBuilder) add(text, path string, foldable bool) {
+	b.lines = append(b.lines, treeLine{text: text, path: path, foldable: foldable})
+}
+
+// foldMarker is the expand/collapse indicator in front of objects and arrays
+func foldMarker(foldable, collapsed bool) string {
+	switch {
+	case !foldable:
+		return "  "
+	case collapsed:
+		return treeMarkerStyle.Render("▸ ")
+	default:
+		return treeMarkerStyle.Render("▾ ")
+	}
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// wrapText hard-wraps plain text to width, preferring to break on spaces
+func wrapText(s string, width int) []string {
+	if width <= 0 || ansi.StringWidth(s) <= width {
+		return []string{s}
+	}
+	return strings.Split(ansi.Wrap(s, width, ""), "\n")
+}
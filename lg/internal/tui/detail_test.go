@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// treeEntry has its keys in alphabetical order, the order they are shown in
+const treeEntry = `{"a":1,"list":[],"user":{"name":"x","tags":["p","q"]}}`
+
+func TestBuildTree(t *testing.T) {
+	entry := parser.Parse(treeEntry)
+
+	tests := []struct {
+		name      string
+		collapsed map[string]bool
+		want      []string // Path, text
+	}{
+		{
+			name: "expanded",
+			want: []string{
+				"|▾ {",
+				`a|    "a": 1,`,
+				`list|    "list": [],`,
+				`user|  ▾ "user": {`,
+				`user.name|      "name": "x",`,
+				`user.tags|    ▾ "tags": [`,
+				`user.tags.0|        "p",`,
+				`user.tags.1|        "q"`,
+				"user.tags|      ]",
+				"user|    }",
+				"|  }",
+			},
+		},
+		{
+			name:      "collapsed object",
+			collapsed: map[string]bool{"user": true},
+			want: []string{
+				"|▾ {",
+				`a|    "a": 1,`,
+				`list|    "list": [],`,
+				`user|  ▸ "user": {…} 2 keys`,
+				"|  }",
+			},
+		},
+		{
+			name:      "collapsed array",
+			collapsed: map[string]bool{"user.tags": true},
+			want: []string{
+				"|▾ {",
+				`a|    "a": 1,`,
+				`list|    "list": [],`,
+				`user|  ▾ "user": {`,
+				`user.name|      "name": "x",`,
+				`user.tags|    ▸ "tags": […] 2 items`,
+				"user|    }",
+				"|  }",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, l := range buildTree(entry, tt.collapsed, 80, false) {
+				got = append(got, l.path+"|"+ansi.Strip(l.text))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("buildTree() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestBuildTree_Foldable(t *testing.T) {
+	lines := buildTree(parser.Parse(treeEntry), nil, 80, false)
+	for _, l := range lines {
+		// Empty objects and arrays and closing braces have nothing to fold
+		want := strings.Contains(l.text, "▾")
+		if l.foldable != want {
+			t.Errorf("line %q foldable = %v, want %v", ansi.Strip(l.text), l.foldable, want)
+		}
+	}
+}
+
+func TestToggleFold(t *testing.T) {
+	m := press(newTestModel(treeEntry), "d", "tab")
+	expanded := len(m.detailLines)
+	if expanded != 11 {
+		t.Fatalf("detail lines = %d, want 11", expanded)
+	}
+
+	// Folding an object hides its lines and keeps the cursor on it
+	m = press(m, "j", "j", "j", "h")
+	if !m.collapsed["user"] || len(m.detailLines) != 5 || m.detailCursor != 3 {
+		t.Errorf("after h on user: collapsed = %v, lines = %d, cursor = %d; want user folded, 5, 3", m.collapsed, len(m.detailLines), m.detailCursor)
+	}
+	m = press(m, "l")
+	if m.collapsed["user"] || len(m.detailLines) != expanded {
+		t.Errorf("after l on user: collapsed = %v, lines = %d; want unfolded, %d", m.collapsed, len(m.detailLines), expanded)
+	}
+	m = press(m, "enter")
+	if !m.collapsed["user"] {
+		t.Error("enter should toggle the fold")
+	}
+	m = press(m, "enter")
+
+	// Folding from inside a node folds the node and moves the cursor to it
+	m = press(m, "j", "j", "j")
+	if p := m.detailLines[m.detailCursor].path; p != "user.tags.0" {
+		t.Fatalf("cursor on %q, want user.tags.0", p)
+	}
+	m = press(m, "h")
+	if !m.collapsed["user.tags"] || m.detailCursor != 5 {
+		t.Errorf("after h inside tags: collapsed = %v, cursor = %d; want user.tags folded, 5", m.collapsed, m.detailCursor)
+	}
+
+	// Unfolding a leaf does nothing
+	m = press(m, "E", "g", "j", "l")
+	if len(m.collapsed) != 0 || len(m.detailLines) != expanded {
+		t.Errorf("after l on a leaf: collapsed = %v, lines = %d", m.collapsed, len(m.detailLines))
+	}
+}
+
+func TestMoveDetailCursor_StaysInBounds(t *testing.T) {
+	m := press(newTestModel(treeEntry), "d", "tab")
+
+	m = press(m, "k")
+	if m.detailCursor != 0 {
+		t.Errorf("cursor = %d after k on the first line, want 0", m.detailCursor)
+	}
+	m = press(m, "G", "j")
+	if want := len(m.detailLines) - 1; m.detailCursor != want {
+		t.Errorf("cursor = %d after G j, want %d", m.detailCursor, want)
+	}
+
+	// Folding the root from the closing brace of user leaves one line
+	m = press(m, "g")
+	for m.detailLines[m.detailCursor].path != "user" || m.detailLines[m.detailCursor].foldable {
+		m = press(m, "j")
+	}
+	m = press(m, "h")
+	if !m.collapsed[""] || len(m.detailLines) != 1 || m.detailCursor != 0 {
+		t.Errorf("after folding the root: lines = %d, cursor = %d; want 1, 0", len(m.detailLines), m.detailCursor)
+	}
+	m = press(m, "j", "G")
+	if m.detailCursor != 0 {
+		t.Errorf("cursor = %d on a single line, want 0", m.detailCursor)
+	}
+
+	// A shorter entry keeps the cursor within its lines
+	m = press(m, "E", "G")
+	m.detailCursor = len(m.detailLines) - 1
+	m.collapsed["user"] = true
+	m.updateDetail()
+	if m.detailCursor >= len(m.detailLines) {
+		t.Errorf("cursor = %d with %d lines", m.detailCursor, len(m.detailLines))
+	}
+}
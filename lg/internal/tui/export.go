@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thalessoares/lg/internal/buffer"
+	"github.com/thalessoares/lg/internal/output"
+)
+
+// Output is the terminal the program draws on, written one frame or
+// sequence at a time so that the sequences the model writes itself, such as
+// the clipboard's, never land in the middle of a frame. It is still a
+// terminal to the program, which reads its size from it.
+type Output struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// NewOutput wraps a terminal, usually os.Stdout, for tea.WithOutput and
+// Options.Output
+func NewOutput(f *os.File) *Output {
+	return &Output{File: f}
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *Output) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// exportMsg reports a save to a file finished
+type exportMsg struct {
+	path    string
+	entries int
+	err     error
+}
+
+// copyEntry copies the selected entry's JSON to the clipboard
+func (m *Model) copyEntry() tea.Cmd {
+	e := m.selectedEntry()
+	if e == nil {
+		return nil
+	}
+	data, err := output.JSON(e)
+	if err != nil {
+		m.notice = "Copy failed: " + err.Error()
+		return nil
+	}
+	if m.output == nil {
+		m.notice = "Copy failed: no terminal to copy through"
+		return nil
+	}
+	m.notice = fmt.Sprintf("Copied the entry's JSON (%d bytes)", len(data))
+	return copyToClipboard(m.output, string(data))
+}
+
+// copyToClipboard sets the terminal's clipboard with an OSC 52 sequence,
+// which also works over SSH. Terminals that do not support it ignore it.
+func copyToClipboard(w io.Writer, s string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(s)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		seq.WriteTo(w)
+		return nil
+	}
+}
+
+// exportFormat picks the format of a saved file from its extension. Other
+// files get the entries as the list shows them: pretty, or compact lines in
+// the table view.
+func (m Model) exportFormat(path string) output.Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".json":
+		return output.JSONL
+	case ".csv":
+		return output.CSV
+	case ".md", ".markdown":
+		return output.Markdown
+	}
+	if m.tableMode {
+		return output.Compact
+	}
+	return output.Pretty
+}
+
+// exportSeqs returns the sequence numbers of the entries the list shows,
+// including repeats folded in dedup mode
+func (m Model) exportSeqs() []uint64 {
+	seqs := make([]uint64, 0, len(m.rows))
+	for _, r := range m.rows {
+		if m.rowVisible(r) {
+			seqs = append(seqs, r.seq)
+		}
+	}
+	return seqs
+}
+
+// saveEntries writes entries to a file in the background
+func saveEntries(buf *buffer.Buffer, seqs []uint64, path string, overwrite bool, opts output.Options) tea.Cmd {
+	return func() tea.Msg {
+		n, err := writeFile(buf, seqs, path, overwrite, opts)
+		return exportMsg{path: path, entries: n, err: err}
+	}
+}
+
+// writeFile writes the entries still in the buffer to a new file and
+// returns how many there were. An existing file is only replaced when
+// overwrite is set.
+func writeFile(buf *buffer.Buffer, seqs []uint64, path string, overwrite bool, opts output.Options) (int, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o666)
+	if err != nil {
+		return 0, err
+	}
+	w := output.New(f, opts)
+	n := 0
+	for _, seq := range seqs {
+		e := buf.GetSeq(seq)
+		if e == nil {
+			continue
+		}
+		if _, err := w.Write(e); err != nil {
+			f.Close()
+			return n, err
+		}
+		n++
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return n, err
+	}
+	return n, f.Close()
+}
+
+func (m Model) handleSaveMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "enter":
+		path := m.savePath()
+		if path == "" {
+			return m, nil
+		}
+		// An existing file is replaced on the second enter
+		_, err := os.Stat(path)
+		overwrite := err == nil
+		if overwrite && m.saveConfirm != path {
+			m.saveConfirm = path
+			return m, nil
+		}
+		m.mode = ModeView
+		m.saveInput.Blur()
+		opts := output.Options{
+			Format:  m.exportFormat(path),
+			Columns: m.columns,
+			Plain:   !m.saveFormatted,
+		}
+		if m.redacting {
+			opts.Redact = m.redactor
+		}
+		m.notice = "Saving to " + path + "..."
+		return m, saveEntries(m.buffer, m.exportSeqs(), path, overwrite, opts)
+
+	case "tab":
+		m.saveFormatted = !m.saveFormatted
+		return m, nil
+
+	case "esc":
+		m.mode = ModeView
+		m.saveInput.Blur()
+		return m, nil
+	}
+
+	m.saveInput, cmd = m.saveInput.Update(msg)
+	return m, cmd
+}
+
+// savePath returns the path typed in the save prompt, with ~ expanded
+func (m Model) savePath() string {
+	path := strings.TrimSpace(m.saveInput.Value())
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// exportDone reports a finished save in the help bar
+func (m *Model) exportDone(msg exportMsg) {
+	if msg.err != nil {
+		m.notice = "Save failed: " + msg.err.Error()
+		return
+	}
+	m.notice = fmt.Sprintf("Saved %d entries to %s", msg.entries, msg.path)
+}
+
+func (m Model) renderSaveBar() string {
+	format := m.exportFormat(m.saveInput.Value())
+	label := string(format)
+	if format == output.Pretty || format == output.Compact {
+		label = "text, plain"
+		if m.saveFormatted {
+			label = "text, with colors"
+		}
+		label += " (tab: toggle colors)"
+	}
+	if path := m.savePath(); path != "" && path == m.saveConfirm {
+		label = "file exists, enter again to overwrite"
+	}
+	prompt := searchPromptStyle.Render(fmt.Sprintf("Save %d entries to", m.saveCount))
+	bar := prompt + m.saveInput.View() + "  " + helpStyle.Render(label)
+	return searchBarStyle.Render(bar)
+}
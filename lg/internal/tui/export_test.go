@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/output"
+)
+
+// save saves the listed entries from the prompt and returns what was
+// written
+func save(t *testing.T, m Model, path string) (Model, string) {
+	t.Helper()
+	m = press(m, "s", "ctrl+u", path)
+	tm, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("saving to %s started nothing, notice = %q", path, tm.(Model).notice)
+	}
+	tm, _ = tm.Update(cmd())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm.(Model), string(data)
+}
+
+func TestExportFormat(t *testing.T) {
+	m := newTestModel()
+
+	tests := []struct {
+		path  string
+		table bool
+		want  output.Format
+	}{
+		{"out.jsonl", false, output.JSONL},
+		{"out.NDJSON", false, output.JSONL},
+		{"out.json", false, output.JSONL},
+		{"out.csv", false, output.CSV},
+		{"out.md", false, output.Markdown},
+		{"out.markdown", false, output.Markdown},
+		{"out.log", false, output.Pretty},
+		{"out", true, output.Compact},
+		{"out.csv", true, output.CSV},
+	}
+
+	for _, tt := range tests {
+		m.tableMode = tt.table
+		if got := m.exportFormat(tt.path); got != tt.want {
+			t.Errorf("exportFormat(%q) in table view %v = %v, want %v", tt.path, tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestSave_Formats(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{`{"level":"info","msg":"ready","port":8080}`, `{"level":"error","msg":"boom"}`}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"out.jsonl", `{"level":"info","msg":"ready","port":8080}` + "\n" + `{"level":"error","msg":"boom"}` + "\n"},
+		{"out.csv", "boom"},
+		{"out.md", "| "},
+		{"out.txt", `"port": 8080`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			m, got := save(t, newTestModel(lines...), filepath.Join(dir, tt.file))
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("%s = %q, want %q in it", tt.file, got, tt.want)
+			}
+			if strings.Contains(got, "\x1b[") {
+				t.Errorf("%s = %q, want no colors", tt.file, got)
+			}
+			if want := "Saved 2 entries to " + filepath.Join(dir, tt.file); m.notice != want {
+				t.Errorf("notice = %q, want %q", m.notice, want)
+			}
+		})
+	}
+}
+
+func TestSave_ListedEntries(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		`{"level":"info","msg":"before"}`,
+		`{"level":"error","msg":"boom"}`,
+		`{"level":"error","msg":"boom"}`,
+		`{"level":"info","msg":"after"}`,
+		`{"level":"info","msg":"unrelated"}`,
+	}
+
+	// Repeats folded into one row are saved as they were read
+	m := press(newTestModel(lines...), "u")
+	if len(m.visible) != 4 {
+		t.Fatalf("listed = %d with repeats folded, want 4", len(m.visible))
+	}
+	_, got := save(t, m, filepath.Join(dir, "dedup.jsonl"))
+	if n := strings.Count(got, `"boom"`); n != 2 || !strings.Contains(got, "unrelated") {
+		t.Errorf("saved %q, want both repeats and the rest", got)
+	}
+
+	// Context rows around the matches are saved with them
+	m = press(newTestModel(lines...), "/", "level=error", "enter", "C")
+	_, got = save(t, m, filepath.Join(dir, "context.jsonl"))
+	for _, want := range []string{"before", "boom", "after"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("saved %q, want %q", got, want)
+		}
+	}
+	if strings.Contains(got, "unrelated") {
+		t.Errorf("saved %q, want the entries outside the context left out", got)
+	}
+}
+
+func TestSave_Redacted(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestModel(`{"msg":"login","password":"hunter2"}`)
+
+	_, got := save(t, m, filepath.Join(dir, "plain.jsonl"))
+	if !strings.Contains(got, "hunter2") {
+		t.Errorf("saved %q, want the value as it was read", got)
+	}
+
+	_, got = save(t, press(m, "X"), filepath.Join(dir, "redacted.jsonl"))
+	if strings.Contains(got, "hunter2") || !strings.Contains(got, `"password":"[REDACTED]"`) {
+		t.Errorf("saved %q, want the password masked", got)
+	}
+}
+
+func TestSave_AsksBeforeOverwriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	if err := os.WriteFile(path, []byte("keep\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(`{"msg":"new"}`)
+
+	m = press(m, "s", "ctrl+u", path, "enter")
+	if m.mode != ModeSave || !strings.Contains(ansi.Strip(m.renderSaveBar()), "enter again to overwrite") {
+		t.Errorf("mode = %v, save bar = %q; want the prompt asking to overwrite", m.mode, ansi.Strip(m.renderSaveBar()))
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep\n" {
+		t.Errorf("file = %q, want it untouched", data)
+	}
+
+	// Opening the prompt again asks again
+	m = press(m, "esc", "s", "ctrl+u", path)
+	if strings.Contains(ansi.Strip(m.renderSaveBar()), "overwrite") {
+		t.Errorf("save bar = %q, want no warning before enter", ansi.Strip(m.renderSaveBar()))
+	}
+
+	tm, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	tm, cmd := tm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("the second enter saved nothing")
+	}
+	tm.Update(cmd())
+	if data, _ := os.ReadFile(path); string(data) != `{"msg":"new"}`+"\n" {
+		t.Errorf("file = %q, want it replaced", data)
+	}
+
+	// Files created after the prompt are not replaced either
+	if _, err := writeFile(m.buffer, m.exportSeqs(), path, false, output.Options{Format: output.JSONL}); !os.IsExist(err) {
+		t.Errorf("writeFile() error = %v, want the file to exist", err)
+	}
+}
+
+func TestCopyEntry(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+
+	m := newTestModel(`{"msg":"a"}`, `{"msg":"b","n":1}`)
+	if cmd := m.copyEntry(); cmd != nil || !strings.HasPrefix(m.notice, "Copy failed") {
+		t.Errorf("notice = %q, want copying to fail without an output", m.notice)
+	}
+
+	var out bytes.Buffer
+	m.output = &out
+	cmd := m.copyEntry()
+	if cmd == nil {
+		t.Fatalf("copyEntry() copied nothing, notice = %q", m.notice)
+	}
+	if out.Len() != 0 {
+		t.Error("copyEntry() wrote before its command ran")
+	}
+	cmd()
+
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(`{"msg":"b","n":1}`)) + "\a"
+	if out.String() != want {
+		t.Errorf("copied %q, want %q", out.String(), want)
+	}
+	if m.notice != "Copied the entry's JSON (17 bytes)" {
+		t.Errorf("notice = %q", m.notice)
+	}
+}
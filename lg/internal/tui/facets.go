@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+)
+
+const (
+	minFacetsWidth    = 28
+	facetsBorderWidth = 1
+	facetValues       = 10 // Values listed under an opened key
+)
+
+// facetLine is one line of the facets sidebar: a key, or one of its values
+// when the key is opened
+type facetLine struct {
+	path    string
+	value   string
+	isValue bool
+	count   int
+}
+
+// syncFacets counts the fields of entries added since the last call. Every
+// entry is counted, whatever the filters, so the panel shows what there is
+// to filter on.
+func (m *Model) syncFacets() {
+	m.facetScanned = m.buffer.Scan(m.facetScanned, func(_ uint64, e *parser.LogEntry) bool {
+		m.facets.Add(m.redact(e))
+		return true
+	})
+}
+
+// facetLines lists the keys, most common first, with the top values of the
+// opened ones
+func (m Model) facetLines() []facetLine {
+	var lines []facetLine
+	for _, key := range m.facets.Keys() {
+		lines = append(lines, facetLine{path: key.Path, count: key.Count})
+		if !m.facetOpen[key.Path] {
+			continue
+		}
+		for _, v := range key.Top(facetValues) {
+			lines = append(lines, facetLine{path: key.Path, value: v.Value, isValue: true, count: v.Count})
+		}
+	}
+	return lines
+}
+
+// facetIndex finds the selected line, which is kept by key and value since
+// lines move around as counts change
+func (m Model) facetIndex(lines []facetLine) int {
+	for i, l := range lines {
+		if l.path == m.facetSel.path && l.isValue == m.facetSel.isValue && l.value == m.facetSel.value {
+			return i
+		}
+	}
+	// The selected value dropped out of the top values; fall back to its key
+	for i, l := range lines {
+		if l.path == m.facetSel.path && !l.isValue {
+			return i
+		}
+	}
+	return 0
+}
+
+// moveFacetCursor moves the sidebar selection by delta lines
+func (m *Model) moveFacetCursor(delta int) {
+	lines := m.facetLines()
+	if len(lines) == 0 {
+		return
+	}
+	i := min(max(m.facetIndex(lines)+delta, 0), len(lines)-1)
+	m.facetSel = lines[i]
+	m.facetTop = m.facetScroll(len(lines), i)
+}
+
+// handleFacetsKey handles navigation inside the facets sidebar. It returns
+// false for keys the sidebar does not use, so they fall through to the list.
+func (m *Model) handleFacetsKey(msg tea.KeyMsg) bool {
+	// Act on the line shown as selected, which may differ from facetSel
+	// before the first move or once a value drops out of the top values
+	if lines := m.facetLines(); len(lines) > 0 {
+		m.facetSel = lines[m.facetIndex(lines)]
+	}
+
+	switch m.keys.action("facets", msg.String()) {
+	case "down":
+		m.moveFacetCursor(1)
+	case "up":
+		m.moveFacetCursor(-1)
+	case "top":
+		m.moveFacetCursor(-len(m.facetLines()))
+	case "bottom":
+		m.moveFacetCursor(len(m.facetLines()))
+	case "page_down":
+		m.moveFacetCursor(max(m.listHeight/2, 1))
+	case "page_up":
+		m.moveFacetCursor(-max(m.listHeight/2, 1))
+	case "include":
+		m.selectFacet(m.facetSel, "=")
+	case "exclude":
+		m.selectFacet(m.facetSel, "!=")
+	case "left":
+		delete(m.facetOpen, m.facetSel.path)
+		m.facetSel = facetLine{path: m.facetSel.path}
+	case "right":
+		m.facetOpen[m.facetSel.path] = true
+	case "focus", "reset":
+		m.focus = focusList
+	default:
+		return false
+	}
+	return true
+}
+
+// selectFacet opens or closes a key, or narrows the filter to entries with
+// (op "=") or without (op "!=") a value
+func (m *Model) selectFacet(l facetLine, op string) {
+	if l.path == "" {
+		return
+	}
+	if !l.isValue {
+		if op == "=" {
+			m.facetOpen[l.path] = !m.facetOpen[l.path]
+		}
+		return
+	}
+
+	input := query.Refine(m.filter, query.Condition(l.path, op, l.value))
+	q, err := query.Compile(input)
+	if err != nil {
+		return
+	}
+	m.filter = input
+	m.query = q
+	m.searchInput.SetValue(input)
+	m.rebuild()
+}
+
+// facetsWidth is the width of the facets sidebar including its border, 0
+// when hidden
+func (m Model) facetsWidth() int {
+	if !m.showFacets {
+		return 0
+	}
+	return max(m.width/5, minFacetsWidth) + facetsBorderWidth
+}
+
+// renderFacets renders the sidebar at the list height
+func (m Model) renderFacets() string {
+	width := m.facetsWidth() - facetsBorderWidth
+	lines := m.facetLines()
+	cursor := m.facetIndex(lines)
+	top := m.facetScroll(len(lines), cursor)
+
+	out := make([]string, 0, m.listHeight)
+	if len(lines) == 0 {
+		out = append(out, foldedBodyStyle.Render(" No fields yet"))
+	}
+	for i := top; i < len(lines) && len(out) < m.listHeight; i++ {
+		l := lines[i]
+		gutter := emptyGutter
+		if i == cursor && m.focus == focusFacets {
+			gutter = cursorGutter
+		}
+
+		// Name on the left, count on the right
+		name, style := "▸ "+l.path, treeKeyStyle
+		switch {
+		case l.isValue && l.value == "":
+			name, style = `  ""`, treeStringStyle
+		case l.isValue:
+			name, style = "  "+l.value, treeStringStyle
+		case m.facetOpen[l.path]:
+			name = "▾ " + l.path
+		}
+		count := fmt.Sprint(l.count)
+		room := width - lipgloss.Width(gutter) - len(count)
+		name = ansi.Truncate(name, max(room-1, 0), "…")
+		gap := strings.Repeat(" ", max(room-lipgloss.Width(name), 1))
+		out = append(out, ansi.Truncate(gutter+style.Render(name)+gap+facetCountStyle.Render(count), width, ""))
+	}
+	for len(out) < m.listHeight {
+		out = append(out, "")
+	}
+
+	for i, l := range out {
+		if gap := width - lipgloss.Width(l); gap > 0 {
+			out[i] = l + strings.Repeat(" ", gap)
+		}
+	}
+	border := detailBorderStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", m.listHeight), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, strings.Join(out, "\n"), border)
+}
+
+// facetScroll returns the first sidebar line on screen. Lines move as
+// counts change, so the selection is brought back into view here.
+func (m Model) facetScroll(n, cursor int) int {
+	top := min(m.facetTop, max(n-m.listHeight, 0))
+	if cursor < top {
+		return cursor
+	}
+	if cursor >= top+m.listHeight {
+		return cursor - m.listHeight + 1
+	}
+	return top
+}
+
+// clickFacet selects the sidebar line on a screen row: a click on a key
+// opens or closes it, a click on a value filters on it
+func (m *Model) clickFacet(row int) {
+	lines := m.facetLines()
+	top := m.facetScroll(len(lines), m.facetIndex(lines))
+	i := top + row
+	if i >= len(lines) {
+		return
+	}
+	m.focus = focusFacets
+	m.facetTop = top
+	m.facetSel = lines[i]
+	m.selectFacet(lines[i], "=")
+}
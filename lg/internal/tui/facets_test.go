@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// facetEntries have service on every entry and status on most, so service
+// is listed first
+var facetEntries = []string{
+	`{"service":"api","status":200}`,
+	`{"service":"api","status":500}`,
+	`{"service":"web","status":200}`,
+	`{"service":"api"}`,
+}
+
+func TestFacets_IncludeAndExclude(t *testing.T) {
+	m := press(newTestModel(facetEntries...), "F")
+	if m.focus != focusFacets {
+		t.Fatalf("focus = %v, want the facets sidebar", m.focus)
+	}
+
+	// enter on a key opens it, listing its values most common first
+	m = press(m, "enter")
+	var lines []string
+	for _, l := range m.facetLines() {
+		lines = append(lines, l.path+"="+l.value)
+	}
+	if want := []string{"service=", "service=api", "service=web", "status="}; !slices.Equal(lines, want) {
+		t.Fatalf("facet lines = %v, want %v", lines, want)
+	}
+
+	steps := []struct {
+		keys   []string
+		filter string
+		listed []uint64
+	}{
+		{[]string{"j", "enter"}, "service=api", []uint64{0, 1, 3}},
+		{[]string{"j", "x"}, "service=api && service!=web", []uint64{0, 1, 3}},
+		{[]string{"esc", "tab", "j", "l", "j", "!"}, "service=api && service!=web && status!=200", []uint64{1, 3}},
+	}
+	for _, s := range steps {
+		m = press(m, s.keys...)
+		if m.filter != s.filter || m.searchInput.Value() != s.filter || !slices.Equal(m.visible, s.listed) {
+			t.Errorf("after %v: filter = %q, input = %q, listed = %v; want %q, %v", s.keys, m.filter, m.searchInput.Value(), m.visible, s.filter, s.listed)
+		}
+	}
+}
+
+func TestFacets_Refine(t *testing.T) {
+	m := press(newTestModel(facetEntries...), "/", "api || web", "enter", "F", "enter", "j", "=")
+	if want := `(api || web) && service=api`; m.filter != want {
+		t.Errorf("filter = %q, want %q", m.filter, want)
+	}
+
+	// Values with spaces or quotes are quoted
+	m = newTestModel(`{"msg":"disk \"sda\" full"}`)
+	m.facetOpen["msg"] = true
+	m.selectFacet(m.facetLines()[1], "=")
+	if want := `msg="disk \"sda\" full"`; m.filter != want || len(m.visible) != 1 {
+		t.Errorf("filter = %q, listed = %d; want %q, 1", m.filter, len(m.visible), want)
+	}
+}
+
+func TestClickFacet(t *testing.T) {
+	m := press(newTestModel(facetEntries...), "F", "tab")
+	click := func(m Model, row int) Model {
+		tm, _ := m.Update(tea.MouseMsg{X: 2, Y: m.listTop + row, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+		return tm.(Model)
+	}
+
+	// A click on a key focuses the sidebar and opens the key, and a click
+	// on a value filters on it
+	if m = click(m, 0); m.focus != focusFacets || !m.facetOpen["service"] {
+		t.Errorf("focus = %v, opened = %v; want the sidebar with service opened", m.focus, m.facetOpen)
+	}
+	if m = click(m, 2); m.filter != "service=web" || !slices.Equal(m.visible, []uint64{2}) {
+		t.Errorf("filter = %q, listed = %v; want service=web, [2]", m.filter, m.visible)
+	}
+	if m = click(m, 0); m.facetOpen["service"] || m.filter != "service=web" {
+		t.Errorf("opened = %v, filter = %q; want service closed and the filter kept", m.facetOpen, m.filter)
+	}
+
+	// Clicks below the last line do nothing
+	if m = click(m, 10); m.filter != "service=web" {
+		t.Errorf("filter = %q after a click on nothing", m.filter)
+	}
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/query"
+)
+
+// Find mode searches without filtering: every entry stays listed, the text
+// found is highlighted and n/N move between the entries that contain it, as
+// in less.
+
+// contextSteps are the context sizes the context key cycles through
+var contextSteps = []int{0, 1, 3, 5, 10}
+
+// setFind searches for text, or stops searching when it is empty
+func (m *Model) setFind(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		m.clearFind()
+		return
+	}
+	m.findText = text
+	m.findRe = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+	m.findQuery = query.Text(text)
+}
+
+// clearFind stops searching
+func (m *Model) clearFind() {
+	m.findText = ""
+	m.findRe = nil
+	m.findQuery = nil
+}
+
+// findMatch reports whether the visible entry at index i contains the text
+// searched for, the same way a plain text filter would match it
+func (m Model) findMatch(i int) bool {
+	e := m.entry(i)
+	return e != nil && m.findQuery.Match(e)
+}
+
+// findNext selects the next entry containing the text searched for, after
+// the selection or before it with forward false. With from set, the
+// selection itself counts. It reports whether there was one.
+func (m *Model) findNext(forward, from bool) bool {
+	if m.findQuery == nil || len(m.visible) == 0 {
+		return false
+	}
+	step, i := 1, m.cursor+1
+	if !forward {
+		step, i = -1, m.cursor-1
+	}
+	if from {
+		i = m.cursor
+	}
+	for ; i >= 0 && i < len(m.visible); i += step {
+		if m.findMatch(i) {
+			m.moveCursor(i - m.cursor)
+			return true
+		}
+	}
+	return false
+}
+
+// findKey moves to the next or previous match for n and N
+func (m *Model) findKey(forward bool) {
+	switch {
+	case m.findQuery == nil:
+		m.notice = "Nothing to find, press " + m.keys.key("", "find") + " to search"
+	case !m.findNext(forward, false):
+		m.notice = "Pattern not found"
+	}
+}
+
+// highlightFind marks the text searched for in a rendered line, keeping the
+// line's own colors around it
+func (m Model) highlightFind(line string) string {
+	if m.findRe == nil {
+		return line
+	}
+	plain := ansi.Strip(line)
+	matches := m.findRe.FindAllStringIndex(plain, -1)
+	if matches == nil {
+		return line
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, match := range matches {
+		from, to := ansi.StringWidth(plain[:match[0]]), ansi.StringWidth(plain[:match[1]])
+		b.WriteString(ansi.Cut(line, col, from))
+		b.WriteString(highlightMatchStyle.Render(plain[match[0]:match[1]]))
+		col = to
+	}
+	b.WriteString(ansi.Cut(line, col, ansi.StringWidth(plain)))
+	return b.String()
+}
+
+func (m Model) handleFindMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "enter":
+		m.mode = ModeView
+		m.findInput.Blur()
+		m.setFind(m.findInput.Value())
+		// The nearest match, looking down first
+		if m.findQuery != nil && !m.findNext(true, true) && !m.findNext(false, false) {
+			m.notice = "Pattern not found"
+		}
+		return m, nil
+
+	case "esc":
+		m.mode = ModeView
+		m.findInput.Blur()
+		return m, nil
+	}
+
+	m.findInput, cmd = m.findInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) renderFindBar() string {
+	prompt := searchPromptStyle.Render("Find")
+	return searchBarStyle.Render(prompt + m.findInput.View())
+}
+
+// cycleContext shows more entries around filter matches, up to the last
+// step, then none again
+func (m *Model) cycleContext() {
+	next := 0
+	for _, n := range contextSteps {
+		if n > m.contextSize {
+			next = n
+			break
+		}
+	}
+	m.contextSize = next
+	if m.query != nil {
+		m.rebuild()
+	}
+}
+
+// findString describes the search for the status bar
+func (m Model) findString() string {
+	if m.findRe == nil {
+		return ""
+	}
+	return fmt.Sprintf("Find %q", m.findText)
+}
+
+// contextString describes the context shown around filter matches
+func (m Model) contextString() string {
+	if m.contextSize == 0 || m.query == nil {
+		return ""
+	}
+	return fmt.Sprintf("Context ±%d", m.contextSize)
+}
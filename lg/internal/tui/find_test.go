@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestFind(t *testing.T) {
+	m := newTestModel(`{"msg":"start"}`, `{"msg":"boom one"}`, `{"msg":"quiet"}`, `{"msg":"BOOM two"}`, `{"msg":"end"}`)
+
+	m = press(m, "n")
+	if m.notice != "Nothing to find, press f to search" {
+		t.Errorf("notice = %q before searching", m.notice)
+	}
+
+	// The nearest match below is selected and nothing is filtered out
+	m = press(m, "g", "f", "boom", "enter")
+	if m.mode != ModeView || m.cursor != 1 || len(m.visible) != 5 {
+		t.Errorf("mode = %v, cursor = %d, listed = %d; want the view, 1, 5", m.mode, m.cursor, len(m.visible))
+	}
+	if got := m.findString(); got != `Find "boom"` {
+		t.Errorf("findString() = %q", got)
+	}
+
+	// Find opens with the last search, and without a match below, the
+	// nearest one above is selected
+	if m = press(m, "G", "f"); m.findInput.Value() != "boom" {
+		t.Errorf("find input = %q, want the last search", m.findInput.Value())
+	}
+	m = press(m, "ctrl+u", "one", "enter")
+	if m.cursor != 1 || m.notice != "" {
+		t.Errorf("cursor = %d, notice = %q; want 1 and no notice", m.cursor, m.notice)
+	}
+
+	m = press(m, "f", "ctrl+u", "nowhere", "enter")
+	if m.cursor != 1 || m.notice != "Pattern not found" {
+		t.Errorf("cursor = %d, notice = %q; want 1 and Pattern not found", m.cursor, m.notice)
+	}
+	if m = press(m, "j"); m.notice != "" {
+		t.Errorf("notice = %q, want it gone after the next key", m.notice)
+	}
+}
+
+func TestFind_NextAndPrevious(t *testing.T) {
+	m := newTestModel(`{"msg":"boom one"}`, `{"msg":"quiet"}`, `{"msg":"Boom two"}`, `{"msg":"quiet"}`, `{"msg":"boom three"}`)
+	m = press(m, "g", "f", "boom", "enter")
+
+	// n and N stop at the last and first match, as in less, instead of
+	// wrapping around
+	steps := []struct {
+		key    string
+		cursor int
+		notice string
+	}{
+		{"n", 2, ""},
+		{"n", 4, ""},
+		{"n", 4, "Pattern not found"},
+		{"N", 2, ""},
+		{"N", 0, ""},
+		{"N", 0, "Pattern not found"},
+	}
+	for i, s := range steps {
+		m = press(m, s.key)
+		if m.cursor != s.cursor || m.notice != s.notice {
+			t.Errorf("step %d (%s): cursor = %d, notice = %q; want %d, %q", i, s.key, m.cursor, m.notice, s.cursor, s.notice)
+		}
+	}
+
+	// Rows hidden by a filter are skipped
+	m = press(m, "/", "two || three", "enter", "g", "n")
+	if seq := m.visible[m.cursor]; seq != 4 {
+		t.Errorf("selected entry %d, want 4", seq)
+	}
+}
+
+func TestHighlightFind(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(1) // 256 colors
+
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	bold := lipgloss.NewStyle().Bold(true)
+
+	tests := []struct {
+		name  string
+		find  string
+		line  string
+		marks []string // Text expected highlighted, in order
+	}{
+		{"plain", "err", "an error here", []string{"err"}},
+		{"across styles", "lo wo", red.Render("hello ") + bold.Render("world"), []string{"lo wo"}},
+		{"every match, any case", "boom", "Boom and BOOM and boom", []string{"Boom", "BOOM", "boom"}},
+		{"after wide runes", "error", "日本語 error 日本", []string{"error"}},
+		{"wide runes", "本語", red.Render("日本語") + " ok", []string{"本語"}},
+		{"no match", "zzz", red.Render("nothing"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel()
+			m.setFind(tt.find)
+			got := m.highlightFind(tt.line)
+
+			if ansi.Strip(got) != ansi.Strip(tt.line) {
+				t.Errorf("highlightFind() text = %q, want %q", ansi.Strip(got), ansi.Strip(tt.line))
+			}
+			rest := got
+			for _, mark := range tt.marks {
+				i := strings.Index(rest, highlightMatchStyle.Render(mark))
+				if i < 0 {
+					t.Fatalf("highlightFind() = %q, want %q highlighted", got, mark)
+				}
+				rest = rest[i+1:]
+			}
+			if tt.marks == nil && got != tt.line {
+				t.Errorf("highlightFind() = %q, want the line unchanged", got)
+			}
+		})
+	}
+}
+
+func TestCycleContext(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		level := "info"
+		if i == 2 || i == 7 {
+			level = "error"
+		}
+		lines = append(lines, fmt.Sprintf(`{"level":%q,"msg":"entry %d"}`, level, i))
+	}
+	m := newTestModel(lines...)
+
+	// Context needs a filter to be shown around
+	if m = press(m, "C"); m.contextString() != "" || len(m.visible) != 10 {
+		t.Errorf("without a filter: context = %q, listed = %d", m.contextString(), len(m.visible))
+	}
+	if m = press(m, "C", "C", "C", "C"); m.contextSize != 0 {
+		t.Fatalf("context size = %d after a full cycle, want 0", m.contextSize)
+	}
+
+	m = press(m, "/", "level=error", "enter")
+	steps := []struct {
+		context string
+		listed  []uint64
+	}{
+		{"Context ±1", []uint64{1, 2, 3, 6, 7, 8}},
+		{"Context ±3", []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"Context ±5", []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"Context ±10", []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"", []uint64{2, 7}},
+	}
+	for _, s := range steps {
+		m = press(m, "C")
+		if m.contextString() != s.context || !slices.Equal(m.visible, s.listed) {
+			t.Errorf("context = %q, listed = %v; want %q, %v", m.contextString(), m.visible, s.context, s.listed)
+		}
+	}
+
+	// Only matches count as matches; the rest are context rows
+	m = press(m, "C")
+	var context []uint64
+	for _, seq := range m.visible {
+		if m.contextRows[seq] {
+			context = append(context, seq)
+		}
+	}
+	if !slices.Equal(context, []uint64{1, 3, 6, 8}) || m.levelCounts[m.entry(1).Level] != 2 {
+		t.Errorf("context rows = %v, level counts = %v", context, m.levelCounts)
+	}
+
+	// Entries that arrive later get their context as they come
+	var tm tea.Model = m
+	for _, l := range []string{`{"level":"info","msg":"a"}`, `{"level":"info","msg":"b"}`, `{"level":"error","msg":"c"}`, `{"level":"info","msg":"d"}`, `{"level":"info","msg":"e"}`} {
+		tm, _ = tm.Update(AddLogEntry(parser.Parse(l)))
+	}
+	if want := []uint64{1, 2, 3, 6, 7, 8, 11, 12, 13}; !slices.Equal(tm.(Model).visible, want) {
+		t.Errorf("listed = %v after new entries, want %v", tm.(Model).visible, want)
+	}
+}
@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/correlate"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// maxGroupIDWidth caps the ID column of the groups screen
+const maxGroupIDWidth = 40
+
+// syncGroups counts entries added since the last call in their correlation
+// group. Without --group-by, the key is detected from the first entry that
+// has one.
+func (m *Model) syncGroups() {
+	m.groupScanned = m.buffer.Scan(m.groupScanned, func(_ uint64, e *parser.LogEntry) bool {
+		if m.groups == nil {
+			key, _, ok := correlate.Detect(e)
+			if !ok {
+				return true
+			}
+			m.groups = correlate.NewIndex(key)
+		}
+		m.groups.Add(e)
+		return true
+	})
+}
+
+// setGroupKey counts the groups of another correlation key, from the
+// oldest entry in the buffer
+func (m *Model) setGroupKey(key string) {
+	m.groups = correlate.NewIndex(key)
+	m.groupScanned = 0
+	m.groupSel = ""
+	m.syncGroups()
+}
+
+// inGroup reports whether an entry belongs to the group shown alone, if any
+func (m Model) inGroup(e *parser.LogEntry) bool {
+	if m.groupID == "" {
+		return true
+	}
+	id, ok := correlate.ID(e, m.groups.Key())
+	return ok && id == m.groupID
+}
+
+// toggleGroup shows only the entries sharing the selected entry's
+// correlation ID, or every entry again
+func (m *Model) toggleGroup() {
+	if m.groupID != "" {
+		m.setGroup("")
+		return
+	}
+	e := m.selectedEntry()
+	if e == nil {
+		return
+	}
+	if m.groups == nil || !hasID(e, m.groups.Key()) {
+		key, _, ok := correlate.Detect(e)
+		if !ok {
+			return
+		}
+		m.setGroupKey(key)
+	}
+	id, _ := correlate.ID(e, m.groups.Key())
+	m.setGroup(id)
+}
+
+func hasID(e *parser.LogEntry, key string) bool {
+	_, ok := correlate.ID(e, key)
+	return ok
+}
+
+// setGroup narrows the list to the entries of one correlation ID, or shows
+// every entry again with ""
+func (m *Model) setGroup(id string) {
+	m.groupID = id
+	m.rebuild()
+}
+
+// groupString describes the group shown alone for the status bar: its ID,
+// entries, span between the first and last entry and errors
+func (m Model) groupString() string {
+	if m.groupID == "" {
+		return ""
+	}
+	s := m.groups.Key() + "=" + m.groupID
+	if g, ok := m.groups.Group(m.groupID); ok {
+		s += fmt.Sprintf(" %d entries", g.Count)
+		if !g.First.IsZero() {
+			s += " " + formatSpan(g.Span())
+		}
+		switch {
+		case g.Errors == 1:
+			s += " " + parser.LevelError.Style().Render("1 error")
+		case g.Errors > 1:
+			s += " " + parser.LevelError.Style().Render(fmt.Sprintf("%d errors", g.Errors))
+		}
+	}
+	return "Group " + s
+}
+
+// formatSpan renders a group's span at a precision matching its length
+func formatSpan(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Microsecond).String()
+	case d < time.Minute:
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// groupList returns the groups in screen order and the index of the
+// selected one, kept by ID since groups move around as entries arrive
+func (m Model) groupList() ([]correlate.Group, int) {
+	if m.groups == nil {
+		return nil, 0
+	}
+	groups := m.groups.Groups()
+	for i, g := range groups {
+		if g.ID == m.groupSel {
+			return groups, i
+		}
+	}
+	return groups, 0
+}
+
+// moveGroupCursor moves the selection on the groups screen by delta rows
+func (m *Model) moveGroupCursor(delta int) {
+	groups, cursor := m.groupList()
+	if len(groups) == 0 {
+		return
+	}
+	i := min(max(cursor+delta, 0), len(groups)-1)
+	m.groupSel = groups[i].ID
+	m.groupTop = m.groupScroll(len(groups), i)
+}
+
+// handleGroupsKey handles keys on the groups screen. It returns false for
+// keys it does not use.
+func (m *Model) handleGroupsKey(msg tea.KeyMsg) bool {
+	groups, cursor := m.groupList()
+	page := max(m.groupRows()/2, 1)
+
+	switch m.keys.action("groups", msg.String()) {
+	case "down":
+		m.moveGroupCursor(1)
+	case "up":
+		m.moveGroupCursor(-1)
+	case "top":
+		m.moveGroupCursor(-len(groups))
+	case "bottom":
+		m.moveGroupCursor(len(groups))
+	case "page_down":
+		m.moveGroupCursor(page)
+	case "page_up":
+		m.moveGroupCursor(-page)
+	case "select":
+		if len(groups) > 0 {
+			m.showGroups = false
+			m.setGroup(groups[cursor].ID)
+		}
+	case "groups", "reset":
+		// The key that opened the screen closes it, as does reset
+		m.showGroups = false
+	default:
+		return false
+	}
+	return true
+}
+
+// groupRows is the number of groups on screen, below the column header
+func (m Model) groupRows() int {
+	return max(m.listHeight-1, 1)
+}
+
+// groupScroll returns the first group on screen, bringing the selection
+// into view
+func (m Model) groupScroll(n, cursor int) int {
+	rows := m.groupRows()
+	top := min(m.groupTop, max(n-rows, 0))
+	if cursor < top {
+		return cursor
+	}
+	if cursor >= top+rows {
+		return cursor - rows + 1
+	}
+	return top
+}
+
+// clickGroup shows the group on a screen row alone
+func (m *Model) clickGroup(y int) {
+	groups, cursor := m.groupList()
+	// Below the status bar and the column header
+	i := m.groupScroll(len(groups), cursor) + y - 2
+	if y < 2 || i >= len(groups) {
+		return
+	}
+	m.showGroups = false
+	m.groupSel = groups[i].ID
+	m.setGroup(groups[i].ID)
+}
+
+// renderGroups renders the groups screen at the list size: one row per
+// correlation ID with its entries, errors and span
+func (m Model) renderGroups() string {
+	groups, cursor := m.groupList()
+	var lines []string
+	if len(groups) == 0 {
+		lines = append(lines, foldedBodyStyle.Render(" No correlation IDs yet ("+strings.Join(correlate.Keys[:5], ", ")+", ...)"))
+	} else {
+		idWidth := len(m.groups.Key())
+		for _, g := range groups {
+			idWidth = max(idWidth, lipgloss.Width(g.ID))
+		}
+		idWidth = min(idWidth, maxGroupIDWidth)
+
+		// Counts are padded before styling, which would throw off %7s
+		row := func(gutter, id, count, errors, span string) string {
+			id = ansi.Truncate(id, idWidth, "…")
+			return gutter + id + strings.Repeat(" ", idWidth-lipgloss.Width(id)) + "  " + count + "  " + errors + "  " + span
+		}
+		lines = append(lines, emptyGutter+treeKeyStyle.Render(row("", m.groups.Key(), "entries", " errors", "span")))
+
+		top := m.groupScroll(len(groups), cursor)
+		for i := top; i < len(groups) && len(lines) < m.listHeight; i++ {
+			g := groups[i]
+			gutter := emptyGutter
+			if i == cursor {
+				gutter = cursorGutter
+			}
+			span := ""
+			if !g.First.IsZero() {
+				span = formatSpan(g.Span())
+			}
+			errors := fmt.Sprintf("%7d", g.Errors)
+			if g.Errors > 0 {
+				errors = parser.LevelError.Style().Render(errors)
+			}
+			line := row(gutter, g.ID, fmt.Sprintf("%7d", g.Count), errors, timeStyle.Render(span))
+			lines = append(lines, ansi.Truncate(line, m.width, ""))
+		}
+	}
+
+	for len(lines) < m.listHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:m.listHeight], "\n")
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// groupEntries have request IDs, detected as the correlation key from the
+// first entry, except for one without any ID and one with a trace ID only
+var groupEntries = []string{
+	`{"request_id":"r1","msg":"start"}`,
+	`{"request_id":"r2","msg":"start"}`,
+	`{"msg":"no id"}`,
+	`{"request_id":"r1","level":"error","msg":"fail"}`,
+	`{"trace_id":"t9","msg":"traced"}`,
+}
+
+func TestToggleGroup(t *testing.T) {
+	m := newTestModel(groupEntries...)
+
+	// r narrows the list to the selected entry's request
+	m = press(m, "k", "r")
+	if !slices.Equal(m.visible, []uint64{0, 3}) {
+		t.Errorf("listed = %v, want [0 3]", m.visible)
+	}
+	if s := ansi.Strip(m.groupString()); s != "Group request_id=r1 2 entries 1 error" {
+		t.Errorf("groupString() = %q", s)
+	}
+	for seq, want := range map[uint64]bool{0: true, 1: false, 2: false} {
+		if got := m.inGroup(m.buffer.GetSeq(seq)); got != want {
+			t.Errorf("inGroup(%d) = %v, want %v", seq, got, want)
+		}
+	}
+
+	// and again shows every entry
+	if m = press(m, "r"); len(m.visible) != 5 || m.groupString() != "" {
+		t.Errorf("listed = %d, group = %q; want 5 and no group", len(m.visible), m.groupString())
+	}
+
+	// An entry without the key switches to the key it has
+	m = press(m, "G", "r")
+	if m.groups.Key() != "trace_id" || !slices.Equal(m.visible, []uint64{4}) {
+		t.Errorf("key = %q, listed = %v; want trace_id, [4]", m.groups.Key(), m.visible)
+	}
+
+	// and one without any ID does nothing
+	m = press(m, "r", "k", "k", "r")
+	if m.groupID != "" || len(m.visible) != 5 {
+		t.Errorf("group = %q, listed = %d; want no group for an entry without an ID", m.groupID, len(m.visible))
+	}
+}
+
+func TestGroupsScreen(t *testing.T) {
+	m := press(newTestModel(groupEntries...), "I")
+	if !m.showGroups {
+		t.Fatal("I did not open the groups screen")
+	}
+
+	// Groups with errors come first
+	groups, cursor := m.groupList()
+	var ids []string
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	if !slices.Equal(ids, []string{"r1", "r2"}) || cursor != 0 {
+		t.Errorf("groups = %v, cursor = %d; want [r1 r2], 0", ids, cursor)
+	}
+	if screen := ansi.Strip(m.renderGroups()); !strings.Contains(screen, "r1") || !strings.Contains(screen, "r2") {
+		t.Errorf("groups screen = %q", screen)
+	}
+
+	m = press(m, "j", "enter")
+	if m.showGroups || m.groupID != "r2" || !slices.Equal(m.visible, []uint64{1}) {
+		t.Errorf("screen shown = %v, group = %q, listed = %v; want the list of r2, [1]", m.showGroups, m.groupID, m.visible)
+	}
+
+	// The selection is kept, and r goes back to every entry
+	if m = press(m, "I"); m.groupSel != "r2" {
+		t.Errorf("selected = %q, want r2 kept", m.groupSel)
+	}
+	if m = press(m, "esc", "r"); m.groupID != "" || len(m.visible) != 5 {
+		t.Errorf("group = %q, listed = %d; want every entry", m.groupID, len(m.visible))
+	}
+}
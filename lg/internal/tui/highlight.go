@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// highlight renders a line of an entry in the style of the rule
+// highlighting it, if any, in place of its own colors
+func (m Model) highlight(e *parser.LogEntry, line string) string {
+	if e == nil || e.Highlight == "" {
+		return line
+	}
+	style, ok := m.rules.Style(e.Highlight)
+	if !ok {
+		return line
+	}
+	return style.Render(ansi.Strip(line))
+}
+
+// alertString counts the entries matched by alert rules since the last
+// clear, for the status bar
+func (m Model) alertString() string {
+	n := m.rules.Alerts() - m.alertsSeen
+	if n == 0 {
+		return ""
+	}
+	return statusInfoStyle.Render(alertStyle.Render(fmt.Sprintf("⚑ %d", n)))
+}
@@ -0,0 +1,355 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/histogram"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// rateWindow is how far back the ingest rate in the status bar averages
+const rateWindow = 10 * time.Second
+
+// Entries without a timestamp at the start of the stream are held until a
+// timestamp shows up, for at most this long or this many entries
+const (
+	untimedWait = 2 * time.Second
+	maxUntimed  = 1000
+)
+
+// histogramLabelWidth is the width of the level label in front of each row
+const histogramLabelWidth = 4
+
+// sparks are the bar heights, in eighths of a line
+var sparks = []rune(" ▁▂▃▄▅▆▇█")
+
+// timeMark places the entries without a timestamp from sequence number seq
+// onwards at a time: that of the timed entry before them in the stream, or
+// their arrival time when there is none
+type timeMark struct {
+	seq uint64
+	at  time.Time
+}
+
+// pendingEntry is an entry without a timestamp that arrived before any
+// timed entry, waiting to be placed
+type pendingEntry struct {
+	seq   uint64
+	level parser.Level
+	at    time.Time // Arrival time
+}
+
+// timeWindow is the time range picked in the histogram. Unlike
+// --since/--until, it also applies to entries without a timestamp, through
+// their time marks.
+type timeWindow struct {
+	from, to time.Time // to is exclusive
+}
+
+func (w timeWindow) isZero() bool {
+	return w.from.IsZero() && w.to.IsZero()
+}
+
+// entryTime is an entry's timestamp, or the time it was placed at without
+// one
+func (m Model) entryTime(seq uint64, e *parser.LogEntry) time.Time {
+	if !e.Time.IsZero() {
+		return e.Time
+	}
+	i := sort.Search(len(m.timeMarks), func(i int) bool { return m.timeMarks[i].seq > seq })
+	if i == 0 {
+		return time.Time{}
+	}
+	return m.timeMarks[i-1].at
+}
+
+// inWindow reports whether an entry falls in the window picked in the
+// histogram
+func (m Model) inWindow(seq uint64, e *parser.LogEntry) bool {
+	if m.window.isZero() {
+		return true
+	}
+	t := m.entryTime(seq, e)
+	return !t.Before(m.window.from) && t.Before(m.window.to)
+}
+
+// syncHistogram counts entries added since the last call, received at now,
+// and forgets the time marks of entries that left the buffer
+func (m *Model) syncHistogram(now time.Time) {
+	first := m.buffer.First()
+	if n := sort.Search(len(m.timeMarks), func(i int) bool { return m.timeMarks[i].seq > first }); n > 1 {
+		m.timeMarks = m.timeMarks[n-1:]
+	}
+
+	m.histScanned = m.buffer.Scan(m.histScanned, func(seq uint64, e *parser.LogEntry) bool {
+		switch {
+		case !e.Time.IsZero():
+			// Lines before the first timestamp, such as a banner, belong with it
+			for _, p := range m.untimed {
+				m.placeUntimed(p.seq, e.Time, p.level)
+			}
+			m.untimed = nil
+			m.lastTime = e.Time
+			m.histogram.Add(e.Time, e.Level)
+		case !m.lastTime.IsZero():
+			m.placeUntimed(seq, m.lastTime, e.Level)
+		default:
+			m.untimed = append(m.untimed, pendingEntry{seq: seq, level: e.Level, at: now})
+		}
+		return true
+	})
+
+	// Without timestamps so far, fall back to arrival times
+	if len(m.untimed) > 0 && (len(m.untimed) >= maxUntimed || now.Sub(m.untimed[0].at) >= untimedWait) {
+		for _, p := range m.untimed {
+			m.placeUntimed(p.seq, p.at, p.level)
+		}
+		m.untimed = nil
+	}
+}
+
+// placeUntimed counts an entry without a timestamp at time t
+func (m *Model) placeUntimed(seq uint64, t time.Time, l parser.Level) {
+	if n := len(m.timeMarks); n == 0 || !m.timeMarks[n-1].at.Equal(t) {
+		m.timeMarks = append(m.timeMarks, timeMark{seq: seq, at: t})
+	}
+	m.histogram.Add(t, l)
+}
+
+// startTick schedules a redraw every second, unless one is pending
+func (m *Model) startTick() tea.Cmd {
+	if m.ticking {
+		return nil
+	}
+	m.ticking = true
+	return tick()
+}
+
+// histogramBins groups the histogram into at most one bin per column, and
+// returns how many columns each bin takes to fill the width
+func (m Model) histogramBins() ([]histogram.Bin, int) {
+	columns := max(m.width-histogramLabelWidth-1, 1)
+	bins := m.histogram.Bins(columns)
+	return bins, max(columns/max(len(bins), 1), 1)
+}
+
+// histogramIndex returns the selected bin, the newest unless one was picked
+func (m Model) histogramIndex(bins []histogram.Bin) int {
+	if m.histCursor < 0 || m.histCursor >= len(bins) {
+		return len(bins) - 1
+	}
+	return m.histCursor
+}
+
+// handleHistogramKey handles keys on the histogram screen. It returns
+// false for keys it does not use.
+func (m *Model) handleHistogramKey(msg tea.KeyMsg) bool {
+	bins, _ := m.histogramBins()
+	cursor := m.histogramIndex(bins)
+
+	switch m.keys.action("histogram", msg.String()) {
+	case "left":
+		m.histCursor = max(cursor-1, 0)
+	case "right":
+		m.histCursor = min(cursor+1, len(bins)-1)
+	case "page_up":
+		m.histCursor = max(cursor-10, 0)
+	case "page_down":
+		m.histCursor = min(cursor+10, len(bins)-1)
+	case "top":
+		m.histCursor = 0
+	case "bottom":
+		m.histCursor = -1
+	case "select":
+		if cursor >= 0 {
+			m.setWindow(timeWindow{from: bins[cursor].From, to: bins[cursor].To})
+			m.showHistogram = false
+		}
+	case "clear_window":
+		m.setWindow(timeWindow{})
+	case "histogram", "reset":
+		// The key that opened the screen closes it, as does reset
+		m.showHistogram = false
+	default:
+		return false
+	}
+	return true
+}
+
+// setWindow narrows the list to a time window, or shows every time again
+// with a zero window
+func (m *Model) setWindow(w timeWindow) {
+	m.window = w
+	m.rebuild()
+}
+
+// clickHistogram picks the bin in a screen column
+func (m *Model) clickHistogram(x int) {
+	bins, binWidth := m.histogramBins()
+	if x < histogramLabelWidth {
+		return
+	}
+	i := (x - histogramLabelWidth) / binWidth
+	if i >= len(bins) {
+		return
+	}
+	m.histCursor = i
+	m.setWindow(timeWindow{from: bins[i].From, to: bins[i].To})
+	m.showHistogram = false
+}
+
+// renderHistogram renders the histogram screen at the list size: a bar
+// chart of all entries, a sparkline per level, a time axis and the counts
+// of the selected bin
+func (m Model) renderHistogram() string {
+	bins, binWidth := m.histogramBins()
+	if len(bins) == 0 {
+		lines := []string{foldedBodyStyle.Render(" No entries yet")}
+		for len(lines) < m.listHeight {
+			lines = append(lines, "")
+		}
+		return strings.Join(lines, "\n")
+	}
+	cursor := m.histogramIndex(bins)
+
+	var levels []parser.Level
+	for i := len(parser.Levels) - 1; i >= 0; i-- {
+		l := parser.Levels[i]
+		for _, b := range bins {
+			if b.Counts[l] > 0 {
+				levels = append(levels, l)
+				break
+			}
+		}
+	}
+
+	// The chart of all entries takes the height left by the sparklines,
+	// the axis and the summary
+	chartHeight := max(m.listHeight-len(levels)-3, 1)
+	total := func(b histogram.Bin) int { return b.Counts.Total() }
+
+	var lines []string
+	lines = append(lines, renderBars(bins, total, chartHeight, binWidth, cursor, "ALL", histogramAllStyle)...)
+	for _, l := range levels {
+		count := func(b histogram.Bin) int { return b.Counts[l] }
+		lines = append(lines, renderBars(bins, count, 1, binWidth, cursor, l.Badge(), l.Style())...)
+	}
+
+	// Time axis: the start, the end and the selected bin, where they fit
+	sel := bins[cursor]
+	layout := axisLayout(bins[0].From, bins[len(bins)-1].To)
+	axis := []rune(strings.Repeat(" ", histogramLabelWidth+len(bins)*binWidth))
+	used := make([]bool, len(axis))
+	place := func(col int, t time.Time) {
+		label := []rune(t.Local().Format(layout))
+		col = min(max(col, 0), len(axis)-len(label))
+		if col < 0 {
+			return
+		}
+		for i := max(col-1, 0); i < min(col+len(label)+1, len(axis)); i++ {
+			if used[i] {
+				return
+			}
+		}
+		copy(axis[col:], label)
+		for i := col; i < col+len(label); i++ {
+			used[i] = true
+		}
+	}
+	place(histogramLabelWidth+cursor*binWidth, sel.From)
+	place(histogramLabelWidth, bins[0].From)
+	place(len(axis)-len(layout), bins[len(bins)-1].To)
+	lines = append(lines, timeStyle.Render(string(axis)))
+
+	// Counts in the selected bin
+	var counts []string
+	for _, l := range levels {
+		if n := sel.Counts[l]; n > 0 {
+			counts = append(counts, l.Badge()+" "+fmt.Sprint(n))
+		}
+	}
+	summary := fmt.Sprintf(" %s → %s  %d entries  %s",
+		sel.From.Local().Format(layout), sel.To.Local().Format(layout),
+		sel.Counts.Total(), strings.Join(counts, "  "))
+	lines = append(lines, "", summary)
+
+	for len(lines) < m.listHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:m.listHeight], "\n")
+}
+
+// renderBars renders one value per bin as bars height lines tall and
+// binWidth columns wide, scaled to the largest value, with the selected bin
+// highlighted
+func renderBars(bins []histogram.Bin, value func(histogram.Bin) int, height, binWidth, cursor int, label string, style lipgloss.Style) []string {
+	peak := 1
+	for _, b := range bins {
+		peak = max(peak, value(b))
+	}
+
+	lines := make([]string, height)
+	for row := range lines {
+		var b strings.Builder
+		if row == height-1 {
+			b.WriteString(label + strings.Repeat(" ", max(histogramLabelWidth-lipgloss.Width(label), 0)))
+		} else {
+			b.WriteString(strings.Repeat(" ", histogramLabelWidth))
+		}
+
+		// Eighths of a line filled in this row, counting from the bottom
+		var bars strings.Builder
+		for i, bin := range bins {
+			v := value(bin)
+			eighths := (v*height*8 + peak - 1) / peak
+			fill := min(max(eighths-(height-1-row)*8, 0), 8)
+			if v > 0 && row == height-1 {
+				fill = max(fill, 1)
+			}
+			bar := strings.Repeat(string(sparks[fill]), binWidth)
+			if i == cursor {
+				b.WriteString(style.Render(bars.String()))
+				bars.Reset()
+				b.WriteString(style.Reverse(true).Render(bar))
+				continue
+			}
+			bars.WriteString(bar)
+		}
+		b.WriteString(style.Render(bars.String()))
+		lines[row] = b.String()
+	}
+	return lines
+}
+
+// axisLayout picks a time format precise enough for the span shown
+func axisLayout(from, to time.Time) string {
+	switch span := to.Sub(from); {
+	case span < 24*time.Hour:
+		return "15:04:05"
+	case span < 365*24*time.Hour:
+		return "Jan _2 15:04"
+	}
+	return "2006-01-02"
+}
+
+// windowString describes the window picked in the histogram, if any
+func (m Model) windowString() string {
+	if m.window.isZero() {
+		return ""
+	}
+	layout := axisLayout(m.window.from, m.window.to)
+	return "Window " + m.window.from.Local().Format(layout) + " → " + m.window.to.Local().Format(layout)
+}
+
+// formatRate renders the ingest rate for the status bar
+func formatRate(perSecond float64) string {
+	if perSecond < 10 {
+		return fmt.Sprintf("%.1f/s", perSecond)
+	}
+	return fmt.Sprintf("%.0f/s", perSecond)
+}
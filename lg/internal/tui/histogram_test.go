@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// histogramEntries are two bursts two hours apart, with a line without a
+// timestamp that goes with the entry before it
+var histogramEntries = []string{
+	`{"time":"2025-01-01T10:00:00Z","msg":"a"}`,
+	`plain line after a`,
+	`{"time":"2025-01-01T10:00:30Z","level":"error","msg":"b"}`,
+	`{"time":"2025-01-01T12:00:00Z","msg":"c"}`,
+	`{"time":"2025-01-01T12:00:10Z","msg":"d"}`,
+}
+
+func TestHistogram_SelectWindow(t *testing.T) {
+	m := press(newTestModel(histogramEntries...), "H")
+	if !m.showHistogram {
+		t.Fatal("H did not open the histogram")
+	}
+
+	// The newest bin is selected first
+	if m = press(m, "enter"); m.showHistogram || !slices.Equal(m.visible, []uint64{3, 4}) {
+		t.Errorf("histogram shown = %v, listed = %v; want the list with [3 4]", m.showHistogram, m.visible)
+	}
+	if s := m.windowString(); !strings.HasPrefix(s, "Window ") || !strings.Contains(s, " → ") {
+		t.Errorf("windowString() = %q", s)
+	}
+
+	// The oldest bin holds the first burst, untimed line included
+	m = press(m, "H", "g", "enter")
+	if !slices.Equal(m.visible, []uint64{0, 1, 2}) {
+		t.Errorf("listed = %v, want [0 1 2]", m.visible)
+	}
+	for seq, want := range map[uint64]bool{0: true, 1: true, 3: false} {
+		if got := m.inWindow(seq, m.buffer.GetSeq(seq)); got != want {
+			t.Errorf("inWindow(%d) = %v, want %v", seq, got, want)
+		}
+	}
+
+	// Entries arriving outside the window are not listed, and the window
+	// applies together with the filter
+	var tm tea.Model = m
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(`{"time":"2025-01-01T12:00:20Z","msg":"e"}`)))
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(`{"time":"2025-01-01T10:00:40Z","msg":"late"}`)))
+	m = tm.(Model)
+	if !slices.Equal(m.visible, []uint64{0, 1, 2, 6}) {
+		t.Errorf("listed = %v after new entries, want [0 1 2 6]", m.visible)
+	}
+	if m = press(m, "/", "level=error", "enter"); !slices.Equal(m.visible, []uint64{2}) {
+		t.Errorf("listed = %v with a filter, want [2]", m.visible)
+	}
+}
+
+func TestHistogram_ClearWindow(t *testing.T) {
+	m := press(newTestModel(histogramEntries...), "H", "h", "l", "enter")
+	if !slices.Equal(m.visible, []uint64{3, 4}) {
+		t.Fatalf("listed = %v, want [3 4]", m.visible)
+	}
+
+	// x clears the window and stays on the histogram
+	m = press(m, "H", "x")
+	if !m.showHistogram || m.windowString() != "" || len(m.visible) != 5 {
+		t.Errorf("histogram shown = %v, window = %q, listed = %d; want the histogram, no window, 5", m.showHistogram, m.windowString(), len(m.visible))
+	}
+	for _, key := range []string{"H", "esc"} {
+		if m = press(m, key); m.showHistogram {
+			t.Errorf("%s did not close the histogram", key)
+		}
+		m = press(m, "H")
+	}
+}
@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Actions of the list that keys can be bound to, with their default keys,
+// in the order they are documented
+var defaultKeys = []struct {
+	action string
+	keys   []string
+}{
+	{"down", []string{"j", "down"}},
+	{"up", []string{"k", "up"}},
+	{"top", []string{"g", "home"}},
+	{"bottom", []string{"G", "end"}},
+	{"page_down", []string{"ctrl+d", "pgdown"}},
+	{"page_up", []string{"ctrl+u", "pgup"}},
+	{"expand", []string{"enter"}},
+	{"search", []string{"/"}},
+	{"find", []string{"f"}},
+	{"next_match", []string{"n"}},
+	{"prev_match", []string{"N"}},
+	{"context", []string{"C"}},
+	{"table", []string{"t"}},
+	{"detail", []string{"d"}},
+	{"facets", []string{"F"}},
+	{"focus", []string{"tab"}},
+	{"histogram", []string{"H"}},
+	{"group", []string{"r"}},
+	{"groups", []string{"I"}},
+	{"dedup", []string{"u"}},
+	{"patterns", []string{"P"}},
+	{"views", []string{"V"}},
+	{"copy", []string{"y"}},
+	{"save", []string{"s"}},
+	{"level_up", []string{"+", "="}},
+	{"level_down", []string{"-"}},
+	{"time", []string{"T"}},
+	{"sort_keys", []string{"K"}},
+	{"redact", []string{"X"}},
+	{"jump", []string{"@"}},
+	{"restart", []string{"R"}},
+	{"pause", []string{"p"}},
+	{"clear", []string{"c"}},
+	{"reset", []string{"esc"}},
+	{"quit", []string{"q", "ctrl+c"}},
+}
+
+// Actions of the detail pane, the facets sidebar and the screens that
+// replace the list, with the screens they work on and their default keys.
+// Moving around on them, going back to the list and closing them follow
+// the list's keys (down, up, top, bottom, page_down, page_up, focus and
+// reset).
+var screenKeys = []struct {
+	action  string
+	screens []string
+	keys    []string
+}{
+	{"select", []string{"histogram", "groups", "patterns", "views"}, []string{"enter"}},
+	{"fold", []string{"detail"}, []string{"enter", " "}},
+	{"left", []string{"detail", "facets", "histogram"}, []string{"h", "left"}},
+	{"right", []string{"detail", "facets", "histogram"}, []string{"l", "right"}},
+	{"expand_all", []string{"detail"}, []string{"E"}},
+	{"include", []string{"facets"}, []string{"enter", " ", "="}},
+	{"exclude", []string{"facets"}, []string{"x", "!"}},
+	{"clear_window", []string{"histogram"}, []string{"x"}},
+}
+
+// KeyMap maps keys to actions, on the list (screen "") and on each screen
+// of screenKeys, so a key can do one thing on the list and another on a
+// screen
+type KeyMap map[string]map[string]string
+
+// ParseKeys builds the key map from the keys bound to actions in the
+// configuration file, which replace the action's default keys. A key bound
+// to one action is taken from any other on the same screen.
+func ParseKeys(custom map[string][]string) (KeyMap, error) {
+	screens := make(map[string][]string, len(defaultKeys)+len(screenKeys))
+	for _, d := range defaultKeys {
+		screens[d.action] = []string{""}
+	}
+	for _, d := range screenKeys {
+		screens[d.action] = d.screens
+	}
+	for action, keys := range custom {
+		if _, ok := screens[action]; !ok {
+			return nil, fmt.Errorf("unknown key action %q", action)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no keys for action %q", action)
+		}
+	}
+
+	km := make(KeyMap)
+	bind := func(action, key string) {
+		for _, screen := range screens[action] {
+			if km[screen] == nil {
+				km[screen] = make(map[string]string)
+			}
+			km[screen][key] = action
+		}
+	}
+	for _, d := range defaultKeys {
+		if _, ok := custom[d.action]; !ok {
+			for _, k := range d.keys {
+				bind(d.action, k)
+			}
+		}
+	}
+	for _, d := range screenKeys {
+		if _, ok := custom[d.action]; !ok {
+			for _, k := range d.keys {
+				bind(d.action, k)
+			}
+		}
+	}
+	for action, keys := range custom {
+		for _, k := range keys {
+			bind(action, normalizeKey(k))
+		}
+	}
+	return km, nil
+}
+
+// normalizeKey converts a key name from the configuration file to its
+// name in key messages
+func normalizeKey(k string) string {
+	switch strings.ToLower(k) {
+	case "space":
+		return " "
+	case "escape":
+		return "esc"
+	case "return":
+		return "enter"
+	}
+	return k
+}
+
+// action returns the action of a key on a screen, or else its action on
+// the list
+func (km KeyMap) action(screen, key string) string {
+	if a, ok := km[screen][key]; ok {
+		return a
+	}
+	return km[""][key]
+}
+
+// keys returns the keys bound to an action on a screen, for the help bar.
+// Default keys come first, in their usual order.
+func (km KeyMap) keys(screen, action string) []string {
+	var keys []string
+	add := func(defaults []string) {
+		for _, k := range defaults {
+			if km.action(screen, k) == action && !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	for _, d := range defaultKeys {
+		if d.action == action {
+			add(d.keys)
+		}
+	}
+	for _, d := range screenKeys {
+		if d.action == action {
+			add(d.keys)
+		}
+	}
+
+	var custom []string
+	for _, bindings := range []map[string]string{km[screen], km[""]} {
+		for k, a := range bindings {
+			if a == action && km.action(screen, k) == action && !slices.Contains(keys, k) && !slices.Contains(custom, k) {
+				custom = append(custom, k)
+			}
+		}
+	}
+	sort.Strings(custom)
+	return append(keys, custom...)
+}
+
+// key returns the first key bound to an action on a screen as shown in the
+// help bar, or "" when there is none
+func (km KeyMap) key(screen, action string) string {
+	keys := km.keys(screen, action)
+	if len(keys) == 0 {
+		return ""
+	}
+	if keys[0] == " " {
+		return "space"
+	}
+	return keys[0]
+}
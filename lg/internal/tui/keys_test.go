@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/buffer"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+func TestParseKeys_Screens(t *testing.T) {
+	defaults, err := ParseKeys(nil)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	custom, err := ParseKeys(map[string][]string{"left": {"a"}, "down": {"n"}, "clear_window": {"space"}})
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+
+	tests := []struct {
+		keys   KeyMap
+		screen string
+		key    string
+		want   string
+	}{
+		{defaults, "", "enter", "expand"},
+		{defaults, "", "x", ""},
+		{defaults, "detail", "enter", "fold"},
+		{defaults, "detail", "h", "left"},
+		{defaults, "detail", "j", "down"}, // Moving follows the list
+		{defaults, "detail", "tab", "focus"},
+		{defaults, "facets", "x", "exclude"},
+		{defaults, "histogram", "x", "clear_window"},
+		{defaults, "histogram", "H", "histogram"},
+		{defaults, "groups", "enter", "select"},
+		{defaults, "groups", "esc", "reset"},
+		{custom, "detail", "a", "left"},
+		{custom, "facets", "a", "left"},
+		{custom, "detail", "h", ""},
+		{custom, "detail", "n", "down"},
+		{custom, "detail", "j", ""},
+		{custom, "histogram", " ", "clear_window"},
+		{custom, "histogram", "x", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.keys.action(tt.screen, tt.key); got != tt.want {
+			t.Errorf("action(%q, %q) = %q, want %q", tt.screen, tt.key, got, tt.want)
+		}
+	}
+
+	if _, err := ParseKeys(map[string][]string{"unfold": {"u"}}); err == nil {
+		t.Error("ParseKeys() with an unknown action should fail")
+	}
+}
+
+func TestScreenKeys_Remapped(t *testing.T) {
+	keys, err := ParseKeys(map[string][]string{"fold": {"z"}, "down": {"n"}, "focus": {"o"}})
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	var tm tea.Model = New(buffer.New(10), Options{Keys: keys})
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(treeEntry)))
+
+	m := press(tm.(Model), "d", "o", "n", "n", "n", "z")
+	if m.focus != focusDetail || m.detailCursor != 3 || !m.collapsed["user"] {
+		t.Errorf("focus = %v, cursor = %d, collapsed = %v; want the detail pane, 3, user folded", m.focus, m.detailCursor, m.collapsed)
+	}
+	help := ansi.Strip(m.renderHelp())
+	for _, want := range []string{"n/k: move", "z: fold", "o: back to list"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help = %q, want %q", help, want)
+		}
+	}
+	if m = press(m, "o"); m.focus != focusList {
+		t.Errorf("focus = %v, want the list back", m.focus)
+	}
+}
+
+func TestRenderHelp_FitsWidth(t *testing.T) {
+	var tm tea.Model = newTestModel(treeEntry)
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 40, Height: 30})
+
+	for _, keys := range [][]string{nil, {"d", "tab"}, {"F"}, {"H"}, {"I"}, {"P"}} {
+		m := press(tm.(Model), keys...)
+		if w := ansi.StringWidth(m.renderHelp()); w > 40 {
+			t.Errorf("help after %v is %d wide, want at most 40: %q", keys, w, ansi.Strip(m.renderHelp()))
+		}
+	}
+}
@@ -0,0 +1,533 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/pattern"
+	"github.com/thalessoares/lg/internal/table"
+)
+
+// The entry list is virtualized: the model keeps the sequence numbers of
+// the entries that pass the filters, and only the entries on screen are
+// read from the buffer and rendered. The scroll position is an entry index
+// plus a line offset inside that entry, since entries span several lines in
+// the pretty view.
+
+// row is an entry that matches the query and the time range. Its level and
+// source are kept so the level threshold and the source toggles can change
+// without reading the buffer again.
+type row struct {
+	seq     uint64
+	level   parser.Level
+	source  string
+	dedup   uint64 // pattern.Key, only set in dedup mode
+	context bool   // Shown around a query match, without matching
+}
+
+// listLine is one rendered line of the list and the entry it belongs to
+type listLine struct {
+	entry int // Index in visible, -1 for padding
+	text  string
+}
+
+// sync brings the filtered entries up to date with the buffer: entries that
+// left it are dropped and only entries added since the last call are
+// matched against the filters
+func (m *Model) sync() {
+	first := m.buffer.First()
+
+	if n := sort.Search(len(m.rows), func(i int) bool { return m.rows[i].seq >= first }); n > 0 {
+		for _, r := range m.rows[:n] {
+			if r.context {
+				delete(m.contextRows, r.seq)
+			} else {
+				m.levelCounts[r.level]--
+			}
+		}
+		m.rows = m.rows[n:]
+	}
+	if n := sort.Search(len(m.visible), func(i int) bool { return m.visible[i] >= first }); n > 0 {
+		for _, seq := range m.visible[:n] {
+			delete(m.repeats, seq)
+		}
+		m.visible = m.visible[n:]
+		m.cursor = max(m.cursor-n, 0)
+		if m.top -= n; m.top < 0 {
+			m.top, m.topLine = 0, 0
+		}
+	}
+
+	m.scanned = m.buffer.Scan(m.scanned, func(seq uint64, e *parser.LogEntry) bool {
+		if !m.inScope(seq, e) {
+			return true
+		}
+		r := row{seq: seq, level: e.Level, source: e.Source}
+		if m.dedup {
+			r.dedup = pattern.Key(e)
+		}
+
+		// Entries around a match are kept as context, like grep -C
+		switch {
+		case m.query == nil || m.query.Match(e):
+			for _, c := range m.contextBefore {
+				m.appendRow(c)
+			}
+			m.contextBefore = m.contextBefore[:0]
+			m.contextAfter = m.contextSize
+		case m.contextAfter > 0:
+			m.contextAfter--
+			r.context = true
+		case m.contextSize > 0:
+			r.context = true
+			if len(m.contextBefore) == m.contextSize {
+				m.contextBefore = append(m.contextBefore[:0], m.contextBefore[1:]...)
+			}
+			m.contextBefore = append(m.contextBefore, r)
+			return true
+		default:
+			return true
+		}
+		m.appendRow(r)
+		return true
+	})
+	m.totalEntries = m.buffer.Len()
+	m.cursor = min(m.cursor, max(len(m.visible)-1, 0))
+}
+
+// appendRow adds a row that passes the filters, or is context around one
+// that does
+func (m *Model) appendRow(r row) {
+	m.rows = append(m.rows, r)
+	if r.context {
+		m.contextRows[r.seq] = true
+	} else {
+		m.levelCounts[r.level]++
+	}
+	if m.rowVisible(r) {
+		m.appendVisible(r)
+	}
+}
+
+// rebuild filters the whole buffer again after the query or time range
+// changed, keeping the selection on the same entry or the next one
+func (m *Model) rebuild() {
+	selected, hadSelection := m.selectedSeq()
+	m.rows = nil
+	m.visible = nil
+	m.repeats = make(map[uint64]int)
+	m.levelCounts = make(map[parser.Level]int)
+	m.contextRows = make(map[uint64]bool)
+	m.contextBefore = nil
+	m.contextAfter = 0
+	m.scanned = 0
+	m.sync()
+	m.restoreSelection(selected, hadSelection)
+}
+
+// applyLevel recomputes the visible entries from the filtered rows after the
+// level threshold or the source toggles changed
+func (m *Model) applyLevel() {
+	selected, hadSelection := m.selectedSeq()
+	m.visible = m.visible[:0]
+	m.repeats = make(map[uint64]int)
+	for _, r := range m.rows {
+		if m.rowVisible(r) {
+			m.appendVisible(r)
+		}
+	}
+	m.restoreSelection(selected, hadSelection)
+}
+
+// appendVisible adds a row to the visible entries. In dedup mode, a row
+// repeating the last one takes its place, with the count of the run.
+func (m *Model) appendVisible(r row) {
+	if m.dedup && len(m.visible) > 0 && r.dedup == m.lastDedup {
+		last := m.visible[len(m.visible)-1]
+		m.repeats[r.seq] = max(m.repeats[last], 1) + 1
+		delete(m.repeats, last)
+		m.visible[len(m.visible)-1] = r.seq
+		return
+	}
+	m.visible = append(m.visible, r.seq)
+	m.lastDedup = r.dedup
+}
+
+// restoreSelection moves the cursor to the entry with sequence number seq,
+// or the closest one after it
+func (m *Model) restoreSelection(seq uint64, ok bool) {
+	if ok {
+		m.cursor = sort.Search(len(m.visible), func(i int) bool { return m.visible[i] >= seq })
+	}
+	m.cursor = min(m.cursor, max(len(m.visible)-1, 0))
+	m.top, m.topLine = m.cursor, 0
+	if m.autoScroll {
+		m.followTail()
+	} else {
+		m.scrollToCursor()
+	}
+	m.refresh()
+}
+
+// inScope reports whether an entry passes the time range, the histogram
+// window, the correlation group and the pattern. Of those, only the ones
+// matching the query are listed, with the context around them.
+func (m Model) inScope(seq uint64, e *parser.LogEntry) bool {
+	return m.inTimeRange(e) && m.inWindow(seq, e) && m.inGroup(e) && m.inPattern(e)
+}
+
+// rowVisible reports whether a row passes the level threshold and its
+// source is not hidden. Entries without a level are hidden once a threshold
+// is set.
+func (m Model) rowVisible(r row) bool {
+	if m.hiddenSources[r.source] {
+		return false
+	}
+	return m.minLevel == parser.LevelUnknown || r.level >= m.minLevel
+}
+
+// entry returns the visible entry at index i, masked when redacting, or nil
+// once it has left the buffer while paused
+func (m Model) entry(i int) *parser.LogEntry {
+	if i < 0 || i >= len(m.visible) {
+		return nil
+	}
+	return m.redact(m.buffer.GetSeq(m.visible[i]))
+}
+
+// selectedSeq returns the sequence number of the entry under the cursor
+func (m Model) selectedSeq() (uint64, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return 0, false
+	}
+	return m.visible[m.cursor], true
+}
+
+// selectedEntry returns the entry under the cursor, if any
+func (m Model) selectedEntry() *parser.LogEntry {
+	return m.entry(m.cursor)
+}
+
+// refresh updates what depends on the entries around the scroll position:
+// the table column widths and the detail pane
+func (m *Model) refresh() {
+	if m.tableMode {
+		// Size the columns from the entries around the screen, so widths stay
+		// stable while scrolling without reading the whole history
+		from := max(m.top-m.listHeight, 0)
+		to := min(m.top+2*m.listHeight, len(m.visible))
+		window := make([]*parser.LogEntry, 0, to-from)
+		for i := from; i < to; i++ {
+			if e := m.entry(i); e != nil {
+				window = append(window, e)
+			}
+		}
+		m.layout = table.NewLayout(m.columns, window, m.rowWidth(), m.timeMode.formatter(time.Now()))
+	}
+	m.updateDetail()
+}
+
+// rowWidth is the width left for entry text after the gutter, the level
+// badge, the source tag, the repeat count and, in the pretty view, the time
+// column
+func (m Model) rowWidth() int {
+	return m.listWidth() - lipgloss.Width(cursorGutter) - badgeWidth - len(m.sourcePadding()) - m.repeatWidth() - m.timeWidth()
+}
+
+// repeatWidth is the width of the repeat count column, only shown in dedup
+// mode
+func (m Model) repeatWidth() int {
+	if !m.dedup {
+		return 0
+	}
+	return repeatColumn + 1
+}
+
+// repeatString renders a repeat count within repeatColumn, e.g. ×12 or ×40k
+func repeatString(n int) string {
+	if n >= 10000 {
+		return fmt.Sprintf("×%dk", min(n/1000, 999))
+	}
+	return fmt.Sprintf("×%d", n)
+}
+
+// sourcePadding is the blank space taken by the source tag column, which is
+// only shown once entries come from several inputs
+func (m Model) sourcePadding() string {
+	if m.sourceWidth == 0 {
+		return ""
+	}
+	return strings.Repeat(" ", m.sourceWidth+1)
+}
+
+// sourceTag renders an entry's source at the width of the column
+func (m Model) sourceTag(entry *parser.LogEntry) string {
+	if m.sourceWidth == 0 {
+		return ""
+	}
+	gap := strings.Repeat(" ", m.sourceWidth-lipgloss.Width(entry.Source)+1)
+	return parser.SourceStyle(entry.Source).Render(entry.Source) + gap
+}
+
+// timeWidth is the width of the time column shown in the pretty view
+func (m Model) timeWidth() int {
+	if m.tableMode || m.timeMode == timeRaw {
+		return 0
+	}
+	return m.timeMode.width() + 1
+}
+
+// renderEntry renders a single entry for the list. Entries expanded with
+// enter show their full pretty view, including any stack trace.
+func (m Model) renderEntry(seq uint64, entry *parser.LogEntry) string {
+	if entry == nil {
+		return foldedBodyStyle.Render("(no longer in history)")
+	}
+
+	expanded := m.expanded[seq]
+	if m.tableMode && !expanded {
+		return m.layout.Row(entry)
+	}
+
+	formatted := entry.Formatted
+	if m.sortKeys {
+		formatted = entry.FormattedSorted()
+	}
+	if len(entry.Continuation) == 0 {
+		return formatted
+	}
+	if expanded {
+		return formatted + "\n" + entry.FormattedBody()
+	}
+	return formatted + "\n" + foldedBodyStyle.Render(
+		fmt.Sprintf("▸ %d more lines (enter to expand)", len(entry.Continuation)))
+}
+
+// entryLines renders the visible entry at index i as list lines: the
+// cursor gutter, the level badge and time column on the first line, and a
+// separator after it in the pretty view
+func (m Model) entryLines(i int, formatTime func(time.Time) string) []string {
+	entry := m.entry(i)
+	block := strings.Split(m.renderEntry(m.visible[i], entry), "\n")
+
+	gutter := emptyGutter
+	if i == m.cursor {
+		gutter = cursorGutter
+	}
+	timeWidth := m.timeWidth()
+
+	lines := make([]string, 0, len(block)+1)
+	for j, l := range block {
+		var b strings.Builder
+		b.WriteString(gutter)
+		if j == 0 && entry != nil {
+			b.WriteString(entry.Level.Badge() + " ")
+			b.WriteString(m.sourceTag(entry))
+			if m.dedup {
+				count := ""
+				if n := m.repeats[m.visible[i]]; n > 1 {
+					count = repeatString(n)
+				}
+				b.WriteString(repeatStyle.Render(count) + strings.Repeat(" ", m.repeatWidth()-lipgloss.Width(count)))
+			}
+			if timeWidth > 0 {
+				b.WriteString(timePrefix(entry.Time, m.timeMode, formatTime))
+			}
+		} else {
+			b.WriteString(badgePadding)
+			b.WriteString(m.sourcePadding())
+			b.WriteString(strings.Repeat(" ", m.repeatWidth()))
+			b.WriteString(strings.Repeat(" ", timeWidth))
+		}
+		l = m.highlight(entry, l)
+		if m.contextRows[m.visible[i]] {
+			l = contextStyle.Render(ansi.Strip(l))
+		}
+		b.WriteString(m.highlightFind(l))
+		lines = append(lines, b.String())
+	}
+
+	if !m.tableMode && i < len(m.visible)-1 {
+		lines = append(lines, emptyGutter+separatorStyle.Render(strings.Repeat("─", max(m.rowWidth()+badgeWidth+len(m.sourcePadding())+m.repeatWidth()+timeWidth, 0))))
+	}
+	return lines
+}
+
+// entryHeight is the number of list lines the visible entry at index i takes
+func (m Model) entryHeight(i int) int {
+	h := strings.Count(m.renderEntry(m.visible[i], m.entry(i)), "\n") + 1
+	if !m.tableMode && i < len(m.visible)-1 {
+		h++ // Separator
+	}
+	return h
+}
+
+// screenLines renders the lines that fit in the list, starting at the
+// scroll position
+func (m Model) screenLines() []listLine {
+	lines := make([]listLine, 0, m.listHeight)
+	formatTime := m.timeMode.formatter(time.Now())
+	width := m.listWidth()
+
+	skip := m.topLine
+	for i := m.top; i < len(m.visible) && len(lines) < m.listHeight; i++ {
+		for _, l := range m.entryLines(i, formatTime) {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(lines) == m.listHeight {
+				break
+			}
+			lines = append(lines, listLine{entry: i, text: ansi.Truncate(l, width, "")})
+		}
+	}
+	for len(lines) < m.listHeight {
+		lines = append(lines, listLine{entry: -1})
+	}
+	return lines
+}
+
+// renderList renders the list at its exact size, padded to the list width
+func (m Model) renderList() string {
+	lines := m.screenLines()
+	width := m.listWidth()
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(l.text)
+		if gap := width - lipgloss.Width(l.text); gap > 0 {
+			b.WriteString(strings.Repeat(" ", gap))
+		}
+	}
+	return b.String()
+}
+
+// entryAtRow returns the index of the entry shown on a list row
+func (m Model) entryAtRow(row int) int {
+	lines := m.screenLines()
+	if row < 0 || row >= len(lines) {
+		return -1
+	}
+	return lines[row].entry
+}
+
+// visibleEntries counts the entries on screen, for paging
+func (m Model) visibleEntries() int {
+	n, last := 0, -1
+	for _, l := range m.screenLines() {
+		if l.entry >= 0 && l.entry != last {
+			n++
+			last = l.entry
+		}
+	}
+	return n
+}
+
+// moveCursor moves the selection by delta entries and keeps it on screen
+func (m *Model) moveCursor(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.visible)-1)
+	m.autoScroll = m.cursor == len(m.visible)-1
+	m.scrollToCursor()
+	m.refresh()
+}
+
+// scrollToCursor scrolls the list the least needed to show the selected
+// entry. Entries taller than the screen are shown from their first line.
+func (m *Model) scrollToCursor() {
+	if len(m.visible) == 0 {
+		m.top, m.topLine = 0, 0
+		return
+	}
+	if m.cursor < m.top || (m.cursor == m.top && m.topLine > 0) {
+		m.top, m.topLine = m.cursor, 0
+		return
+	}
+
+	// Already fully visible?
+	lines := -m.topLine
+	for i := m.top; i <= m.cursor && lines <= m.listHeight; i++ {
+		lines += m.entryHeight(i)
+	}
+	if lines <= m.listHeight {
+		return
+	}
+	m.alignBottom(m.cursor)
+}
+
+// alignBottom scrolls so the entry at index i ends on the last list line,
+// or starts on the first one when it is taller than the list
+func (m *Model) alignBottom(i int) {
+	remaining := m.listHeight - m.entryHeight(i)
+	if remaining <= 0 {
+		m.top, m.topLine = i, 0
+		return
+	}
+	for i > 0 {
+		h := m.entryHeight(i - 1)
+		if h > remaining {
+			m.top, m.topLine = i-1, h-remaining
+			return
+		}
+		remaining -= h
+		i--
+	}
+	m.top, m.topLine = 0, 0
+}
+
+// scrollLines scrolls the list by delta lines without moving the cursor
+func (m *Model) scrollLines(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+
+	m.topLine += delta
+	for m.topLine < 0 && m.top > 0 {
+		m.top--
+		m.topLine += m.entryHeight(m.top)
+	}
+	m.topLine = max(m.topLine, 0)
+	for m.top < len(m.visible)-1 && m.topLine >= m.entryHeight(m.top) {
+		m.topLine -= m.entryHeight(m.top)
+		m.top++
+	}
+
+	// Stop at the bottom of the list
+	top, topLine := m.top, m.topLine
+	m.alignBottom(len(m.visible) - 1)
+	if top < m.top || (top == m.top && topLine < m.topLine) {
+		m.top, m.topLine = top, topLine
+		m.autoScroll = false
+	} else {
+		m.autoScroll = m.cursor == len(m.visible)-1
+	}
+	m.refresh()
+}
+
+// followTail keeps the newest entry selected and in view while auto-scroll
+// is on
+func (m *Model) followTail() {
+	if !m.autoScroll || len(m.visible) == 0 {
+		return
+	}
+	m.cursor = len(m.visible) - 1
+	m.alignBottom(m.cursor)
+}
+
+// scrollPercent is how far through the entries the cursor is
+func (m Model) scrollPercent() float64 {
+	if len(m.visible) <= 1 {
+		return 1
+	}
+	return float64(m.cursor) / float64(len(m.visible)-1)
+}
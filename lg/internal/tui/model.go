@@ -2,14 +2,28 @@ package tui
 
 import (
 	"fmt"
+	"io"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/thalessoares/lg/internal/buffer"
+	"github.com/thalessoares/lg/internal/config"
+	"github.com/thalessoares/lg/internal/correlate"
+	"github.com/thalessoares/lg/internal/facets"
+	"github.com/thalessoares/lg/internal/histogram"
 	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/pattern"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/redact"
+	"github.com/thalessoares/lg/internal/rules"
+	"github.com/thalessoares/lg/internal/table"
 )
 
 // Mode represents the current UI mode
@@ -18,41 +32,302 @@ type Mode int
 const (
 	ModeView Mode = iota
 	ModeSearch
+	ModeJump // Prompt for a time to jump to
+	ModeSave // Prompt for a file to save the entries to
+	ModeFind // Prompt for text to find without filtering
 )
 
 // LogMsg is sent when a new log entry is received
 type LogMsg *parser.LogEntry
 
+// LogBatchMsg delivers the entries received during a frame at once, see
+// Forward
+type LogBatchMsg []*parser.LogEntry
+
+// wheelLines is how far a mouse wheel step scrolls the list
+const wheelLines = 3
+
+// Options configures the initial state of the TUI
+type Options struct {
+	Columns   []string                   // Columns for the table view (defaults to table.DefaultColumns)
+	TableMode bool                       // Start in the one-line-per-entry table view
+	Filter    string                     // Initial search query
+	MinLevel  parser.Level               // Hide entries below this level
+	Since     time.Time                  // Hide entries older than this (zero for no bound)
+	Until     time.Time                  // Hide entries newer than this (zero for no bound)
+	Commands  []string                   // Commands started with `lg -- cmd` or -s, by the name in CommandMsg
+	Restart   func()                     // Restarts the commands (nil when there are none)
+	Progress  func() (read, total int64) // Bytes of the input files read so far (nil without files)
+	Listen    string                     // Address logs are received on with --listen
+	Dropped   func() uint64              // UDP lines the listener dropped for arriving too fast (nil without one)
+	GroupBy   string                     // Correlation key to group by (detected when empty)
+	Rules     *rules.Set                 // Highlight rules, already applied to the entries (nil for none)
+	Keys      KeyMap                     // Key bindings (nil for the defaults)
+	Views     []config.View              // Saved views, selectable with V
+	View      string                     // Name of the view the options come from, if any
+	Context   int                        // Entries shown before and after each filter match
+	SortKeys  bool                       // Show keys in alphabetical order instead of the order they were read
+	Redactor  *redact.Redactor           // Rules for masking sensitive values (the default preset when nil)
+	Redact    bool                       // Start with sensitive values masked
+	Output    io.Writer                  // The terminal the program draws on, for copying to the clipboard (nil to not copy)
+}
+
+// CommandMsg reports that a command started with `lg -- cmd` or -s started
+// or exited
+type CommandMsg struct {
+	Name    string // One of Options.Commands
+	Running bool
+	Err     error // Why the command exited, nil for success
+}
+
 // Model is the main TUI model
 type Model struct {
 	buffer       *buffer.Buffer
-	viewport     viewport.Model
 	searchInput  textinput.Model
 	mode         Mode
 	paused       bool
 	filter       string
+	query        *query.Query // Compiled filter
+	searchErr    error        // Compile error for the query being typed
 	width        int
 	height       int
 	ready        bool
 	autoScroll   bool
-	entries      []*parser.LogEntry // Filtered entries for display
-	totalEntries int                // Total entries in buffer
+	totalEntries int // Total entries in buffer
+	sourceWidth  int // Width of the longest source tag seen
+
+	// Inputs, see sources.go
+	commands      []commandStatus
+	restart       func()
+	progress      func() (read, total int64)
+	listen        string
+	listenDropped func() uint64
+	sources       []string        // Source names in order of first appearance
+	hiddenSources map[string]bool // Sources toggled off with the digit keys
+
+	// Highlight rules, see highlight.go
+	rules      *rules.Set
+	alertsSeen int // Alerts before the last clear
+
+	// Entry list, see list.go
+	rows        []row                // Entries matching the query and time range
+	visible     []uint64             // Sequence numbers of rows at or above minLevel, from shown sources
+	scanned     uint64               // Next buffer sequence number to match
+	minLevel    parser.Level         // Hide entries below this level (LevelUnknown shows all)
+	levelCounts map[parser.Level]int // Entries per level matching the filter
+	cursor      int                  // Index of the selected entry in visible
+	top         int                  // Index in visible of the first entry on screen
+	topLine     int                  // Lines of the top entry scrolled off screen
+	listTop     int                  // Screen row of the first list line
+	listHeight  int
+
+	// Timestamps
+	timeMode  timeMode
+	ticking   bool      // A relative time refresh is scheduled
+	since     time.Time // Entries with a timestamp outside since..until are hidden
+	until     time.Time
+	jumpInput textinput.Model
+	jumpErr   error
+
+	// Table view
+	tableMode bool
+	columns   []string
+	layout    table.Layout
+	expanded  map[uint64]bool // Rows expanded to the pretty view, by sequence number
+
+	// Detail pane
+	showDetail   bool
+	focus        focusArea
+	detail       viewport.Model
+	detailLines  []treeLine
+	detailCursor int
+	collapsed    map[string]bool // Collapsed tree paths, kept across entries
+
+	// Facets sidebar, see facets.go
+	showFacets   bool
+	facets       *facets.Index
+	facetScanned uint64          // Next buffer sequence number to count
+	facetOpen    map[string]bool // Keys whose values are listed
+	facetSel     facetLine       // Selected line
+	facetTop     int             // First line on screen
+
+	// Histogram screen, see histogram.go
+	showHistogram bool
+	histogram     *histogram.Histogram
+	histScanned   uint64         // Next buffer sequence number to count
+	histCursor    int            // Selected bin, -1 for the newest
+	timeMarks     []timeMark     // Times of entries without a timestamp, oldest first
+	untimed       []pendingEntry // Entries without a timestamp not placed yet
+	lastTime      time.Time      // Newest timestamp counted
+	rate          *histogram.Meter
+	window        timeWindow // Time window picked in the histogram
+
+	// Correlation groups, see groups.go
+	groups       *correlate.Index // nil until a correlation key is known
+	groupScanned uint64           // Next buffer sequence number to count
+	groupID      string           // Only show entries with this ID ("" for all)
+	showGroups   bool
+	groupSel     string // Selected ID on the groups screen
+	groupTop     int    // First group on screen
+
+	// Dedup and patterns, see patterns.go
+	dedup          bool
+	repeats        map[uint64]int // Entries folded into a visible entry, by its sequence number
+	lastDedup      uint64         // Dedup key of the last visible entry
+	patterns       *pattern.Index // nil until the patterns screen is opened
+	patternScanned uint64         // Next buffer sequence number to count
+	showPatterns   bool
+	patternSel     string // Selected template on the patterns screen
+	patternTop     int    // First template on screen
+	patternFilter  string // Only show entries with this template ("" for all)
+
+	// Key bindings, see keys.go
+	keys KeyMap
+
+	sortKeys bool // Show keys in alphabetical order
+
+	// Redaction, see redact.go
+	redactor  *redact.Redactor
+	redacting bool // Whether sensitive values are masked
+
+	// Context around filter matches, see list.go
+	contextSize   int
+	contextRows   map[uint64]bool // Rows listed as context, by sequence number
+	contextBefore []row           // Rows since the last one listed, at most contextSize
+	contextAfter  int             // Rows left to list after the last match
+
+	// Find mode, see find.go
+	findInput textinput.Model
+	findText  string
+	findRe    *regexp.Regexp // Highlights the text found (nil when not searching)
+	findQuery *query.Query   // Matches the entries containing it
+
+	// Saved views, see views.go
+	views          []config.View
+	showViews      bool
+	viewSel        int    // Selected view on the views screen
+	viewTop        int    // First view on screen
+	viewName       string // View applied, until the filter changes
+	defaultColumns []string
+
+	// Saving the entries to a file
+	saveInput     textinput.Model
+	saveFormatted bool   // Keep colors in text files
+	saveCount     int    // Entries to save, counted when the prompt opened
+	saveConfirm   string // Existing file the next enter overwrites
+	output        io.Writer
+	notice        string // Result of a copy or save, shown until the next key
 }
 
+// commandState is the state of a command started with `lg -- cmd` or -s
+type commandState int
+
+const (
+	cmdNone commandState = iota // Not started yet
+	cmdRunning
+	cmdExited
+)
+
+// focusArea is the pane that receives navigation keys
+type focusArea int
+
+const (
+	focusList focusArea = iota
+	focusDetail
+	focusFacets
+)
+
 // New creates a new Model
-func New(buf *buffer.Buffer) Model {
+func New(buf *buffer.Buffer, opts Options) Model {
 	ti := textinput.New()
-	ti.Placeholder = "Search..."
+	ti.Placeholder = `Search... (text, level=error, status>=500 && path~"^/api")`
 	ti.CharLimit = 256
 	ti.Width = 50
 
-	return Model{
-		buffer:     buf,
-		searchInput: ti,
-		mode:       ModeView,
-		paused:     false,
-		autoScroll: true,
+	ji := textinput.New()
+	ji.Placeholder = "Jump to time... (10:00:03, 2025-01-01T10:00:03Z, 5m)"
+	ji.CharLimit = 64
+	ji.Width = 50
+
+	fi := textinput.New()
+	fi.Placeholder = "text to highlight, n/N to move between matches"
+	fi.Prompt = ": "
+	fi.CharLimit = 256
+	fi.Width = 50
+
+	si := textinput.New()
+	si.Placeholder = "file.jsonl, file.csv, file.md or any other file for text"
+	si.Prompt = ": "
+	si.CharLimit = 1024
+	si.Width = 50
+
+	// Like buffer.Filter, an invalid expression is a substring search
+	q, err := query.Compile(opts.Filter)
+	if err != nil {
+		q = query.Text(opts.Filter)
+	}
+	ti.SetValue(opts.Filter)
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = table.DefaultColumns
+	}
+
+	m := Model{
+		buffer:        buf,
+		searchInput:   ti,
+		filter:        opts.Filter,
+		query:         q,
+		mode:          ModeView,
+		paused:        false,
+		autoScroll:    true,
+		tableMode:     opts.TableMode,
+		minLevel:      opts.MinLevel,
+		since:         opts.Since,
+		until:         opts.Until,
+		jumpInput:     ji,
+		columns:       columns,
+		expanded:      make(map[uint64]bool),
+		collapsed:     make(map[string]bool),
+		levelCounts:   make(map[parser.Level]int),
+		facets:        facets.New(),
+		facetOpen:     make(map[string]bool),
+		histogram:     histogram.New(histogram.DefaultBuckets),
+		histCursor:    -1,
+		rate:          histogram.NewMeter(rateWindow),
+		restart:       opts.Restart,
+		progress:      opts.Progress,
+		listen:        opts.Listen,
+		listenDropped: opts.Dropped,
+		hiddenSources: make(map[string]bool),
+		rules:         opts.Rules,
+		repeats:       make(map[uint64]int),
+		keys:          opts.Keys,
+		views:         opts.Views,
+		viewName:      opts.View,
+		saveInput:     si,
+		findInput:     fi,
+		contextSize:   opts.Context,
+		sortKeys:      opts.SortKeys,
+		redactor:      opts.Redactor,
+		redacting:     opts.Redact,
+		output:        opts.Output,
+		contextRows:   make(map[uint64]bool),
 	}
+	m.defaultColumns = m.columns
+	if m.keys == nil {
+		m.keys, _ = ParseKeys(nil)
+	}
+	if m.redactor == nil {
+		m.redactor = redact.Default()
+	}
+	if opts.GroupBy != "" {
+		m.groups = correlate.NewIndex(opts.GroupBy)
+	}
+	for _, name := range opts.Commands {
+		m.commands = append(m.commands, commandStatus{name: name})
+	}
+	return m
 }
 
 // Init implements tea.Model
@@ -62,8 +337,6 @@ func (m Model) Init() tea.Cmd {
 
 // Update implements tea.Model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
-
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
@@ -72,152 +345,678 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		headerHeight := 1 // Status bar
-		footerHeight := 2 // Help + search bar (when visible)
-
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-headerHeight-footerHeight)
-			m.viewport.YPosition = headerHeight
+			m.detail = viewport.New(0, 0)
 			m.ready = true
-		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - headerHeight - footerHeight
 		}
-		m.updateViewportContent()
+		m.resizeViewport()
+		m.sync()
+		m.followTail()
+		m.scrollToCursor()
+		m.refresh()
 
 	case LogMsg:
 		if msg != nil {
-			m.buffer.Add(msg)
-			if !m.paused {
-				m.updateViewportContent()
-				if m.autoScroll {
-					m.viewport.GotoBottom()
-				}
-			}
+			m.addEntries(msg)
+			return m, m.startTick()
 		}
-	}
 
-	// Update viewport
-	if m.mode == ModeView {
-		var vpCmd tea.Cmd
-		m.viewport, vpCmd = m.viewport.Update(msg)
-		cmds = append(cmds, vpCmd)
+	case LogBatchMsg:
+		m.addEntries(msg...)
+		return m, m.startTick()
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case CommandMsg:
+		m.setCommand(msg)
+
+	case exportMsg:
+		m.exportDone(msg)
+
+	case tickMsg:
+		m.syncHistogram(time.Time(msg))
+
+		// View renders relative times and the ingest rate from the current
+		// time, so keep redrawing while either changes
+		if m.timeMode != timeRelative && m.rate.Rate(time.Time(msg)) == 0 {
+			m.ticking = false
+			return m, nil
+		}
+		return m, tick()
 	}
 
-	return m, tea.Batch(cmds...)
+	return m, nil
+}
+
+// addEntries stores new entries and counts them for the facets and the
+// histogram. Unless paused, it also matches them against the filters and
+// follows the tail.
+func (m *Model) addEntries(entries ...*parser.LogEntry) {
+	now := time.Now()
+	for _, e := range entries {
+		m.buffer.Add(e)
+		m.sourceWidth = max(m.sourceWidth, lipgloss.Width(e.Source))
+		m.addSource(e.Source)
+	}
+	m.rate.Add(now, len(entries))
+	m.syncFacets()
+	m.syncHistogram(now)
+	m.syncGroups()
+	m.syncPatterns()
+	if m.paused || !m.ready {
+		return
+	}
+	m.sync()
+	m.followTail()
+	m.refresh()
 }
 
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.notice = ""
 	switch m.mode {
 	case ModeSearch:
 		return m.handleSearchMode(msg)
+	case ModeJump:
+		return m.handleJumpMode(msg)
+	case ModeSave:
+		return m.handleSaveMode(msg)
+	case ModeFind:
+		return m.handleFindMode(msg)
 	default:
 		return m.handleViewMode(msg)
 	}
 }
 
 func (m Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "ctrl+c":
+	if m.showDetail && m.focus == focusDetail && m.handleDetailKey(msg) {
+		return m, nil
+	}
+	if m.showFacets && m.focus == focusFacets && m.handleFacetsKey(msg) {
+		return m, nil
+	}
+	if m.showHistogram && m.handleHistogramKey(msg) {
+		return m, nil
+	}
+	if m.showGroups && m.handleGroupsKey(msg) {
+		return m, nil
+	}
+	if m.showPatterns && m.handlePatternsKey(msg) {
+		return m, nil
+	}
+	if m.showViews && m.handleViewsKey(msg) {
+		return m, nil
+	}
+
+	// Digits not bound to an action toggle sources
+	action := m.keys.action("", msg.String())
+	if k := msg.String(); action == "" && len(k) == 1 && k >= "1" && k <= "9" {
+		m.toggleSource(int(k[0] - '1'))
+		return m, nil
+	}
+
+	switch action {
+	case "quit":
 		return m, tea.Quit
 
-	case "/":
+	case "search":
 		m.mode = ModeSearch
 		m.searchInput.Focus()
 		return m, textinput.Blink
 
-	case "p":
+	case "restart":
+		if m.restart != nil {
+			m.restart()
+		}
+
+	case "pause":
 		m.paused = !m.paused
 		if !m.paused {
-			m.updateViewportContent()
-			if m.autoScroll {
-				m.viewport.GotoBottom()
+			m.sync()
+			m.followTail()
+			m.refresh()
+		}
+
+	case "table":
+		m.tableMode = !m.tableMode
+		m.resizeViewport()
+		m.followTail()
+		m.scrollToCursor()
+		m.refresh()
+
+	case "time":
+		m.timeMode = (m.timeMode + 1) % (timeRelative + 1)
+		m.scrollToCursor()
+		m.refresh()
+		if m.timeMode == timeRelative {
+			return m, m.startTick()
+		}
+
+	case "jump":
+		m.mode = ModeJump
+		m.jumpErr = nil
+		m.jumpInput.SetValue("")
+		m.jumpInput.Focus()
+		return m, textinput.Blink
+
+	case "copy":
+		return m, m.copyEntry()
+
+	case "find":
+		m.mode = ModeFind
+		m.findInput.SetValue(m.findText)
+		m.findInput.CursorEnd()
+		m.findInput.Focus()
+		return m, textinput.Blink
+
+	case "next_match":
+		m.findKey(true)
+
+	case "prev_match":
+		m.findKey(false)
+
+	case "context":
+		m.cycleContext()
+
+	case "save":
+		m.mode = ModeSave
+		m.saveCount = len(m.exportSeqs())
+		m.saveConfirm = ""
+		m.saveInput.Focus()
+		return m, textinput.Blink
+
+	case "detail":
+		m.showDetail = !m.showDetail
+		m.focus = focusList
+		m.resizeViewport()
+		m.scrollToCursor()
+		m.refresh()
+
+	case "histogram":
+		m.showHistogram = true
+		m.histCursor = -1
+
+	case "group":
+		m.toggleGroup()
+
+	case "groups":
+		m.showGroups = true
+
+	case "dedup":
+		m.toggleDedup()
+
+	case "sort_keys":
+		m.sortKeys = !m.sortKeys
+		m.scrollToCursor()
+		m.refresh()
+
+	case "redact":
+		m.toggleRedact()
+		m.scrollToCursor()
+		m.refresh()
+
+	case "patterns":
+		m.openPatterns()
+
+	case "views":
+		m.showViews = true
+
+	case "facets":
+		m.showFacets = !m.showFacets
+		m.focus = focusList
+		if m.showFacets {
+			m.focus = focusFacets
+		}
+		m.resizeViewport()
+		m.scrollToCursor()
+		m.refresh()
+
+	case "focus":
+		switch {
+		case m.showDetail:
+			m.focus = focusDetail
+		case m.showFacets:
+			m.focus = focusFacets
+		}
+
+	case "expand":
+		// Expands table rows to the pretty view and unfolds stack traces
+		seq, _ := m.selectedSeq()
+		if entry := m.selectedEntry(); entry != nil && (m.tableMode || len(entry.Continuation) > 0) {
+			m.expanded[seq] = !m.expanded[seq]
+			if !m.expanded[seq] {
+				delete(m.expanded, seq)
 			}
+			m.scrollToCursor()
+			m.refresh()
 		}
 
-	case "j", "down":
-		m.viewport.LineDown(1)
-		m.autoScroll = m.viewport.AtBottom()
+	case "down":
+		m.moveCursor(1)
 
-	case "k", "up":
-		m.viewport.LineUp(1)
-		m.autoScroll = false
+	case "up":
+		m.moveCursor(-1)
 
-	case "g":
-		m.viewport.GotoTop()
-		m.autoScroll = false
+	case "top":
+		m.moveCursor(-len(m.visible))
 
-	case "G":
-		m.viewport.GotoBottom()
-		m.autoScroll = true
+	case "bottom":
+		m.moveCursor(len(m.visible))
+
+	case "page_down":
+		m.moveCursor(max(m.visibleEntries()/2, 1))
+
+	case "page_up":
+		m.moveCursor(-max(m.visibleEntries()/2, 1))
 
-	case "ctrl+d", "pgdown":
-		m.viewport.HalfViewDown()
-		m.autoScroll = m.viewport.AtBottom()
+	case "level_up":
+		m.setMinLevel(m.minLevel + 1)
 
-	case "ctrl+u", "pgup":
-		m.viewport.HalfViewUp()
-		m.autoScroll = false
+	case "level_down":
+		m.setMinLevel(m.minLevel - 1)
 
-	case "c":
+	case "clear":
 		m.buffer.Clear()
 		m.filter = ""
-		m.updateViewportContent()
+		m.query = nil
+		m.cursor = 0
+		m.autoScroll = true
+		m.expanded = make(map[uint64]bool)
+		m.searchInput.SetValue("")
+		m.facets = facets.New()
+		m.histogram = histogram.New(histogram.DefaultBuckets)
+		m.timeMarks = nil
+		m.untimed = nil
+		m.window = timeWindow{}
+		if m.groups != nil {
+			m.groups = correlate.NewIndex(m.groups.Key())
+		}
+		m.groupID = ""
+		if m.patterns != nil {
+			m.patterns = pattern.New()
+		}
+		m.patternFilter = ""
+		m.viewName = ""
+		m.clearFind()
+		m.alertsSeen = m.rules.Alerts()
+		m.rebuild()
 
-	case "esc":
-		if m.filter != "" {
+	case "reset":
+		m.clearFind()
+		if m.filter != "" || !m.window.isZero() || m.groupID != "" || m.patternFilter != "" || m.viewName != "" {
 			m.filter = ""
+			m.query = nil
+			m.window = timeWindow{}
+			m.groupID = ""
+			m.patternFilter = ""
+			m.viewName = ""
 			m.searchInput.SetValue("")
-			m.updateViewportContent()
+			m.rebuild()
 		}
 	}
 
 	return m, nil
 }
 
+// handleDetailKey handles navigation inside the detail pane. It returns
+// false for keys the pane does not use, so they fall through to the list.
+func (m *Model) handleDetailKey(msg tea.KeyMsg) bool {
+	switch m.keys.action("detail", msg.String()) {
+	case "down":
+		m.moveDetailCursor(1)
+	case "up":
+		m.moveDetailCursor(-1)
+	case "top":
+		m.moveDetailCursor(-len(m.detailLines))
+	case "bottom":
+		m.moveDetailCursor(len(m.detailLines))
+	case "page_down":
+		m.moveDetailCursor(max(m.detail.Height/2, 1))
+	case "page_up":
+		m.moveDetailCursor(-max(m.detail.Height/2, 1))
+	case "fold":
+		m.toggleFold(m.detailCursor, !m.isCollapsedAt(m.detailCursor))
+	case "left":
+		m.toggleFold(m.detailCursor, true)
+	case "right":
+		m.toggleFold(m.detailCursor, false)
+	case "expand_all":
+		m.collapsed = make(map[string]bool)
+		m.updateDetail()
+	case "focus":
+		m.focus = focusList
+		if m.showFacets {
+			m.focus = focusFacets
+		}
+	case "reset":
+		m.focus = focusList
+	default:
+		return false
+	}
+	return true
+}
+
+// handleMouse selects entries with a click in the list and toggles tree
+// nodes with a click in the detail pane
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if !m.ready || m.mode != ModeView {
+		return m, nil
+	}
+
+	if m.showHistogram {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.clickHistogram(msg.X)
+		}
+		return m, nil
+	}
+	if m.showGroups {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.clickGroup(msg.Y)
+		}
+		return m, nil
+	}
+	if m.showPatterns {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.clickPattern(msg.Y)
+		}
+		return m, nil
+	}
+	if m.showViews {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.clickView(msg.Y)
+		}
+		return m, nil
+	}
+
+	inFacets := m.showFacets && msg.X < m.facetsWidth()
+	inDetail := m.showDetail && msg.X >= m.facetsWidth()+m.listWidth()
+
+	if msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown {
+		switch {
+		case inDetail:
+			m.detail, _ = m.detail.Update(msg)
+		case inFacets:
+			delta := wheelLines
+			if msg.Button == tea.MouseButtonWheelUp {
+				delta = -delta
+			}
+			m.moveFacetCursor(delta)
+		default:
+			delta := wheelLines
+			if msg.Button == tea.MouseButtonWheelUp {
+				delta = -delta
+			}
+			m.scrollLines(delta)
+		}
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	row := msg.Y - m.listTop
+	if row < 0 || row >= m.listHeight {
+		return m, nil
+	}
+
+	if inFacets {
+		m.clickFacet(row)
+		return m, nil
+	}
+
+	if inDetail {
+		line := m.detail.YOffset + row
+		if line < len(m.detailLines) {
+			m.focus = focusDetail
+			m.detailCursor = line
+			m.toggleFold(line, !m.isCollapsedAt(line))
+		}
+		return m, nil
+	}
+
+	m.focus = focusList
+	if idx := m.entryAtRow(row); idx >= 0 {
+		m.moveCursor(idx - m.cursor)
+	}
+	return m, nil
+}
+
 func (m Model) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg.String() {
 	case "enter":
+		q, err := query.Compile(m.searchInput.Value())
+		if err != nil {
+			// Keep the search bar open so the expression can be fixed
+			m.searchErr = err
+			return m, nil
+		}
 		m.filter = m.searchInput.Value()
+		m.query = q
+		m.searchErr = nil
+		m.viewName = ""
 		m.mode = ModeView
 		m.searchInput.Blur()
-		m.updateViewportContent()
+		m.rebuild()
 		return m, nil
 
 	case "esc":
 		m.mode = ModeView
 		m.searchInput.Blur()
 		m.searchInput.SetValue(m.filter)
+		m.searchErr = nil
 		return m, nil
 	}
 
 	m.searchInput, cmd = m.searchInput.Update(msg)
+	_, m.searchErr = query.Compile(m.searchInput.Value())
 	return m, cmd
 }
 
-func (m *Model) updateViewportContent() {
-	if !m.ready {
+func (m Model) handleJumpMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "enter":
+		// Times of day are read on the selected entry's date, in the zone
+		// currently displayed
+		var ref time.Time
+		if entry := m.selectedEntry(); entry != nil {
+			switch ref = entry.Time; m.timeMode {
+			case timeLocal, timeRelative:
+				ref = ref.Local()
+			case timeUTC:
+				ref = ref.UTC()
+			}
+		}
+		target, err := parser.ParseTimeBound(m.jumpInput.Value(), time.Now(), ref)
+		if err != nil {
+			m.jumpErr = err
+			return m, nil
+		}
+		m.mode = ModeView
+		m.jumpInput.Blur()
+		m.jumpTo(target)
+		return m, nil
+
+	case "esc":
+		m.mode = ModeView
+		m.jumpInput.Blur()
+		m.jumpErr = nil
+		return m, nil
+	}
+
+	m.jumpInput, cmd = m.jumpInput.Update(msg)
+	m.jumpErr = nil
+	return m, cmd
+}
+
+// jumpTo selects the first entry at or after t, or the last timed entry
+// when every entry is older
+func (m *Model) jumpTo(t time.Time) {
+	if len(m.visible) == 0 {
 		return
 	}
 
-	m.entries = m.buffer.Filter(m.filter)
-	m.totalEntries = m.buffer.Len()
+	// Walk the buffer once instead of looking up every visible entry
+	target, i := -1, 0
+	m.buffer.Scan(m.visible[0], func(seq uint64, e *parser.LogEntry) bool {
+		for i < len(m.visible) && m.visible[i] < seq {
+			i++
+		}
+		if i == len(m.visible) {
+			return false
+		}
+		if m.visible[i] != seq || e.Time.IsZero() {
+			return true
+		}
+		target = i
+		return e.Time.Before(t)
+	})
+	if target >= 0 {
+		m.moveCursor(target - m.cursor)
+	}
+}
 
-	var content strings.Builder
-	separator := separatorStyle.Render(strings.Repeat("─", m.width-2))
+// inTimeRange reports whether an entry falls inside --since/--until.
+// Entries without a timestamp are always kept.
+func (m Model) inTimeRange(entry *parser.LogEntry) bool {
+	if entry.Time.IsZero() {
+		return true
+	}
+	if !m.since.IsZero() && entry.Time.Before(m.since) {
+		return false
+	}
+	if !m.until.IsZero() && entry.Time.After(m.until) {
+		return false
+	}
+	return true
+}
 
-	for i, entry := range m.entries {
-		content.WriteString(entry.Formatted)
-		if i < len(m.entries)-1 {
-			content.WriteString("\n")
-			content.WriteString(separator)
+// resizeViewport fits the list between the status bar (plus the column
+// header in the table view) and the footer, next to the detail pane
+func (m *Model) resizeViewport() {
+	headerHeight := 1 // Status bar
+	if m.tableMode {
+		headerHeight++ // Column header
+	}
+	footerHeight := 2 // Help + search bar (when visible)
+
+	m.listHeight = max(m.height-headerHeight-footerHeight, 1)
+	m.listTop = headerHeight
+
+	m.detail.Width = max(m.width-m.facetsWidth()-m.listWidth()-detailBorderWidth, 0)
+	m.detail.Height = m.listHeight
+	m.detail.YPosition = headerHeight
+}
+
+// listWidth is the width of the entry list, which shrinks to make room for
+// the detail pane and the facets sidebar
+func (m Model) listWidth() int {
+	width := m.width - m.facetsWidth()
+	if !m.showDetail {
+		return width
+	}
+	return width - max(m.width*2/5, minDetailWidth) - detailBorderWidth
+}
+
+// setMinLevel changes the level threshold, clamped to the known levels
+func (m *Model) setMinLevel(l parser.Level) {
+	m.minLevel = min(max(l, parser.LevelUnknown), parser.LevelFatal)
+	m.applyLevel()
+}
+
+// updateDetail rebuilds the tree for the selected entry
+func (m *Model) updateDetail() {
+	if !m.showDetail {
+		return
+	}
+
+	m.detailLines = buildTree(m.selectedEntry(), m.collapsed, m.detail.Width-lipgloss.Width(cursorGutter), m.sortKeys)
+	m.detailCursor = min(m.detailCursor, max(len(m.detailLines)-1, 0))
+
+	var content strings.Builder
+	for i, l := range m.detailLines {
+		if i > 0 {
 			content.WriteString("\n")
 		}
+		if i == m.detailCursor && m.focus == focusDetail {
+			content.WriteString(cursorGutter)
+		} else {
+			content.WriteString(emptyGutter)
+		}
+		content.WriteString(l.text)
 	}
+	m.detail.SetContent(content.String())
+}
 
-	m.viewport.SetContent(content.String())
+// moveDetailCursor moves the tree cursor by delta lines and keeps it on
+// screen
+func (m *Model) moveDetailCursor(delta int) {
+	if len(m.detailLines) == 0 {
+		return
+	}
+	m.detailCursor = min(max(m.detailCursor+delta, 0), len(m.detailLines)-1)
+	m.updateDetail()
+
+	switch {
+	case m.detailCursor < m.detail.YOffset:
+		m.detail.SetYOffset(m.detailCursor)
+	case m.detailCursor >= m.detail.YOffset+m.detail.Height:
+		m.detail.SetYOffset(m.detailCursor - m.detail.Height + 1)
+	}
+}
+
+// isCollapsedAt reports whether the node on a detail line is collapsed
+func (m Model) isCollapsedAt(line int) bool {
+	if line < 0 || line >= len(m.detailLines) {
+		return false
+	}
+	return m.collapsed[m.detailLines[line].path]
+}
+
+// toggleFold collapses or expands the object or array on a detail line.
+// Lines inside a node act on the enclosing node when collapsing.
+func (m *Model) toggleFold(line int, collapse bool) {
+	if line < 0 || line >= len(m.detailLines) {
+		return
+	}
+	l := m.detailLines[line]
+	path := l.path
+	if !l.foldable {
+		if !collapse {
+			return
+		}
+		path = parentPath(path)
+		if path == l.path {
+			return
+		}
+	}
+
+	if collapse {
+		m.collapsed[path] = true
+	} else {
+		delete(m.collapsed, path)
+	}
+	m.updateDetail()
+
+	// Move the cursor to the node's own line so it stays put while folding
+	for i, dl := range m.detailLines {
+		if dl.path == path && dl.foldable {
+			m.moveDetailCursor(i - m.detailCursor)
+			break
+		}
+	}
+}
+
+// parentPath returns the enclosing node of a tree path
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
 }
 
 // View implements tea.Model
@@ -232,20 +1031,74 @@ func (m Model) View() string {
 	b.WriteString(m.renderStatusBar())
 	b.WriteString("\n")
 
-	// Main viewport
-	b.WriteString(m.viewport.View())
-	b.WriteString("\n")
+	if m.showHistogram {
+		b.WriteString(m.renderHistogram())
+		b.WriteString("\n")
+		b.WriteString(m.renderBottomBar())
+		return b.String()
+	}
+	if m.showGroups {
+		b.WriteString(m.renderGroups())
+		b.WriteString("\n")
+		b.WriteString(m.renderBottomBar())
+		return b.String()
+	}
+	if m.showPatterns {
+		b.WriteString(m.renderPatterns())
+		b.WriteString("\n")
+		b.WriteString(m.renderBottomBar())
+		return b.String()
+	}
+	if m.showViews {
+		b.WriteString(m.renderViews())
+		b.WriteString("\n")
+		b.WriteString(m.renderBottomBar())
+		return b.String()
+	}
 
-	// Search bar or help
-	if m.mode == ModeSearch {
-		b.WriteString(m.renderSearchBar())
-	} else {
-		b.WriteString(m.renderHelp())
+	// Column header
+	if m.tableMode {
+		b.WriteString(strings.Repeat(" ", m.facetsWidth()))
+		b.WriteString(emptyGutter + badgePadding + m.sourcePadding() + strings.Repeat(" ", m.repeatWidth()))
+		b.WriteString(m.layout.Header())
+		b.WriteString("\n")
+	}
+
+	// Entry list, with the facets sidebar on the left and the detail pane on
+	// the right
+	panes := []string{m.renderList()}
+	if m.showFacets {
+		panes = append([]string{m.renderFacets()}, panes...)
 	}
+	if m.showDetail {
+		border := detailBorderStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", m.listHeight), "\n"))
+		panes = append(panes, border, m.detail.View())
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, panes...))
+	b.WriteString("\n")
 
+	b.WriteString(m.renderBottomBar())
 	return b.String()
 }
 
+// renderBottomBar renders the prompt being typed in, or the help
+func (m Model) renderBottomBar() string {
+	switch m.mode {
+	case ModeSearch:
+		return m.renderSearchBar()
+	case ModeJump:
+		return m.renderJumpBar()
+	case ModeSave:
+		return m.renderSaveBar()
+	case ModeFind:
+		return m.renderFindBar()
+	}
+	if m.notice != "" {
+		return helpStyle.Render(ansi.Truncate(m.notice, max(m.width-2, 0), "…"))
+	}
+	return m.renderHelp()
+}
+
 func (m Model) renderStatusBar() string {
 	// Mode indicator
 	var modeStr string
@@ -256,26 +1109,96 @@ func (m Model) renderStatusBar() string {
 	} else {
 		modeStr = statusModeStyle.Render("VIEW")
 	}
+	if m.redacting {
+		modeStr += statusRedactedStyle.Render("REDACTED")
+	}
 
-	// Entry count
+	// Inputs: the commands, the listener and the source toggles
+	cmdStr := m.renderCommands() + m.renderSources()
+	if m.listen != "" {
+		listenStr := commandRunningStyle.Render("⇄") + " " + m.listen
+		if m.listenDropped != nil {
+			if n := m.listenDropped(); n > 0 {
+				listenStr += " " + droppedStyle.Render(fmt.Sprintf("%d dropped", n))
+			}
+		}
+		cmdStr = statusInfoStyle.Render(listenStr) + cmdStr
+	}
+
+	// Entry count, and how much of the input files was read while loading
 	countStr := statusInfoStyle.Render(
-		fmt.Sprintf("Entries: %d/%d", len(m.entries), m.totalEntries),
+		fmt.Sprintf("Entries: %d/%d", len(m.visible), m.totalEntries),
 	)
+	if m.progress != nil {
+		if read, total := m.progress(); read < total {
+			countStr += statusInfoStyle.Render(fmt.Sprintf("Loading %.0f%%", float64(read)/float64(total)*100))
+		}
+	}
+	// The oldest history no longer fits in memory and --max-disk
+	if n := m.buffer.Dropped(); n > 0 {
+		countStr += statusInfoStyle.Render(droppedStyle.Render(fmt.Sprintf("%d oldest dropped", n)))
+	}
 
 	// Filter info
 	var filterStr string
+	if viewStr := m.viewString(); viewStr != "" {
+		filterStr += statusInfoStyle.Render(viewStr)
+	}
 	if m.filter != "" {
-		filterStr = statusInfoStyle.Render(fmt.Sprintf("Filter: %q", m.filter))
+		filterStr += statusInfoStyle.Render(fmt.Sprintf("Filter: %q", m.filter))
+	}
+	if contextStr := m.contextString(); contextStr != "" {
+		filterStr += statusInfoStyle.Render(contextStr)
+	}
+	if findStr := m.findString(); findStr != "" {
+		filterStr += statusInfoStyle.Render(findStr)
+	}
+	if m.minLevel != parser.LevelUnknown {
+		filterStr += statusInfoStyle.Render("Level ≥ " + m.minLevel.Style().Render(strings.ToUpper(m.minLevel.String())))
+	}
+	if rangeStr := m.timeRangeString(); rangeStr != "" {
+		filterStr += statusInfoStyle.Render(rangeStr)
+	}
+	if windowStr := m.windowString(); windowStr != "" {
+		filterStr += statusInfoStyle.Render(windowStr)
+	}
+	if groupStr := m.groupString(); groupStr != "" {
+		filterStr += statusInfoStyle.Render(groupStr)
+	}
+	if patternStr := m.patternString(); patternStr != "" {
+		filterStr += statusInfoStyle.Render(patternStr)
 	}
+	if m.dedup {
+		filterStr += statusInfoStyle.Render("Dedup")
+	}
+	if m.sortKeys {
+		filterStr += statusInfoStyle.Render("Sorted keys")
+	}
+	if m.timeMode != timeRaw {
+		filterStr += statusInfoStyle.Render("Time: " + m.timeMode.String())
+	}
+
+	// Per-level counts, most severe first
+	var levelStrs []string
+	for i := len(parser.Levels) - 1; i >= 0; i-- {
+		l := parser.Levels[i]
+		if n := m.levelCounts[l]; n > 0 {
+			levelStrs = append(levelStrs, l.Badge()+" "+fmt.Sprint(n))
+		}
+	}
+	levelStr := m.alertString() + statusInfoStyle.Render(strings.Join(levelStrs, "  "))
+
+	// Ingest rate
+	rateStr := statusInfoStyle.Render(formatRate(m.rate.Rate(time.Now())))
 
 	// Scroll position
 	scrollStr := statusInfoStyle.Render(
-		fmt.Sprintf("%.0f%%", m.viewport.ScrollPercent()*100),
+		fmt.Sprintf("%.0f%%", m.scrollPercent()*100),
 	)
 
 	// Build status bar
-	left := lipgloss.JoinHorizontal(lipgloss.Left, modeStr, countStr, filterStr)
-	right := scrollStr
+	left := lipgloss.JoinHorizontal(lipgloss.Left, modeStr, cmdStr, countStr, filterStr)
+	right := lipgloss.JoinHorizontal(lipgloss.Left, levelStr, rateStr, scrollStr)
 
 	gap := m.width - lipgloss.Width(left) - lipgloss.Width(right)
 	if gap < 0 {
@@ -293,19 +1216,172 @@ func (m Model) renderStatusBar() string {
 
 func (m Model) renderSearchBar() string {
 	prompt := searchPromptStyle.Render("/")
-	return searchBarStyle.Render(prompt + m.searchInput.View())
+	bar := prompt + m.searchInput.View()
+	if m.searchErr != nil {
+		bar += "  " + searchErrorStyle.Render(m.searchErr.Error())
+	}
+	return searchBarStyle.Render(bar)
+}
+
+// timeRangeString describes the --since/--until window, if any
+func (m Model) timeRangeString() string {
+	const layout = "2006-01-02 15:04:05"
+	switch {
+	case !m.since.IsZero() && !m.until.IsZero():
+		return m.since.Local().Format(layout) + " → " + m.until.Local().Format(layout)
+	case !m.since.IsZero():
+		return "Since " + m.since.Local().Format(layout)
+	case !m.until.IsZero():
+		return "Until " + m.until.Local().Format(layout)
+	}
+	return ""
+}
+
+func (m Model) renderJumpBar() string {
+	prompt := searchPromptStyle.Render("@")
+	bar := prompt + m.jumpInput.View()
+	if m.jumpErr != nil {
+		bar += "  " + searchErrorStyle.Render(m.jumpErr.Error())
+	}
+	return searchBarStyle.Render(bar)
 }
 
 func (m Model) renderHelp() string {
+	if m.showHistogram {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("histogram", "move", "left", "right"),
+			m.screenItem("histogram", "oldest/newest", "top", "bottom"),
+			m.screenItem("histogram", "show this window", "select"),
+			m.screenItem("histogram", "clear window", "clear_window"),
+			m.closeKeys("histogram") + ": back to list",
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+	if m.showPatterns {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("patterns", "move", "down", "up"),
+			m.screenItem("patterns", "top/bottom", "top", "bottom"),
+			m.screenItem("patterns", "show this pattern", "select"),
+			m.closeKeys("patterns") + ": back to list",
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+	if m.showGroups {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("groups", "move", "down", "up"),
+			m.screenItem("groups", "top/bottom", "top", "bottom"),
+			m.screenItem("groups", "show this group", "select"),
+			m.closeKeys("groups") + ": back to list",
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+	if m.showViews {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("views", "move", "down", "up"),
+			m.screenItem("views", "top/bottom", "top", "bottom"),
+			m.screenItem("views", "apply this view", "select"),
+			m.closeKeys("views") + ": back to list",
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+	if m.showFacets && m.focus == focusFacets {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("facets", "move", "down", "up"),
+			m.screenItem("facets", "open key/filter value", "include"),
+			m.screenItem("facets", "exclude value", "exclude"),
+			m.screenItem("facets", "close/open key", "left", "right"),
+			m.screenItem("facets", "back to list", "focus"),
+			m.helpItem("hide", "facets"),
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+	if m.showDetail && m.focus == focusDetail {
+		return helpStyle.Render(ansi.Truncate(strings.Join([]string{
+			m.screenItem("detail", "move", "down", "up"),
+			m.screenItem("detail", "fold", "fold"),
+			m.screenItem("detail", "collapse/expand", "left", "right"),
+			m.screenItem("detail", "expand all", "expand_all"),
+			m.screenItem("detail", "back to list", "focus"),
+			m.helpItem("quit", "quit"),
+		}, " | "), max(m.width-2, 0), "…"))
+	}
+
 	helpItems := []string{
-		"j/k: scroll",
-		"g/G: top/bottom",
-		"/: search",
-		"p: pause",
-		"c: clear",
-		"q: quit",
-	}
-	return helpStyle.Render(strings.Join(helpItems, " | "))
+		m.helpItem("select", "down", "up"),
+		m.helpItem("top/bottom", "top", "bottom"),
+		m.helpItem("search", "search"),
+		m.helpItem("table", "table"),
+		m.helpItem("detail", "detail"),
+		m.helpItem("facets", "facets"),
+		m.helpItem("histogram", "histogram"),
+		m.helpItem("group by request", "group"),
+		m.helpItem("request IDs", "groups"),
+		m.helpItem("dedup", "dedup"),
+		m.helpItem("patterns", "patterns"),
+		m.helpItem("min level", "level_up", "level_down"),
+		m.helpItem("time format", "time"),
+		m.helpItem("sort keys", "sort_keys"),
+		m.helpItem("redact", "redact"),
+		m.helpItem("jump to time", "jump"),
+		m.helpItem("find", "find"),
+		m.helpItem("copy JSON", "copy"),
+		m.helpItem("save", "save"),
+		m.helpItem("expand", "expand"),
+	}
+	if len(m.views) > 0 {
+		helpItems = append(helpItems, m.helpItem("views", "views"))
+	}
+	if m.findRe != nil {
+		helpItems = append(helpItems, m.helpItem("next/prev match", "next_match", "prev_match"))
+	}
+	if m.query != nil {
+		helpItems = append(helpItems, m.helpItem("context", "context"))
+	}
+	switch {
+	case m.showDetail:
+		helpItems = append(helpItems, m.helpItem("focus detail", "focus"))
+	case m.showFacets:
+		helpItems = append(helpItems, m.helpItem("focus facets", "focus"))
+	}
+	if len(m.sources) > 1 {
+		helpItems = append(helpItems, "1-9: toggle source")
+	}
+	if m.restart != nil {
+		helpItems = append(helpItems, m.helpItem("restart", "restart"))
+	}
+	helpItems = append(helpItems,
+		m.helpItem("pause", "pause"),
+		m.helpItem("clear", "clear"),
+		m.helpItem("quit", "quit"),
+	)
+	// Actions without a key are left out
+	helpItems = slices.DeleteFunc(helpItems, func(s string) bool { return s == "" })
+	// Drop what does not fit rather than wrapping into the list
+	help := strings.Join(helpItems, " | ")
+	return helpStyle.Render(ansi.Truncate(help, max(m.width-2, 0), "…"))
+}
+
+// helpItem describes actions for the help bar with their first keys, or
+// returns "" when one has no key
+func (m Model) helpItem(text string, actions ...string) string {
+	return m.screenItem("", text, actions...)
+}
+
+// screenItem is helpItem for the keys of a screen
+func (m Model) screenItem(screen, text string, actions ...string) string {
+	keys := make([]string, len(actions))
+	for i, a := range actions {
+		if keys[i] = m.keys.key(screen, a); keys[i] == "" {
+			return ""
+		}
+	}
+	return strings.Join(keys, "/") + ": " + text
+}
+
+// closeKeys lists the keys that close a screen opened with an action
+func (m Model) closeKeys(action string) string {
+	keys := slices.DeleteFunc([]string{m.keys.key(action, action), m.keys.key(action, "reset")}, func(k string) bool { return k == "" })
+	return strings.Join(keys, "/")
 }
 
 // AddLogEntry is called to add a new log entry (for use with Program.Send)
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/thalessoares/lg/internal/buffer"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// newTestModel returns a model sized like a terminal, showing the given
+// lines
+func newTestModel(lines ...string) Model {
+	var tm tea.Model = New(buffer.New(100), Options{})
+	tm, _ = tm.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	for _, l := range lines {
+		tm, _ = tm.Update(AddLogEntry(parser.Parse(l)))
+	}
+	return tm.(Model)
+}
+
+// press sends keys to a model, named as tea.KeyMsg.String() names them
+func press(m Model, keys ...string) Model {
+	var tm tea.Model = m
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "ctrl+u":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+		}
+		tm, _ = tm.Update(msg)
+	}
+	return tm.(Model)
+}
+
+// linesPerFrame is a 10k lines per second stream batched at 60 frames per
+// second, as Forward delivers it
+const linesPerFrame = 10000 / 60
+
+// benchEntries returns n parsed entries with a mix of levels and sizes
+func benchEntries(n int) []*parser.LogEntry {
+	levels := []string{"debug", "info", "info", "warn", "error"}
+	entries := make([]*parser.LogEntry, n)
+	for i := range entries {
+		entries[i] = parser.Parse(fmt.Sprintf(
+			`{"timestamp":"2025-01-01T10:00:00Z","level":%q,"message":"request %d","status":%d,"user":{"id":%d}}`,
+			levels[i%len(levels)], i, 200+i%5*100, i%50))
+	}
+	return entries
+}
+
+// BenchmarkModel_Frame measures one frame of a 10k lines per second stream:
+// the batch update plus rendering the screen. Frame time should stay flat as
+// the history grows.
+func BenchmarkModel_Frame(b *testing.B) {
+	entries := benchEntries(linesPerFrame)
+
+	for _, history := range []int{1000, 10000, 100000} {
+		for _, view := range []struct {
+			name string
+			keys []tea.KeyMsg
+		}{
+			{"pretty", nil},
+			{"table", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("t")}}},
+			{"filtered", []tea.KeyMsg{
+				{Type: tea.KeyRunes, Runes: []rune("/")},
+				{Type: tea.KeyRunes, Runes: []rune("status>=500")},
+				{Type: tea.KeyEnter},
+			}},
+		} {
+			b.Run(fmt.Sprintf("history=%d/%s", history, view.name), func(b *testing.B) {
+				buf, err := buffer.NewWithDisk(buffer.DefaultCapacity, 1<<30)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer buf.Close()
+
+				var m tea.Model = New(buf, Options{})
+				m, _ = m.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+				for _, k := range view.keys {
+					m, _ = m.Update(k)
+				}
+				for i := 0; i < history; i += len(entries) {
+					m, _ = m.Update(LogBatchMsg(entries))
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					m, _ = m.Update(LogBatchMsg(entries))
+					_ = m.View()
+				}
+			})
+		}
+	}
+}
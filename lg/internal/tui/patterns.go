@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/pattern"
+)
+
+// syncPatterns counts entries added since the last call by template, once
+// the patterns screen was opened. Like the facets, every entry is counted,
+// whatever the filters.
+func (m *Model) syncPatterns() {
+	if m.patterns == nil {
+		return
+	}
+	m.patternScanned = m.buffer.Scan(m.patternScanned, func(_ uint64, e *parser.LogEntry) bool {
+		m.patterns.Add(m.redact(e))
+		return true
+	})
+}
+
+// openPatterns shows the patterns screen, counting the templates of the
+// entries in the buffer the first time
+func (m *Model) openPatterns() {
+	if m.patterns == nil {
+		m.patterns = pattern.New()
+		m.patternScanned = 0
+		m.syncPatterns()
+	}
+	m.showPatterns = true
+}
+
+// inPattern reports whether an entry matches the template drilled into, if
+// any
+func (m Model) inPattern(e *parser.LogEntry) bool {
+	return m.patternFilter == "" || pattern.Template(pattern.Message(m.redact(e))) == m.patternFilter
+}
+
+// setPattern narrows the list to the entries of a template, or shows every
+// entry again with ""
+func (m *Model) setPattern(template string) {
+	m.patternFilter = template
+	m.rebuild()
+}
+
+// toggleDedup folds runs of repeated entries into one row, or unfolds them
+func (m *Model) toggleDedup() {
+	m.dedup = !m.dedup
+	// Rows only carry their dedup key in dedup mode
+	if m.dedup {
+		m.rebuild()
+	} else {
+		m.applyLevel()
+	}
+}
+
+// patternList returns the templates in screen order and the index of the
+// selected one, kept by template since they move around as counts change
+func (m Model) patternList() ([]pattern.Pattern, int) {
+	if m.patterns == nil {
+		return nil, 0
+	}
+	patterns := m.patterns.Patterns()
+	for i, p := range patterns {
+		if p.Template == m.patternSel {
+			return patterns, i
+		}
+	}
+	return patterns, 0
+}
+
+// movePatternCursor moves the selection on the patterns screen by delta
+// rows
+func (m *Model) movePatternCursor(delta int) {
+	patterns, cursor := m.patternList()
+	if len(patterns) == 0 {
+		return
+	}
+	i := min(max(cursor+delta, 0), len(patterns)-1)
+	m.patternSel = patterns[i].Template
+	m.patternTop = m.patternScroll(len(patterns), i)
+}
+
+// handlePatternsKey handles keys on the patterns screen. It returns false
+// for keys it does not use.
+func (m *Model) handlePatternsKey(msg tea.KeyMsg) bool {
+	patterns, cursor := m.patternList()
+	page := max(m.groupRows()/2, 1)
+
+	switch m.keys.action("patterns", msg.String()) {
+	case "down":
+		m.movePatternCursor(1)
+	case "up":
+		m.movePatternCursor(-1)
+	case "top":
+		m.movePatternCursor(-len(patterns))
+	case "bottom":
+		m.movePatternCursor(len(patterns))
+	case "page_down":
+		m.movePatternCursor(page)
+	case "page_up":
+		m.movePatternCursor(-page)
+	case "select":
+		if len(patterns) > 0 {
+			m.showPatterns = false
+			m.setPattern(patterns[cursor].Template)
+		}
+	case "patterns", "reset":
+		// The key that opened the screen closes it, as does reset
+		m.showPatterns = false
+	default:
+		return false
+	}
+	return true
+}
+
+// patternScroll returns the first template on screen, bringing the
+// selection into view
+func (m Model) patternScroll(n, cursor int) int {
+	rows := m.groupRows()
+	top := min(m.patternTop, max(n-rows, 0))
+	if cursor < top {
+		return cursor
+	}
+	if cursor >= top+rows {
+		return cursor - rows + 1
+	}
+	return top
+}
+
+// clickPattern drills into the template on a screen row
+func (m *Model) clickPattern(y int) {
+	patterns, cursor := m.patternList()
+	// Below the status bar and the column header
+	i := m.patternScroll(len(patterns), cursor) + y - 2
+	if y < 2 || i >= len(patterns) {
+		return
+	}
+	m.showPatterns = false
+	m.patternSel = patterns[i].Template
+	m.setPattern(patterns[i].Template)
+}
+
+// renderPatterns renders the patterns screen at the list size: one row per
+// template with its entries and errors, most common first
+func (m Model) renderPatterns() string {
+	patterns, cursor := m.patternList()
+	var lines []string
+	if len(patterns) == 0 {
+		lines = append(lines, foldedBodyStyle.Render(" No entries yet"))
+	} else {
+		lines = append(lines, emptyGutter+treeKeyStyle.Render("entries   errors  template"))
+
+		top := m.patternScroll(len(patterns), cursor)
+		for i := top; i < len(patterns) && len(lines) < m.listHeight; i++ {
+			p := patterns[i]
+			gutter := emptyGutter
+			if i == cursor {
+				gutter = cursorGutter
+			}
+			errors := fmt.Sprintf("%7d", p.Errors)
+			if p.Errors > 0 {
+				errors = parser.LevelError.Style().Render(errors)
+			}
+			line := gutter + fmt.Sprintf("%7d", p.Count) + "  " + errors + "  " + highlightTokens(p.Template)
+			lines = append(lines, ansi.Truncate(line, m.width, "…"))
+		}
+	}
+
+	for len(lines) < m.listHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:m.listHeight], "\n")
+}
+
+// highlightTokens highlights the masked tokens of a template
+func highlightTokens(template string) string {
+	var pairs []string
+	for _, token := range []string{"<num>", "<ip>", "<uuid>", "<hex>"} {
+		pairs = append(pairs, token, repeatStyle.Render(token))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// patternString describes the template drilled into for the status bar
+func (m Model) patternString() string {
+	if m.patternFilter == "" {
+		return ""
+	}
+	return "Pattern " + ansi.Truncate(m.patternFilter, 40, "…")
+}
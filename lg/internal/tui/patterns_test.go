@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+)
+
+// patternEntries repeat a warning in a run, then with another level, then
+// apart from the run
+var patternEntries = []string{
+	`{"time":"2025-01-01T10:00:00Z","level":"warn","msg":"retrying"}`,
+	`{"time":"2025-01-01T10:00:01Z","level":"warn","msg":"retrying"}`,
+	`{"time":"2025-01-01T10:00:02Z","level":"warn","msg":"retrying"}`,
+	`{"level":"error","msg":"retrying"}`,
+	`{"level":"info","msg":"user 1 logged in"}`,
+	`{"level":"info","msg":"user 2 logged in"}`,
+	`{"level":"warn","msg":"retrying"}`,
+}
+
+func TestDedup(t *testing.T) {
+	m := press(newTestModel(patternEntries...), "u")
+
+	// A run of the same level and message, whatever the time, is one row
+	// showing the newest entry
+	if !slices.Equal(m.visible, []uint64{2, 3, 4, 5, 6}) || !maps.Equal(m.repeats, map[uint64]int{2: 3}) {
+		t.Errorf("listed = %v, repeats = %v; want [2 3 4 5 6], map[2:3]", m.visible, m.repeats)
+	}
+	if list := ansi.Strip(m.renderList()); !strings.Contains(list, "×3") {
+		t.Errorf("list = %q, want ×3", list)
+	}
+
+	// A new repeat folds into the last row
+	var tm tea.Model = m
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(`{"level":"warn","msg":"retrying"}`)))
+	m = tm.(Model)
+	if !slices.Equal(m.visible, []uint64{2, 3, 4, 5, 7}) || !maps.Equal(m.repeats, map[uint64]int{2: 3, 7: 2}) {
+		t.Errorf("listed = %v, repeats = %v after a repeat", m.visible, m.repeats)
+	}
+
+	// Runs are of the entries listed, so the filter joins them
+	m = press(m, "/", "level=warn", "enter")
+	if !slices.Equal(m.visible, []uint64{7}) || !maps.Equal(m.repeats, map[uint64]int{7: 5}) {
+		t.Errorf("listed = %v, repeats = %v with a filter; want [7], map[7:5]", m.visible, m.repeats)
+	}
+
+	if m = press(m, "esc", "u"); m.dedup || len(m.visible) != 8 || len(m.repeats) != 0 {
+		t.Errorf("dedup = %v, listed = %d, repeats = %v; want every entry unfolded", m.dedup, len(m.visible), m.repeats)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	m := press(newTestModel(patternEntries...), "P")
+	if !m.showPatterns {
+		t.Fatal("P did not open the patterns screen")
+	}
+
+	// Most common first
+	patterns, _ := m.patternList()
+	var templates []string
+	for _, p := range patterns {
+		templates = append(templates, p.Template)
+	}
+	if want := []string{"retrying", "user <num> logged in"}; !slices.Equal(templates, want) {
+		t.Errorf("templates = %v, want %v", templates, want)
+	}
+
+	m = press(m, "j", "enter")
+	if m.showPatterns || !slices.Equal(m.visible, []uint64{4, 5}) || m.patternString() != "Pattern user <num> logged in" {
+		t.Errorf("screen shown = %v, listed = %v, status = %q; want the list of the user template", m.showPatterns, m.visible, m.patternString())
+	}
+	for seq, want := range map[uint64]bool{3: false, 4: true} {
+		if got := m.inPattern(m.buffer.GetSeq(seq)); got != want {
+			t.Errorf("inPattern(%d) = %v, want %v", seq, got, want)
+		}
+	}
+
+	// New entries of the template are listed as they come
+	var tm tea.Model = m
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(`{"level":"info","msg":"user 3 logged in"}`)))
+	tm, _ = tm.Update(AddLogEntry(parser.Parse(`{"level":"info","msg":"user 3 logged out"}`)))
+	if m = tm.(Model); !slices.Equal(m.visible, []uint64{4, 5, 7}) {
+		t.Errorf("listed = %v after new entries, want [4 5 7]", m.visible)
+	}
+
+	// The filter applies within the pattern, and reset leaves both
+	if m = press(m, "/", "3", "enter"); !slices.Equal(m.visible, []uint64{7}) {
+		t.Errorf("listed = %v with a filter, want [7]", m.visible)
+	}
+	if m = press(m, "esc"); m.patternString() != "" || len(m.visible) != 9 {
+		t.Errorf("status = %q, listed = %d; want every entry", m.patternString(), len(m.visible))
+	}
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"github.com/thalessoares/lg/internal/facets"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/pattern"
+)
+
+// Redaction masks sensitive values wherever entries are shown, copied or
+// saved. Entries are kept as read, so filters still match the values
+// hidden, and the toggle shows them again.
+
+// redact returns an entry masked when redacting
+func (m Model) redact(e *parser.LogEntry) *parser.LogEntry {
+	if !m.redacting {
+		return e
+	}
+	return m.redactor.Entry(e)
+}
+
+// toggleRedact masks sensitive values, or shows them again. The facets and
+// patterns are counted again, since they show values.
+func (m *Model) toggleRedact() {
+	m.redacting = !m.redacting
+
+	m.facets = facets.New()
+	m.facetScanned = 0
+	m.syncFacets()
+	if m.patterns != nil {
+		m.patterns = pattern.New()
+		m.patternScanned = 0
+		m.syncPatterns()
+	}
+	// The template drilled into was read with the values shown or hidden
+	if m.patternFilter != "" {
+		m.setPattern("")
+	}
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestToggleRedact(t *testing.T) {
+	m := newTestModel(`{"msg":"login bob@example.com","password":"hunter2"}`, `{"msg":"ready"}`)
+	if strings.Contains(ansi.Strip(m.renderStatusBar()), "REDACTED") {
+		t.Error("status bar shows REDACTED before the toggle")
+	}
+
+	m = press(m, "X")
+	if status := ansi.Strip(m.renderStatusBar()); !strings.Contains(status, "REDACTED") {
+		t.Errorf("status bar = %q, want REDACTED", status)
+	}
+	screen := ansi.Strip(m.View())
+	for _, secret := range []string{"hunter2", "bob@example.com"} {
+		if strings.Contains(screen, secret) {
+			t.Errorf("screen shows %q while redacting", secret)
+		}
+	}
+	if !strings.Contains(screen, `"password": "[REDACTED]"`) {
+		t.Errorf("screen = %q, want the password masked", screen)
+	}
+
+	// Facets count the masked values
+	m.facetOpen["password"] = true
+	var values []string
+	for _, l := range m.facetLines() {
+		if l.isValue && l.path == "password" {
+			values = append(values, l.value)
+		}
+	}
+	if !slices.Equal(values, []string{"[REDACTED]"}) {
+		t.Errorf("password facet values = %v, want only the mask", values)
+	}
+
+	// Filters still match the values hidden
+	if m = press(m, "/", "hunter2", "enter"); !slices.Equal(m.visible, []uint64{0}) {
+		t.Errorf("listed = %v, want the entry with the hidden value", m.visible)
+	}
+
+	m = press(m, "esc", "X")
+	if status := ansi.Strip(m.renderStatusBar()); strings.Contains(status, "REDACTED") {
+		t.Errorf("status bar = %q after the second toggle", status)
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "hunter2") {
+		t.Error("the second toggle did not show the values again")
+	}
+}
+
+func TestToggleRedact_ClearsPattern(t *testing.T) {
+	m := press(newTestModel(`{"msg":"login bob@example.com"}`, `{"msg":"ready"}`), "P", "enter")
+	if m.patternFilter == "" {
+		t.Fatal("no pattern drilled into")
+	}
+
+	// The template was read with the email shown, so it no longer applies
+	if m = press(m, "X"); m.patternFilter != "" || len(m.visible) != 2 {
+		t.Errorf("pattern = %q, listed = %d; want every entry", m.patternFilter, len(m.visible))
+	}
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/source"
+)
+
+// maxSourceKeys is how many sources the digit keys can toggle
+const maxSourceKeys = 9
+
+// commandStatus is the state of a command started with `lg -- cmd` or -s
+type commandStatus struct {
+	name  string
+	state commandState
+	err   error // Why the command exited, nil for success
+}
+
+// addSource records the source of a new entry, in order of first appearance
+func (m *Model) addSource(name string) {
+	if name != "" && !slices.Contains(m.sources, name) {
+		m.sources = append(m.sources, name)
+	}
+}
+
+// toggleSource shows or hides the entries of the i-th source
+func (m *Model) toggleSource(i int) {
+	if i >= len(m.sources) {
+		return
+	}
+	name := m.sources[i]
+	if m.hiddenSources[name] {
+		delete(m.hiddenSources, name)
+	} else {
+		m.hiddenSources[name] = true
+	}
+	m.applyLevel()
+}
+
+// setCommand updates the status of a command
+func (m *Model) setCommand(msg CommandMsg) {
+	state := cmdExited
+	if msg.Running {
+		state = cmdRunning
+	}
+	for i := range m.commands {
+		if m.commands[i].name == msg.Name {
+			m.commands[i].state, m.commands[i].err = state, msg.Err
+			return
+		}
+	}
+}
+
+// renderCommands describes the state of the commands for the status bar.
+// A single command is shown by its command line, several by their labels.
+func (m Model) renderCommands() string {
+	var parts []string
+	exited := false
+	for _, c := range m.commands {
+		label := ""
+		if len(m.commands) > 1 {
+			label = c.name
+		}
+		switch c.state {
+		case cmdRunning:
+			if label == "" {
+				label = ansi.Truncate(c.name, 30, "…")
+			}
+			parts = append(parts, commandRunningStyle.Render("▶")+" "+label)
+		case cmdExited:
+			exited = true
+			style := commandExitedStyle
+			if c.err == nil {
+				style = commandDoneStyle
+			}
+			if label != "" {
+				label += ": "
+			}
+			parts = append(parts, style.Render("■ "+label+source.ExitStatus(c.err)))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if exited {
+		parts = append(parts, "(R: restart)")
+	}
+	return statusInfoStyle.Render(strings.Join(parts, " "))
+}
+
+// renderSources lists the sources with the digit that toggles them, the
+// hidden ones dimmed. It is empty with a single source.
+func (m Model) renderSources() string {
+	if len(m.sources) < 2 {
+		return ""
+	}
+	var parts []string
+	for i, name := range m.sources[:min(len(m.sources), maxSourceKeys)] {
+		style := parser.SourceStyle(name)
+		if m.hiddenSources[name] {
+			style = hiddenSourceStyle
+		}
+		parts = append(parts, string(rune('1'+i))+" "+style.Render(name))
+	}
+	return statusInfoStyle.Render(strings.Join(parts, " "))
+}
@@ -1,87 +1,171 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"strings"
 
-var (
-	// Colors
-	primaryColor   = lipgloss.Color("81")  // Cyan
-	secondaryColor = lipgloss.Color("214") // Orange
-	successColor   = lipgloss.Color("82")  // Green
-	warningColor   = lipgloss.Color("214") // Orange
-	errorColor     = lipgloss.Color("196") // Red
-	mutedColor     = lipgloss.Color("241") // Gray
-	highlightColor = lipgloss.Color("226") // Yellow
+	"github.com/charmbracelet/lipgloss"
+	"github.com/thalessoares/lg/internal/theme"
+)
 
+// Styles, set by SetTheme
+var (
 	// Status bar styles
-	statusBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("236")).
-			Foreground(lipgloss.Color("252")).
-			Padding(0, 1)
-
-	statusModeStyle = lipgloss.NewStyle().
-			Background(primaryColor).
-			Foreground(lipgloss.Color("0")).
-			Padding(0, 1).
-			Bold(true)
-
-	statusPausedStyle = lipgloss.NewStyle().
-				Background(warningColor).
-				Foreground(lipgloss.Color("0")).
-				Padding(0, 1).
-				Bold(true)
-
-	statusSearchStyle = lipgloss.NewStyle().
-				Background(successColor).
-				Foreground(lipgloss.Color("0")).
-				Padding(0, 1).
-				Bold(true)
-
-	statusInfoStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252")).
-			Padding(0, 1)
+	statusBarStyle    lipgloss.Style
+	statusModeStyle   lipgloss.Style
+	statusPausedStyle lipgloss.Style
+	statusSearchStyle lipgloss.Style
+	statusInfoStyle   lipgloss.Style
+
+	// Redaction indicator in the status bar
+	statusRedactedStyle lipgloss.Style
+
+	// Command state in the status bar
+	commandRunningStyle lipgloss.Style
+	commandDoneStyle    lipgloss.Style
+	commandExitedStyle  lipgloss.Style
+
+	// Source toggled off in the status bar
+	hiddenSourceStyle lipgloss.Style
+
+	// Alert counter in the status bar
+	alertStyle lipgloss.Style
+
+	// Dropped history warning in the status bar
+	droppedStyle lipgloss.Style
 
 	// Help text style
-	helpStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Padding(0, 1)
+	helpStyle lipgloss.Style
 
 	// Search bar styles
-	searchBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("236")).
-			Foreground(lipgloss.Color("252")).
-			Padding(0, 1)
-
-	searchPromptStyle = lipgloss.NewStyle().
-				Foreground(successColor).
-				Bold(true)
+	searchBarStyle    lipgloss.Style
+	searchPromptStyle lipgloss.Style
+	searchErrorStyle  lipgloss.Style
 
 	// Log entry styles
-	entryStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			MarginBottom(1)
+	entryStyle         lipgloss.Style
+	selectedEntryStyle lipgloss.Style
+
+	// Cursor gutter for the selected entry
+	cursorStyle  lipgloss.Style
+	cursorGutter string
+
+	// Time column in front of every entry
+	timeStyle lipgloss.Style
 
-	selectedEntryStyle = lipgloss.NewStyle().
-				Padding(0, 1).
-				MarginBottom(1).
-				Background(lipgloss.Color("237"))
+	// Detail pane styles
+	detailBorderStyle lipgloss.Style
+	treeKeyStyle      lipgloss.Style
+	treeStringStyle   lipgloss.Style
+	treeNumberStyle   lipgloss.Style
+	treeBoolStyle     lipgloss.Style
+	treeNullStyle     lipgloss.Style
+	treeBraceStyle    lipgloss.Style
+	treeMarkerStyle   lipgloss.Style
+	treeSummaryStyle  lipgloss.Style
+	plainDetailStyle  lipgloss.Style
+	treeTraceStyle    lipgloss.Style
+
+	// Value counts in the facets sidebar
+	facetCountStyle lipgloss.Style
+
+	// Chart of all entries in the histogram
+	histogramAllStyle lipgloss.Style
+
+	// ×N counter of folded repeats and masked tokens of a pattern
+	repeatStyle lipgloss.Style
+
+	// Summary shown in place of a folded stack trace
+	foldedBodyStyle lipgloss.Style
 
 	// Separator style
-	separatorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("238"))
+	separatorStyle lipgloss.Style
 
 	// Highlight style for search matches
-	highlightMatchStyle = lipgloss.NewStyle().
-				Background(highlightColor).
-				Foreground(lipgloss.Color("0"))
+	highlightMatchStyle lipgloss.Style
+
+	// Entries listed as context around filter matches
+	contextStyle lipgloss.Style
 
 	// Title style
-	titleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Padding(0, 1)
+	titleStyle lipgloss.Style
 
 	// Border style
-	borderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("238"))
+	borderStyle lipgloss.Style
 )
+
+var (
+	emptyGutter = "  "
+
+	// Level badge column in front of every entry
+	badgeWidth   = 4
+	badgePadding = strings.Repeat(" ", badgeWidth)
+
+	// Repeat count column in dedup mode, wide enough for ×9999
+	repeatColumn = 5
+)
+
+func init() {
+	SetTheme(theme.Dark)
+}
+
+// SetTheme colors the TUI with a theme. Without colors, the status mode
+// and search matches are shown in reverse video instead.
+func SetTheme(t theme.Theme) {
+	bg := func(c string) lipgloss.Style {
+		style := lipgloss.NewStyle().Foreground(theme.Color(t.Contrast))
+		if t.Mono {
+			return style.Reverse(true)
+		}
+		return style.Background(theme.Color(c))
+	}
+
+	statusBarStyle = theme.Fg(t.Text).Background(theme.Color(t.Bar)).Padding(0, 1)
+	statusModeStyle = bg(t.Primary).Padding(0, 1).Bold(true)
+	statusPausedStyle = bg(t.Warning).Padding(0, 1).Bold(true)
+	statusSearchStyle = bg(t.Success).Padding(0, 1).Bold(true)
+	statusInfoStyle = theme.Fg(t.Text).Padding(0, 1)
+	statusRedactedStyle = bg(t.Error).Padding(0, 1).Bold(true)
+
+	commandRunningStyle = theme.Fg(t.Success)
+	commandDoneStyle = theme.Fg(t.Muted)
+	commandExitedStyle = theme.Fg(t.Error)
+
+	hiddenSourceStyle = theme.Fg(t.Muted).Strikethrough(true)
+	alertStyle = theme.Fg(t.Error).Bold(true)
+	droppedStyle = theme.Fg(t.Warning)
+	helpStyle = theme.Fg(t.Muted).Padding(0, 1)
+
+	searchBarStyle = theme.Fg(t.Text).Background(theme.Color(t.Bar)).Padding(0, 1)
+	searchPromptStyle = theme.Fg(t.Success).Bold(true)
+	searchErrorStyle = theme.Fg(t.Error)
+
+	entryStyle = lipgloss.NewStyle().Padding(0, 1).MarginBottom(1)
+	selectedEntryStyle = lipgloss.NewStyle().Padding(0, 1).MarginBottom(1).Background(theme.Color(t.Selection))
+
+	cursorStyle = theme.Fg(t.Primary)
+	cursorGutter = cursorStyle.Render("▌") + " "
+
+	timeStyle = theme.Fg(t.Dim)
+
+	detailBorderStyle = theme.Fg(t.Border)
+	treeKeyStyle = theme.Fg(t.Primary)
+	treeStringStyle = theme.Fg(t.Success)
+	treeNumberStyle = theme.Fg(t.Secondary)
+	treeBoolStyle = theme.Fg(t.Accent)
+	treeNullStyle = theme.Fg(t.Muted)
+	treeBraceStyle = theme.Fg(t.Dim)
+	treeMarkerStyle = theme.Fg(t.Secondary)
+	treeSummaryStyle = theme.Fg(t.Muted).Italic(true)
+	plainDetailStyle = theme.Fg(t.Text)
+	treeTraceStyle = theme.Fg(t.Dim)
+
+	facetCountStyle = theme.Fg(t.Muted)
+	histogramAllStyle = theme.Fg(t.Primary)
+	repeatStyle = theme.Fg(t.Warning).Bold(true)
+	foldedBodyStyle = theme.Fg(t.Muted).Italic(true)
+	separatorStyle = theme.Fg(t.Border)
+	highlightMatchStyle = bg(t.Match)
+	contextStyle = theme.Fg(t.Faint).Faint(t.Mono)
+	titleStyle = theme.Fg(t.Primary).Bold(true).Padding(0, 1)
+	borderStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Color(t.Border))
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timeMode is how entry timestamps are displayed
+type timeMode int
+
+const (
+	timeRaw      timeMode = iota // As written in the log
+	timeLocal                    // Converted to the local time zone
+	timeUTC                      // Converted to UTC
+	timeRelative                 // Age, e.g. "5m ago"
+)
+
+const (
+	localTimeLayout = "Jan _2 15:04:05.000"
+	utcTimeLayout   = "Jan _2 15:04:05.000Z"
+	relativeWidth   = len("59m ahead")
+)
+
+// relativeTick refreshes relative times while they are shown
+const relativeTick = time.Second
+
+// tickMsg re-renders relative times
+type tickMsg time.Time
+
+func tick() tea.Cmd {
+	return tea.Tick(relativeTick, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// String names the mode for the status bar
+func (t timeMode) String() string {
+	switch t {
+	case timeLocal:
+		return "local"
+	case timeUTC:
+		return "UTC"
+	case timeRelative:
+		return "relative"
+	default:
+		return "raw"
+	}
+}
+
+// width is the fixed width of the time prefix, 0 when none is shown
+func (t timeMode) width() int {
+	switch t {
+	case timeLocal:
+		return len(localTimeLayout)
+	case timeUTC:
+		return len(utcTimeLayout)
+	case timeRelative:
+		return relativeWidth
+	}
+	return 0
+}
+
+// formatter returns the function used to render timestamps in this mode,
+// or nil to keep them as written
+func (t timeMode) formatter(now time.Time) func(time.Time) string {
+	switch t {
+	case timeLocal:
+		return func(ts time.Time) string { return ts.Local().Format(localTimeLayout) }
+	case timeUTC:
+		return func(ts time.Time) string { return ts.UTC().Format(utcTimeLayout) }
+	case timeRelative:
+		return func(ts time.Time) string { return formatAge(now.Sub(ts)) }
+	}
+	return nil
+}
+
+// formatAge renders a duration in its largest whole unit, e.g. "5m ago"
+func formatAge(d time.Duration) string {
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " ahead"
+	}
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds())) + suffix
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes())) + suffix
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours())) + suffix
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24)) + suffix
+}
+
+// timePrefix renders the fixed-width time column shown before each entry in
+// the pretty view
+func timePrefix(ts time.Time, mode timeMode, format func(time.Time) string) string {
+	w := mode.width()
+	if w == 0 {
+		return ""
+	}
+	if ts.IsZero() {
+		return strings.Repeat(" ", w+1)
+	}
+	s := format(ts)
+	if gap := w - len(s); gap > 0 {
+		s += strings.Repeat(" ", gap)
+	}
+	return timeStyle.Render(s) + " "
+}
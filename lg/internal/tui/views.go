@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/thalessoares/lg/internal/config"
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+)
+
+// applyView replaces the filter, the columns and the level threshold with
+// a saved view's. Columns switch to the table view; a view without them
+// goes back to the default columns.
+func (m *Model) applyView(v config.View) {
+	q, err := query.Compile(v.Filter)
+	if err != nil {
+		q = query.Text(v.Filter)
+	}
+	m.filter = v.Filter
+	m.query = q
+	m.searchInput.SetValue(v.Filter)
+
+	m.minLevel = parser.LevelUnknown
+	if l, ok := parser.ParseLevel(v.Level); ok {
+		m.minLevel = l
+	}
+
+	m.columns = m.defaultColumns
+	if len(v.Columns) > 0 {
+		m.columns = v.Columns
+		m.tableMode = true
+		m.resizeViewport()
+	}
+	m.viewName = v.Name
+	m.rebuild()
+}
+
+// moveViewCursor moves the selection on the views screen by delta rows
+func (m *Model) moveViewCursor(delta int) {
+	if len(m.views) == 0 {
+		return
+	}
+	m.viewSel = min(max(m.viewSel+delta, 0), len(m.views)-1)
+	m.viewTop = m.viewScroll()
+}
+
+// handleViewsKey handles keys on the views screen. It returns false for
+// keys it does not use.
+func (m *Model) handleViewsKey(msg tea.KeyMsg) bool {
+	page := max(m.groupRows()/2, 1)
+	switch m.keys.action("views", msg.String()) {
+	case "down":
+		m.moveViewCursor(1)
+	case "up":
+		m.moveViewCursor(-1)
+	case "top":
+		m.moveViewCursor(-len(m.views))
+	case "bottom":
+		m.moveViewCursor(len(m.views))
+	case "page_down":
+		m.moveViewCursor(page)
+	case "page_up":
+		m.moveViewCursor(-page)
+	case "select":
+		if m.viewSel < len(m.views) {
+			m.showViews = false
+			m.applyView(m.views[m.viewSel])
+		}
+	case "views", "reset":
+		// The key that opened the screen closes it, as does reset
+		m.showViews = false
+	default:
+		return false
+	}
+	return true
+}
+
+// viewScroll returns the first view on screen, bringing the selection into
+// view
+func (m Model) viewScroll() int {
+	rows := m.groupRows()
+	top := min(m.viewTop, max(len(m.views)-rows, 0))
+	if m.viewSel < top {
+		return m.viewSel
+	}
+	if m.viewSel >= top+rows {
+		return m.viewSel - rows + 1
+	}
+	return top
+}
+
+// clickView applies the view on a screen row
+func (m *Model) clickView(y int) {
+	// Below the status bar and the column header
+	i := m.viewScroll() + y - 2
+	if y < 2 || i >= len(m.views) {
+		return
+	}
+	m.showViews = false
+	m.viewSel = i
+	m.applyView(m.views[i])
+}
+
+// renderViews renders the views screen at the list size: one row per saved
+// view with its filter, columns and level
+func (m Model) renderViews() string {
+	var lines []string
+	if len(m.views) == 0 {
+		lines = append(lines, foldedBodyStyle.Render(" No saved views (add them under views: in "+config.Path()+")"))
+	} else {
+		nameWidth, columnsWidth := len("view"), len("columns")
+		for _, v := range m.views {
+			nameWidth = max(nameWidth, lipgloss.Width(v.Name))
+			columnsWidth = max(columnsWidth, lipgloss.Width(viewColumns(v)))
+		}
+		nameWidth = min(nameWidth, maxGroupIDWidth)
+
+		pad := func(s string, width int) string {
+			s = ansi.Truncate(s, width, "…")
+			return s + strings.Repeat(" ", max(width-lipgloss.Width(s), 0))
+		}
+		// Level names are at most 5 wide
+		row := func(gutter, name, level, columns, filter string) string {
+			return gutter + pad(name, nameWidth) + "  " + pad(level, 5) + "  " + pad(columns, columnsWidth) + "  " + filter
+		}
+		lines = append(lines, emptyGutter+treeKeyStyle.Render(row("", "view", "level", "columns", "filter")))
+
+		for i := m.viewScroll(); i < len(m.views) && len(lines) < m.listHeight; i++ {
+			v := m.views[i]
+			gutter := emptyGutter
+			if i == m.viewSel {
+				gutter = cursorGutter
+			}
+			level := ""
+			if l, ok := parser.ParseLevel(v.Level); ok {
+				level = l.Style().Render(l.String())
+			}
+			line := row(gutter, v.Name, level, timeStyle.Render(viewColumns(v)), v.Filter)
+			lines = append(lines, ansi.Truncate(line, m.width, "…"))
+		}
+	}
+
+	for len(lines) < m.listHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:m.listHeight], "\n")
+}
+
+// viewColumns lists a view's columns for the views screen
+func viewColumns(v config.View) string {
+	if len(v.Columns) == 0 {
+		return "-"
+	}
+	return strings.Join(v.Columns, ",")
+}
+
+// viewString names the view applied for the status bar
+func (m Model) viewString() string {
+	if m.viewName == "" {
+		return ""
+	}
+	return "View " + m.viewName
+}
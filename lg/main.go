@@ -1,79 +1,657 @@
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/thalessoares/lg/internal/buffer"
+	"github.com/thalessoares/lg/internal/config"
+	"github.com/thalessoares/lg/internal/merge"
+	"github.com/thalessoares/lg/internal/multiline"
+	"github.com/thalessoares/lg/internal/output"
 	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/query"
+	"github.com/thalessoares/lg/internal/redact"
+	"github.com/thalessoares/lg/internal/rules"
+	"github.com/thalessoares/lg/internal/source"
+	"github.com/thalessoares/lg/internal/table"
+	"github.com/thalessoares/lg/internal/theme"
 	"github.com/thalessoares/lg/internal/tui"
 )
 
-const (
-	bufferCapacity = 10000
-)
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: <command> | lg [flags]")
+	fmt.Fprintln(os.Stderr, "       lg [flags] file...")
+	fmt.Fprintln(os.Stderr, "       lg [flags] -- command [args...]")
+	fmt.Fprintln(os.Stderr, "       lg [flags] -s name=file -s name='command args' ...")
+	fmt.Fprintln(os.Stderr, "       lg [flags] --listen tcp://:5170|udp://:5514|http://:8088/ingest")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "lg reads JSON logs from stdin or files and displays them in an interactive TUI.")
+	fmt.Fprintln(os.Stderr, "logfmt, syslog, nginx/CLF access logs and klog lines are detected too, as")
+	fmt.Fprintln(os.Stderr, "is JSON after a containerd/CRI or timestamp prefix. String fields holding")
+	fmt.Fprintln(os.Stderr, "JSON are decoded into nested objects.")
+	fmt.Fprintln(os.Stderr, "gzip and zstd input is decompressed automatically.")
+	fmt.Fprintln(os.Stderr, "After --, lg runs the command itself and tags its stdout and stderr.")
+	fmt.Fprintln(os.Stderr, "When stdout is not a terminal, or with --no-tui, matching entries are")
+	fmt.Fprintln(os.Stderr, "written to stdout instead.")
+	fmt.Fprintln(os.Stderr, "Themes, keys, default columns, saved views and highlight rules are read")
+	fmt.Fprintln(os.Stderr, "from ~/.config/lg/config.yaml. NO_COLOR turns colors off.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Example:")
+	fmt.Fprintln(os.Stderr, "  tail -f app.log | lg")
+	fmt.Fprintln(os.Stderr, "  docker logs -f container | lg")
+	fmt.Fprintln(os.Stderr, "  kubectl logs -f pod | lg --columns time,level,msg,request_id")
+	fmt.Fprintln(os.Stderr, "  tail -f access.log | lg --format nginx")
+	fmt.Fprintln(os.Stderr, "  kubectl logs -f pod | lg --max-disk 4GB")
+	fmt.Fprintln(os.Stderr, "  cat app.log | lg --since 2025-01-01T10:00:00Z --until 2025-01-01T10:05:00Z")
+	fmt.Fprintln(os.Stderr, "  lg -f app.log")
+	fmt.Fprintln(os.Stderr, "  lg app.log app.log.1.gz")
+	fmt.Fprintln(os.Stderr, "  lg -- kubectl logs -f deploy/api")
+	fmt.Fprintln(os.Stderr, "  lg -s api='kubectl logs -f api' -s worker=worker.log")
+	fmt.Fprintln(os.Stderr, "  lg --listen tcp://:5170")
+	fmt.Fprintln(os.Stderr, "  lg --filter 'status>=500' --output jsonl app.log > errors.jsonl")
+	fmt.Fprintln(os.Stderr, "  lg --theme light --view errors app.log")
+	fmt.Fprintln(os.Stderr, "  lg --output csv --columns time,status,path --filter 'status>=500' app.log > errors.csv")
+	fmt.Fprintln(os.Stderr, "  kubectl logs pod | lg --no-tui --level warn --columns time,level,msg --head 20")
+	fmt.Fprintln(os.Stderr, "  lg --filter 'msg~\"timeout\"' -C 3 app.log")
+	fmt.Fprintln(os.Stderr, "  kubectl logs -f pod | lg --redact")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Keybindings (remappable under keys: in the configuration file, including the")
+	fmt.Fprintln(os.Stderr, "detail pane, facets and histogram keys, e.g. fold, left, right, select):")
+	fmt.Fprintln(os.Stderr, "  j/k, arrows  : select next/previous entry")
+	fmt.Fprintln(os.Stderr, "  g/G          : go to first/last entry")
+	fmt.Fprintln(os.Stderr, "  Ctrl+d/u     : page down/up")
+	fmt.Fprintln(os.Stderr, "  /            : search/filter")
+	fmt.Fprintln(os.Stderr, "  f            : find text without filtering, highlighting it in every entry")
+	fmt.Fprintln(os.Stderr, "  n/N          : select the next/previous entry containing the text found")
+	fmt.Fprintln(os.Stderr, "  C            : show 1, 3, 5 or 10 entries around each filter match, like grep -C")
+	fmt.Fprintln(os.Stderr, "  t            : toggle table view")
+	fmt.Fprintln(os.Stderr, "  enter        : expand/collapse the selected row or stack trace")
+	fmt.Fprintln(os.Stderr, "  d            : toggle the detail pane")
+	fmt.Fprintln(os.Stderr, "  tab          : move focus to/from the detail pane")
+	fmt.Fprintln(os.Stderr, "  enter/space  : fold/unfold a JSON node (detail pane, or click it)")
+	fmt.Fprintln(os.Stderr, "  F            : toggle the facets sidebar (field values and counts)")
+	fmt.Fprintln(os.Stderr, "  H            : histogram of entries per level over time; enter narrows to a bucket")
+	fmt.Fprintln(os.Stderr, "  r            : show only the entries sharing the selected entry's request/trace ID")
+	fmt.Fprintln(os.Stderr, "  I            : list the request/trace IDs with their entries, errors and span")
+	fmt.Fprintln(os.Stderr, "  u            : fold repeated messages into one row with ×N")
+	fmt.Fprintln(os.Stderr, "  P            : list message patterns with their counts; enter shows a pattern's entries")
+	fmt.Fprintln(os.Stderr, "  V            : list the saved views; enter applies a view's filter, columns and level")
+	fmt.Fprintln(os.Stderr, "  y            : copy the selected entry's JSON to the clipboard (OSC 52)")
+	fmt.Fprintln(os.Stderr, "  s            : save the listed entries to a file (.jsonl, .csv, .md, or text)")
+	fmt.Fprintln(os.Stderr, "  enter/x      : filter on/exclude the selected value (facets sidebar)")
+	fmt.Fprintln(os.Stderr, "  +/-          : raise/lower the minimum level")
+	fmt.Fprintln(os.Stderr, "  T            : cycle time display (raw, local, UTC, relative)")
+	fmt.Fprintln(os.Stderr, "  K            : toggle between keys in the order read and sorted keys")
+	fmt.Fprintln(os.Stderr, "  X            : mask/show sensitive values (passwords, tokens, emails, ...)")
+	fmt.Fprintln(os.Stderr, "  @            : jump to a time (10:00:03, 2025-01-01T10:00:03Z, 5m)")
+	fmt.Fprintln(os.Stderr, "  R            : restart the commands (lg -- command, -s name='command')")
+	fmt.Fprintln(os.Stderr, "  1-9          : show/hide a source (-s)")
+	fmt.Fprintln(os.Stderr, "  p            : pause/resume")
+	fmt.Fprintln(os.Stderr, "  esc          : clear the filter, the histogram time window, the group, the pattern, the view and the text found")
+	fmt.Fprintln(os.Stderr, "  c            : clear logs and the alert counter")
+	fmt.Fprintln(os.Stderr, "  q, Ctrl+c    : quit")
+}
 
 func main() {
-	// Check if stdin is a pipe
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) != 0 {
-		fmt.Fprintln(os.Stderr, "Usage: <command> | lg")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "lg reads JSON logs from stdin and displays them in an interactive TUI.")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Example:")
-		fmt.Fprintln(os.Stderr, "  tail -f app.log | lg")
-		fmt.Fprintln(os.Stderr, "  docker logs -f container | lg")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Keybindings:")
-		fmt.Fprintln(os.Stderr, "  j/k, arrows  : scroll up/down")
-		fmt.Fprintln(os.Stderr, "  g/G          : go to top/bottom")
-		fmt.Fprintln(os.Stderr, "  Ctrl+d/u     : page down/up")
-		fmt.Fprintln(os.Stderr, "  /            : search/filter")
-		fmt.Fprintln(os.Stderr, "  p            : pause/resume")
-		fmt.Fprintln(os.Stderr, "  c            : clear logs")
-		fmt.Fprintln(os.Stderr, "  q, Ctrl+c    : quit")
-		os.Exit(1)
-	}
-
-	// Create buffer
-	buf := buffer.New(bufferCapacity)
+	columns := flag.String("columns", "", "comma separated fields for the table view (e.g. time,level,msg,request_id); starts in table view")
+	format := flag.String("format", "auto", "input format: "+strings.Join(parser.Formats(), ", "))
+	minLevel := flag.String("level", "", "only show entries at or above this level (trace, debug, info, warn, error, fatal)")
+	filter := flag.String("filter", "", "only show entries matching this query (e.g. 'level=error && status>=500')")
+	multilineEnabled := flag.Bool("multiline", true, "merge stack traces and other continuation lines into the previous entry")
+	multilineTimeout := flag.Duration("multiline-timeout", multiline.DefaultTimeout, "how long to wait for more continuation lines before showing an entry")
+	since := flag.String("since", "", "only show entries at or after this time: a duration ago (10m), a date or a timestamp")
+	until := flag.String("until", "", "only show entries at or before this time: a duration ago (10m), a date or a timestamp")
+	maxDisk := flag.String("max-disk", "1GB", "disk space for history older than the newest entries kept in memory (e.g. 512MB, 0 to keep memory only)")
+	noTUI := flag.Bool("no-tui", false, "write matching entries to stdout instead of starting the TUI (default when stdout is not a terminal)")
+	outputFormat := flag.String("output", "", "output format without the TUI: pretty, compact, jsonl, csv or markdown (default pretty on a terminal, compact with --columns, jsonl otherwise)")
+	head := flag.Int("head", 0, "without the TUI, stop after this many matching entries")
+	var sources specList
+	flag.Var(&sources, "s", "add a labeled input, merged with the others by timestamp: name=file or name='command args' (repeatable)")
+	groupBy := flag.String("group-by", "", "correlation field for r and I, e.g. request_id (default: detected from trace_id, request_id, ...)")
+	configPath := flag.String("config", "", "configuration file with themes, keys, columns, views and highlight rules (default: ~/.config/lg/config.yaml)")
+	themeName := flag.String("theme", "", "color theme: dark, light, none or one from the config file (default: the config's theme, none with NO_COLOR)")
+	viewName := flag.String("view", "", "start with a saved view from the config file; flags override its filter, columns and level")
+	listen := flag.String("listen", "", "receive logs over the network: tcp://:5170 (newline-delimited), udp://:5514 (syslog datagrams) or http://:8088/ingest (POSTed batches)")
+	var follow bool
+	flag.BoolVar(&follow, "follow", false, "keep reading files as they grow, like tail -f, across log rotation")
+	flag.BoolVar(&follow, "f", false, "shorthand for --follow")
+	var contextEntries int
+	flag.IntVar(&contextEntries, "context", 0, "also show this many entries before and after each --filter match, like grep -C")
+	flag.IntVar(&contextEntries, "C", 0, "shorthand for --context")
+	sortKeys := flag.Bool("sort-keys", false, "show fields in alphabetical order instead of the order they were read")
+	redactValues := flag.Bool("redact", false, "mask passwords, tokens, authorization headers, JWTs, emails and card numbers, plus the config's redact rules")
+	flag.Usage = usage
+	flag.Parse()
+
+	lineParser, err := parser.ParserFor(*format)
+	if err != nil {
+		fatalf(2, "%v", err)
+	}
+
+	var cfg *config.Config
+	if *configPath != "" {
+		cfg, err = config.Load(*configPath)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		fatalf(2, "config: %v", err)
+	}
+
+	// Entries are colored as they are parsed, so the theme comes first
+	var t theme.Theme
+	switch {
+	case *themeName != "":
+		t, err = cfg.LookupTheme(*themeName)
+	case os.Getenv("NO_COLOR") != "":
+		t = theme.None
+	case cfg.Theme != "":
+		t, err = cfg.LookupTheme(cfg.Theme)
+	default:
+		t = theme.Dark
+	}
+	if err != nil {
+		fatalf(2, "%v", err)
+	}
+	setTheme(t)
+
+	// A saved view stands in for the flags that were not given
+	if *viewName != "" {
+		v, err := cfg.LookupView(*viewName)
+		if err != nil {
+			fatalf(2, "--view: %v", err)
+		}
+		given := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		if !given["filter"] {
+			*filter = v.Filter
+		}
+		if !given["columns"] && len(v.Columns) > 0 {
+			*columns = strings.Join(v.Columns, ",")
+		}
+		if !given["level"] {
+			*minLevel = v.Level
+		}
+	}
+	columnList := table.ParseColumns(*columns)
+	if len(columnList) == 0 {
+		columnList = cfg.Columns
+	}
+
+	customKeys := make(map[string][]string, len(cfg.Keys))
+	for action, keys := range cfg.Keys {
+		customKeys[action] = keys
+	}
+	keys, err := tui.ParseKeys(customKeys)
+	if err != nil {
+		fatalf(2, "config: %v", err)
+	}
+
+	var level parser.Level
+	if *minLevel != "" {
+		var ok bool
+		if level, ok = parser.ParseLevel(*minLevel); !ok {
+			fatalf(2, "unknown level %q", *minLevel)
+		}
+	}
+
+	q, err := query.Compile(*filter)
+	if err != nil {
+		fatalf(2, "--filter: %v", err)
+	}
+	if contextEntries < 0 {
+		fatalf(2, "--context must be 0 or more, got %d", contextEntries)
+	}
+
+	// The bell goes to stderr, which stays the terminal while stdout is
+	// piped
+	ruleSet, err := rules.New(cfg.Rules, os.Stderr)
+	if err != nil {
+		fatalf(2, "config: %v", err)
+	}
+	redactor, err := redact.New(cfg.Redact.Keys, cfg.Redact.Patterns)
+	if err != nil {
+		fatalf(2, "config: %v", err)
+	}
+	if *redactValues {
+		ruleSet.Redact(redactor)
+	}
+
+	now := time.Now()
+	sinceTime, err := parseTimeFlag("since", *since, now)
+	if err != nil {
+		fatalf(2, "%v", err)
+	}
+	untilTime, err := parseTimeFlag("until", *until, now)
+	if err != nil {
+		fatalf(2, "%v", err)
+	}
+
+	// Each input is read and grouped into entries on its own, so stack
+	// traces from different inputs cannot interleave
+	var (
+		inputs []input
+		jobs   []*job
+		files  []io.Reader // Opened files, for the loading progress
+
+		listenAddr string
+		listener   *source.Listener
+	)
+	for _, spec := range sources {
+		if spec.File != "" {
+			readers, err := source.Open([]string{spec.File}, follow)
+			if err != nil {
+				fatalf(1, "%v", err)
+			}
+			files = append(files, readers...)
+			inputs = append(inputs, readInput(spec.Name, readers))
+			continue
+		}
+		j := newJob(spec.Name, source.NewCommand(spec.Command), spec.Name, spec.Name+"/"+source.Stderr)
+		jobs = append(jobs, j)
+		inputs = append(inputs, j.stdout, j.stderr)
+	}
+	switch {
+	case isCommand():
+		command := source.NewCommand(flag.Args())
+		j := newJob(command.String(), command, source.Stdout, source.Stderr)
+		jobs = append(jobs, j)
+		inputs = append(inputs, j.stdout, j.stderr)
+
+	case flag.NArg() > 0:
+		readers, err := source.Open(flag.Args(), follow)
+		if err != nil {
+			fatalf(1, "%v", err)
+		}
+		files = append(files, readers...)
+		inputs = append(inputs, fileInputs(readers)...)
+
+	case len(inputs) == 0 && *listen == "":
+		if isTerminal(os.Stdin) {
+			usage()
+			os.Exit(1)
+		}
+		stdin, err := source.Decompress(os.Stdin)
+		if err != nil {
+			fatalf(1, "stdin: %v", err)
+		}
+		inputs = append(inputs, readInput("", []io.Reader{stdin}))
+	}
+
+	timeout := *multilineTimeout
+	if !*multilineEnabled {
+		timeout = 0
+	}
+	entries := make(chan *parser.LogEntry, 1024)
+	// Every connection to the listener is an input of its own
+	var conns chan source.Conn
+	if *listen != "" {
+		listener, err = source.Listen(*listen)
+		if err != nil {
+			fatalf(1, "--listen: %v", err)
+		}
+		defer listener.Close()
+		listenAddr = listener.Addr()
+		conns = make(chan source.Conn)
+		go func() {
+			if err := listener.Serve(conns); err != nil {
+				fmt.Fprintf(os.Stderr, "Error listening: %v\n", err)
+			}
+		}()
+	}
+	go parseInputs(inputs, conns, lineParser, ruleSet, timeout, entries)
+
+	if *noTUI || !isTerminal(os.Stdout) {
+		opts := output.Options{
+			Columns:  columnList,
+			Query:    q,
+			MinLevel: level,
+			Since:    sinceTime,
+			Until:    untilTime,
+			Head:     *head,
+			Context:  contextEntries,
+			SortKeys: *sortKeys,
+		}
+		if *redactValues {
+			opts.Redact = redactor
+		}
+		switch {
+		case *outputFormat != "":
+			if opts.Format, err = output.ParseFormat(*outputFormat); err != nil {
+				fatalf(2, "%v", err)
+			}
+		case *columns != "":
+			opts.Format = output.Compact
+		case isTerminal(os.Stdout):
+			opts.Format = output.Pretty
+		default:
+			opts.Format = output.JSONL
+		}
+		if listenAddr != "" {
+			fmt.Fprintf(os.Stderr, "Listening on %s\n", listenAddr)
+		}
+		// Commands run once; their exit status becomes lg's
+		for _, j := range jobs {
+			go j.runOnce()
+		}
+		if err := writeEntries(entries, opts); err != nil {
+			fatalf(1, "%v", err)
+		}
+		if listener != nil {
+			if n := listener.Dropped(); n > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: dropped %d UDP lines that arrived faster than they were written\n", n)
+			}
+		}
+		ruleSet.Close()
+		exitWithJobs(jobs)
+		return
+	}
+
+	diskLimit, err := buffer.ParseSize(*maxDisk)
+	if err != nil {
+		fatalf(2, "--max-disk: %v", err)
+	}
+
+	// Create buffer, spilling older history to disk
+	buf, err := buffer.NewWithDisk(buffer.DefaultCapacity, diskLimit)
+	if err != nil {
+		fatalf(1, "%v", err)
+	}
 
 	// Create TUI model
-	model := tui.New(buf)
+	opts := tui.Options{
+		Columns:   columnList,
+		TableMode: *columns != "",
+		Filter:    *filter,
+		Context:   contextEntries,
+		SortKeys:  *sortKeys,
+		Redactor:  redactor,
+		Redact:    *redactValues,
+		MinLevel:  level,
+		Since:     sinceTime,
+		Until:     untilTime,
+	}
+	opts.Listen = listenAddr
+	if listener != nil {
+		opts.Dropped = listener.Dropped
+	}
+	opts.GroupBy = *groupBy
+	opts.Rules = ruleSet
+	opts.Keys = keys
+	opts.Views = cfg.Views
+	opts.View = *viewName
+	if len(files) > 0 {
+		opts.Progress = func() (int64, int64) { return source.Progress(files) }
+	}
+	for _, j := range jobs {
+		opts.Commands = append(opts.Commands, j.name)
+	}
+	if len(jobs) > 0 {
+		opts.Restart = func() {
+			for _, j := range jobs {
+				j.cmd.Restart()
+			}
+		}
+	}
+	// The program and the clipboard share the terminal
+	out := tui.NewOutput(os.Stdout)
+	opts.Output = out
+	model := tui.New(buf, opts)
 
-	// Create program with stdin reading disabled (we'll read from stdin ourselves)
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
+		tea.WithOutput(out),
 	)
 
-	// Start reading stdin in a goroutine
+	// Entries reach the TUI in one batch per frame
+	go tui.Forward(p, entries)
+
+	// Commands run until lg quits, restarted on request
+	for _, j := range jobs {
+		go j.cmd.Loop(j.stdout.lines, j.stderr.lines, func(running bool, err error) {
+			p.Send(tui.CommandMsg{Name: j.name, Running: running, Err: err})
+		})
+	}
+
+	// Run the program
+	_, err = p.Run()
+	for _, j := range jobs {
+		j.cmd.Close()
+	}
+	ruleSet.Close()
+	buf.Close()
+	if err != nil {
+		fatalf(1, "running program: %v", err)
+	}
+	if err := buf.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: disk history disabled: %v\n", err)
+	}
+}
+
+// setTheme colors everything rendered from now on with a theme
+func setTheme(t theme.Theme) {
+	parser.SetTheme(t)
+	table.SetTheme(t)
+	output.SetTheme(t)
+	tui.SetTheme(t)
+}
+
+// specList collects the -s flags
+type specList []source.Spec
+
+func (l *specList) String() string {
+	names := make([]string, len(*l))
+	for i, s := range *l {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *specList) Set(value string) error {
+	spec, err := source.ParseSpec(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, spec)
+	return nil
+}
+
+// input is a stream of lines; the entries read from it are tagged with its
+// name
+type input struct {
+	name  string
+	lines chan string
+}
+
+// readInput reads lines from readers into a new input
+func readInput(name string, readers []io.Reader) input {
+	in := input{name: name, lines: make(chan string, 1024)}
 	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		// Increase buffer size for long lines
-		const maxScanTokenSize = 1024 * 1024 // 1MB
-		scanBuf := make([]byte, maxScanTokenSize)
-		scanner.Buffer(scanBuf, maxScanTokenSize)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-			if entry := parser.Parse(line); entry != nil {
-				p.Send(tui.AddLogEntry(entry))
+		if err := source.ReadAll(readers, in.lines); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		}
+	}()
+	return in
+}
+
+// fileInputs reads every file into an input of its own. Each is grouped
+// into entries on its own, so stack traces stay with their file, and merged
+// with the others by timestamp, so rotated files read in time order.
+func fileInputs(readers []io.Reader) []input {
+	inputs := make([]input, len(readers))
+	for i, r := range readers {
+		inputs[i] = readInput("", []io.Reader{r})
+	}
+	return inputs
+}
+
+// parseInputs groups the lines of every input, and of every connection on
+// conns, into entries, merging stack traces unless timeout is 0, parses
+// them and applies the rules. Entries from several inputs are merged by timestamp. It closes
+// entries once every input is done.
+func parseInputs(inputs []input, conns <-chan source.Conn, p parser.Parser, r *rules.Set, timeout time.Duration, entries chan<- *parser.LogEntry) {
+	parsed := make([]<-chan *parser.LogEntry, 0, len(inputs)+1)
+	for _, in := range inputs {
+		ch := make(chan *parser.LogEntry, 1024)
+		parsed = append(parsed, ch)
+		go func() {
+			defer close(ch)
+			parseLines(in.name, in.lines, p, r, timeout, ch)
+		}()
+	}
+
+	// Connections come and go, so their entries are passed on as they
+	// arrive rather than merged with each other
+	if conns != nil {
+		ch := make(chan *parser.LogEntry, 1024)
+		parsed = append(parsed, ch)
+		go func() {
+			var wg sync.WaitGroup
+			for c := range conns {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					parseLines(c.Name, c.Lines, p, r, timeout, ch)
+				}()
 			}
+			wg.Wait()
+			close(ch)
+		}()
+	}
+	merge.Merge(parsed, merge.DefaultWait, entries)
+}
+
+// parseLines parses the lines of one input into entries tagged with its
+// name. Rules are applied here, so their actions fire whatever the TUI
+// shows.
+func parseLines(name string, lines <-chan string, p parser.Parser, r *rules.Set, timeout time.Duration, entries chan<- *parser.LogEntry) {
+	multiline.New().Run(lines, timeout, func(group []string) {
+		if entry := parser.ParseLines(group, p); entry != nil {
+			entry.Source = name
+			r.Apply(entry)
+			entries <- entry
 		}
+	})
+}
+
+// job is a command whose stdout and stderr are inputs
+type job struct {
+	name           string // Shown in the status bar
+	cmd            *source.Command
+	stdout, stderr input
+	done           chan error // Result of runOnce
+}
+
+// newJob creates the inputs for a command, tagging its output streams
+func newJob(name string, cmd *source.Command, stdoutName, stderrName string) *job {
+	return &job{
+		name:   name,
+		cmd:    cmd,
+		stdout: input{name: stdoutName, lines: make(chan string, 1024)},
+		stderr: input{name: stderrName, lines: make(chan string, 1024)},
+		done:   make(chan error, 1),
+	}
+}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+// runOnce runs the command to the end and closes its inputs
+func (j *job) runOnce() {
+	j.done <- j.cmd.Run(j.stdout.lines, j.stderr.lines)
+	close(j.stdout.lines)
+	close(j.stderr.lines)
+}
+
+// isCommand reports whether the arguments after the flags follow "--", to
+// run a command instead of reading files
+func isCommand() bool {
+	n := flag.NArg()
+	return n > 0 && len(os.Args) > n && os.Args[len(os.Args)-n-1] == "--"
+}
+
+// exitWithJobs exits with the status of the first command that failed,
+// once all ran to the end. Commands still running, because --head was
+// reached, are stopped instead.
+func exitWithJobs(jobs []*job) {
+	errs := make([]error, len(jobs))
+	for i, j := range jobs {
+		select {
+		case errs[i] = <-j.done:
+		default:
+			for _, j := range jobs {
+				j.cmd.Close()
+			}
+			return
 		}
-	}()
+	}
 
-	// Run the program
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		os.Exit(1)
+	for i, err := range errs {
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+		case errors.As(err, &exitErr) && exitErr.Exited():
+			os.Exit(exitErr.ExitCode())
+		default:
+			fatalf(1, "%s: %s", jobs[i].cmd, source.ExitStatus(err))
+		}
+	}
+}
+
+// writeEntries streams matching entries to stdout until the input ends or
+// --head is reached. Output is flushed whenever the input goes quiet, so
+// followed streams show up as they arrive.
+func writeEntries(entries <-chan *parser.LogEntry, opts output.Options) error {
+	w := output.New(os.Stdout, opts)
+	for entry := range entries {
+		more, err := w.Write(entry)
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+		if len(entries) == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
 	}
+	return w.Flush()
+}
+
+// parseTimeFlag parses a --since/--until value, leaving the bound open when
+// the flag is empty
+func parseTimeFlag(name, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := parser.ParseTimeBound(value, now, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// fatalf prints an error and exits with the given status
+func fatalf(status int, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	os.Exit(status)
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thalessoares/lg/internal/parser"
+	"github.com/thalessoares/lg/internal/source"
+)
+
+func TestParseInputs_FilesKeepTheirStackTraces(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a", "b"} {
+		var b strings.Builder
+		for i := range 5000 {
+			b.WriteString(`{"time":"2025-01-01T10:00:00Z","file":"` + name + `","level":"error","msg":"boom"}` + "\n")
+			b.WriteString("\tat com." + name + ".Pool.get(Pool.java:42)\n")
+			b.WriteString("\tat com." + name + ".Main.run(Main.java:" + strconv.Itoa(i) + ")\n")
+			b.WriteString("\tat com." + name + ".Main.main(Main.java:1)\n")
+		}
+		path := filepath.Join(dir, name+".log")
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	readers, err := source.Open(paths, false)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	inputs := fileInputs(readers)
+	entries := make(chan *parser.LogEntry, 1024)
+	go parseInputs(inputs, nil, nil, nil, time.Second, entries)
+
+	n := 0
+	for e := range entries {
+		n++
+		v, _ := e.Parsed.Get("file")
+		name, _ := v.(string)
+		if len(e.Continuation) != 3 {
+			t.Fatalf("entry of %s has %d continuation lines, want 3: %q", name, len(e.Continuation), e.Continuation)
+		}
+		for _, line := range e.Continuation {
+			if !strings.Contains(line, "com."+name+".") {
+				t.Fatalf("entry of %s has a line from the other file: %q", name, line)
+			}
+		}
+	}
+	if n != 10000 {
+		t.Errorf("got %d entries, want 10000", n)
+	}
+}
@@ -13,4 +13,11 @@ echo "Plain text log line"
 echo '{"level":"info","message":"Request completed","method":"GET","path":"/api/users","status":200,"duration_ms":45,"timestamp":"2025-01-01T10:00:05Z"}'
 echo '{"level":"debug","message":"Cache hit","key":"user:42","ttl":3600,"timestamp":"2025-01-01T10:00:06Z"}'
 echo 'Not valid JSON {'
+echo '{"level":"error","message":"Unhandled exception","timestamp":"2025-01-01T10:00:06Z"}'
+echo 'java.lang.IllegalStateException: pool exhausted'
+printf '\tat com.example.db.Pool.acquire(Pool.java:42)\n'
+printf '\tat com.example.api.UserHandler.get(UserHandler.java:17)\n'
+echo 'Caused by: java.net.SocketTimeoutException: connect timed out'
+printf '\tat java.net.Socket.connect(Socket.java:601)\n'
+printf '\t... 2 more\n'
 echo '{"level":"info","message":"Graceful shutdown initiated","active_connections":10,"timestamp":"2025-01-01T10:00:07Z"}'